@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Chart analysis constants
+const (
+	NPS_WINDOW        = 1.0  // Seconds over which the peak notes per second are counted
+	CHORD_WINDOW      = 0.01 // Seconds within which notes are played as one chord
+	STREAM_MAX_GAP    = 0.2  // Longest gap between notes that keeps a stream going
+	STREAM_MIN_NOTES  = 8    // Fewest notes counted as a stream
+	MAX_STREAMS       = 3    // Longest streams reported
+	STREAM_FULL_NOTES = 32   // Stream length that counts fully towards the difficulty
+	MAX_DIFFICULTY    = 10.0 // Highest difficulty rating
+)
+
+// ChartStats describes how a chart plays
+type ChartStats struct {
+	File              string     `json:"file,omitempty"`
+	Notes             int        `json:"notes"`
+	Length            float64    `json:"length"` // Seconds from the first note to the end of the last
+	AverageNPS        float64    `json:"average_nps"`
+	PeakNPS           float64    `json:"peak_nps"`
+	PeakTime          float64    `json:"peak_time"` // Start of the busiest NPS_WINDOW
+	LaneCounts        [3]int     `json:"lane_counts"`
+	LaneShares        [3]float64 `json:"lane_shares"`
+	ChordRatio        float64    `json:"chord_ratio"`         // Share of hits that are chords
+	SustainRatio      float64    `json:"sustain_ratio"`       // Share of notes that must be held
+	Streams           []Stream   `json:"streams"`             // Longest streams, longest first
+	LaneChangeEntropy float64    `json:"lane_change_entropy"` // Bits of surprise in the lanes of each next hit
+	Difficulty        float64    `json:"difficulty"`          // Rating from 0 to MAX_DIFFICULTY
+}
+
+// Stream is a run of hits without a break longer than STREAM_MAX_GAP
+type Stream struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"` // Start of the last note
+	Notes     int     `json:"notes"`
+	NPS       float64 `json:"nps"`
+}
+
+// chartHit is the notes played together at one time, as a set of lanes
+type chartHit struct {
+	Time  float64
+	Lanes int // Bit for each lane
+	Notes int
+}
+
+// AnalyzeChart computes the statistics and difficulty rating of lane-assigned
+// notes, judging sustains with a judgement profile
+func AnalyzeChart(notes []MIDINote, judgement JudgementProfile) ChartStats {
+	stats := ChartStats{Notes: len(notes), Streams: make([]Stream, 0)}
+	if len(notes) == 0 {
+		return stats
+	}
+	
+	sorted := make([]MIDINote, len(notes))
+	copy(sorted, notes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartTime < sorted[j].StartTime
+	})
+	
+	end := 0.0
+	sustains := 0
+	for _, note := range sorted {
+		end = max(end, note.StartTime+note.Duration)
+		if note.Lane >= 0 && note.Lane < len(stats.LaneCounts) {
+			stats.LaneCounts[note.Lane]++
+		}
+		if judgement.IsSustain(note.Duration) {
+			sustains++
+		}
+	}
+	stats.Length = end - sorted[0].StartTime
+	stats.AverageNPS = float64(len(sorted)) / max(stats.Length, NPS_WINDOW)
+	stats.SustainRatio = float64(sustains) / float64(len(sorted))
+	for lane, count := range stats.LaneCounts {
+		stats.LaneShares[lane] = float64(count) / float64(len(sorted))
+	}
+	
+	// The busiest window starts on a note
+	first := 0
+	for last, note := range sorted {
+		for note.StartTime-sorted[first].StartTime >= NPS_WINDOW {
+			first++
+		}
+		if count := float64(last - first + 1); count/NPS_WINDOW > stats.PeakNPS {
+			stats.PeakNPS = count / NPS_WINDOW
+			stats.PeakTime = sorted[first].StartTime
+		}
+	}
+	
+	hits := chartHits(sorted)
+	chords := 0
+	for _, hit := range hits {
+		if hit.Lanes&(hit.Lanes-1) != 0 {
+			chords++
+		}
+	}
+	stats.ChordRatio = float64(chords) / float64(len(hits))
+	stats.Streams = longestStreams(hits)
+	stats.LaneChangeEntropy = laneChangeEntropy(hits)
+	stats.Difficulty = difficultyRating(stats)
+	return stats
+}
+
+// chartHits groups notes sorted by start time into the chords played together
+func chartHits(sorted []MIDINote) []chartHit {
+	hits := make([]chartHit, 0, len(sorted))
+	for _, note := range sorted {
+		last := len(hits) - 1
+		if last < 0 || note.StartTime-hits[last].Time > CHORD_WINDOW {
+			hits = append(hits, chartHit{Time: note.StartTime})
+			last++
+		}
+		if note.Lane >= 0 && note.Lane < 3 {
+			hits[last].Lanes |= 1 << note.Lane
+		}
+		hits[last].Notes++
+	}
+	return hits
+}
+
+// longestStreams returns up to MAX_STREAMS streams of at least STREAM_MIN_NOTES, longest first
+func longestStreams(hits []chartHit) []Stream {
+	streams := make([]Stream, 0)
+	addStream := func(run []chartHit) {
+		stream := Stream{StartTime: run[0].Time, EndTime: run[len(run)-1].Time}
+		for _, hit := range run {
+			stream.Notes += hit.Notes
+		}
+		if stream.Notes < STREAM_MIN_NOTES || stream.EndTime <= stream.StartTime {
+			return
+		}
+		stream.NPS = float64(stream.Notes) / (stream.EndTime - stream.StartTime)
+		streams = append(streams, stream)
+	}
+	
+	start := 0
+	for i := 1; i <= len(hits); i++ {
+		if i == len(hits) || hits[i].Time-hits[i-1].Time > STREAM_MAX_GAP {
+			addStream(hits[start:i])
+			start = i
+		}
+	}
+	
+	sort.SliceStable(streams, func(i, j int) bool {
+		return streams[i].Notes > streams[j].Notes
+	})
+	if len(streams) > MAX_STREAMS {
+		streams = streams[:MAX_STREAMS]
+	}
+	return streams
+}
+
+// laneChangeEntropy returns the Shannon entropy in bits of the moves from each
+// hit's lanes to the next hit's, 0 when the chart always moves the same way
+func laneChangeEntropy(hits []chartHit) float64 {
+	if len(hits) < 2 {
+		return 0
+	}
+	moves := make(map[[2]int]int)
+	for i := 1; i < len(hits); i++ {
+		moves[[2]int{hits[i-1].Lanes, hits[i].Lanes}]++
+	}
+	
+	entropy := 0.0
+	total := float64(len(hits) - 1)
+	for _, count := range moves {
+		p := float64(count) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// difficultyRating weighs a chart's statistics into a rating from 0 to
+// MAX_DIFFICULTY, rounded to one decimal. Density counts most, then chords,
+// unpredictable lane changes, long streams and sustains.
+func difficultyRating(stats ChartStats) float64 {
+	if stats.Notes == 0 {
+		return 0
+	}
+	
+	// Seven lane sets can follow each other 49 ways
+	maxEntropy := math.Log2(49)
+	longest := 0
+	if len(stats.Streams) > 0 {
+		longest = stats.Streams[0].Notes
+	}
+	
+	rating := 0.6*stats.AverageNPS + 0.25*stats.PeakNPS +
+		2*stats.ChordRatio +
+		2*stats.LaneChangeEntropy/maxEntropy +
+		1.5*min(float64(longest)/STREAM_FULL_NOTES, 1) +
+		0.5*stats.SustainRatio
+	rating = min(max(rating, 0), MAX_DIFFICULTY)
+	return math.Round(rating*10) / 10
+}
+
+// AnalyzeSong computes the statistics of a loaded song's guitar track
+func AnalyzeSong(midiProcessor *MIDIProcessor, judgement JudgementProfile) (ChartStats, error) {
+	track, err := midiProcessor.FindGuitarTrack()
+	if err != nil {
+		return ChartStats{}, err
+	}
+	stats := AnalyzeChart(track.Notes, judgement)
+	stats.File = midiProcessor.FilePath()
+	return stats, nil
+}
+
+// RateSong returns the difficulty rating of a song file, 0 if it cannot be read
+func RateSong(path string) float64 {
+	midiProcessor, err := loadChartQuietly(path)
+	if err != nil {
+		return 0
+	}
+	stats, err := AnalyzeSong(midiProcessor, StandardJudgement())
+	if err != nil {
+		return 0
+	}
+	return stats.Difficulty
+}
+
+// RunStats runs the stats command on its arguments, printing the chart's
+// statistics as JSON, and returns the exit code
+func RunStats(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	judgementName := flags.String("judgement", "Standard", "timing profile whose sustain threshold is used")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: ghero stats [-judgement name] <file.mid>\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 1
+	}
+	
+	judgement, err := ResolveJudgementProfile(*judgementName)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load judgement profile: %v\n", err)
+		return 1
+	}
+	
+	midiProcessor, err := loadChartQuietly(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load MIDI file: %v\n", err)
+		return 1
+	}
+	stats, err := AnalyzeSong(midiProcessor, judgement)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to analyze chart: %v\n", err)
+		return 1
+	}
+	
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(stats); err != nil {
+		fmt.Fprintf(stderr, "Failed to write stats: %v\n", err)
+		return 1
+	}
+	return 0
+}
\ No newline at end of file
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// assignedNote returns a note in a lane that is already assigned to it
+func assignedNote(lane int, start float64, duration float64) MIDINote {
+	note := laneNote(lane, start, duration)
+	note.Lane = lane
+	return note
+}
+
+// alternatingNotes returns count short notes a spacing apart, alternating lanes A and W
+func alternatingNotes(count int, spacing float64) []MIDINote {
+	notes := make([]MIDINote, 0, count)
+	for i := 0; i < count; i++ {
+		notes = append(notes, assignedNote(i%2, float64(i)*spacing, 0.05))
+	}
+	return notes
+}
+
+func TestAnalyzeChart(t *testing.T) {
+	// Sixteen notes at 8 per second, then a held chord two seconds later
+	notes := alternatingNotes(16, 0.125)
+	notes = append(notes, assignedNote(0, 4, 1), assignedNote(2, 4, 1))
+	
+	stats := AnalyzeChart(notes, StandardJudgement())
+	if stats.Notes != 18 || stats.Length != 5 {
+		t.Errorf("Notes = %d over %vs, want 18 over 5s", stats.Notes, stats.Length)
+	}
+	if math.Abs(stats.AverageNPS-3.6) > 1e-9 || stats.PeakNPS != 8 || stats.PeakTime != 0 {
+		t.Errorf("NPS = %v average, %v peak at %vs, want 3.6 and 8 at 0s", stats.AverageNPS, stats.PeakNPS, stats.PeakTime)
+	}
+	if stats.LaneCounts != [3]int{9, 8, 1} {
+		t.Errorf("LaneCounts = %v, want [9 8 1]", stats.LaneCounts)
+	}
+	if math.Abs(stats.ChordRatio-1.0/17) > 1e-9 {
+		t.Errorf("ChordRatio = %v, want one chord in 17 hits", stats.ChordRatio)
+	}
+	if math.Abs(stats.SustainRatio-2.0/18) > 1e-9 {
+		t.Errorf("SustainRatio = %v, want the two held notes of 18", stats.SustainRatio)
+	}
+	if len(stats.Streams) != 1 || stats.Streams[0].Notes != 16 || stats.Streams[0].EndTime != 1.875 {
+		t.Errorf("Streams = %+v, want the 16 note run ending at 1.875s", stats.Streams)
+	}
+	if stats.Difficulty <= 0 || stats.Difficulty > MAX_DIFFICULTY {
+		t.Errorf("Difficulty = %v, want a rating in (0, %v]", stats.Difficulty, MAX_DIFFICULTY)
+	}
+	
+	if empty := AnalyzeChart(nil, StandardJudgement()); empty.Difficulty != 0 || empty.Notes != 0 {
+		t.Errorf("AnalyzeChart(nil) = %+v, want an empty chart rated 0", empty)
+	}
+}
+
+func TestLaneChangeEntropy(t *testing.T) {
+	// Alternating two lanes always makes one of two moves, equally often
+	stats := AnalyzeChart(alternatingNotes(9, 0.5), StandardJudgement())
+	if stats.LaneChangeEntropy != 1 {
+		t.Errorf("LaneChangeEntropy = %v alternating, want 1 bit", stats.LaneChangeEntropy)
+	}
+	
+	repeated := make([]MIDINote, 0)
+	for i := 0; i < 8; i++ {
+		repeated = append(repeated, assignedNote(1, float64(i)*0.5, 0.05))
+	}
+	if entropy := AnalyzeChart(repeated, StandardJudgement()).LaneChangeEntropy; entropy != 0 {
+		t.Errorf("LaneChangeEntropy = %v in one lane, want 0", entropy)
+	}
+}
+
+func TestDifficultyRisesWithDensity(t *testing.T) {
+	slow := AnalyzeChart(alternatingNotes(20, 0.5), StandardJudgement())
+	fast := AnalyzeChart(alternatingNotes(80, 0.125), StandardJudgement())
+	if fast.Difficulty <= slow.Difficulty {
+		t.Errorf("fast chart rated %v, want more than the slow chart's %v", fast.Difficulty, slow.Difficulty)
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	path := writeMIDIFile(t, 480, midiTrackChunk(
+		midiEvent(0, 0x90, 52, 100), midiEvent(240, 0x80, 52, 0),
+		midiEvent(0, 0x90, 64, 100), midiEvent(240, 0x80, 64, 0),
+		midiEvent(0, 0x90, 76, 100), midiEvent(960, 0x80, 76, 0),
+	))
+	
+	var stdout, stderr bytes.Buffer
+	if code := RunStats([]string{path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("RunStats exited with %d: %s", code, stderr.String())
+	}
+	var stats ChartStats
+	if err := json.Unmarshal(stdout.Bytes(), &stats); err != nil {
+		t.Fatalf("output is not JSON stats: %v\n%s", err, stdout.String())
+	}
+	if stats.Notes != 3 || stats.LaneCounts != [3]int{1, 1, 1} || stats.Difficulty != RateSong(path) {
+		t.Errorf("stats = %+v, want 3 notes, one per lane, rated as on song select", stats)
+	}
+	
+	if code := RunStats(nil, &stdout, &stderr); code == 0 {
+		t.Errorf("RunStats without a file should fail")
+	}
+}
+
+func TestRateSongSkipsUnparsedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.mid")
+	if err := os.WriteFile(path, []byte("not a MIDI file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// The game loads test notes in place of the file, which must not be rated
+	if rating := RateSong(path); rating != 0 {
+		t.Errorf("RateSong = %v for a file that is not MIDI, want 0", rating)
+	}
+}
\ No newline at end of file
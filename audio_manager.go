@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/faiface/beep"
@@ -22,6 +23,8 @@ type AudioManager struct {
 	musicStream   *MIDIAudioStreamer
 	currentTime   float64
 	startTime     time.Time
+	songOffset    float64 // Song time playback started at
+	speed         float64 // Song seconds played per real second
 }
 
 // MIDIAudioStreamer generates audio from MIDI notes
@@ -30,6 +33,12 @@ type MIDIAudioStreamer struct {
 	sampleRate   beep.SampleRate
 	currentSample int64
 	startTime    time.Time
+	songOffset   float64 // Song time at startTime
+	speed        float64 // Song seconds played per real second, notes keep their pitch
+	
+	// Whammy pitch bend per lane, set from the game thread
+	pitchBends   [3]atomic.Uint64 // math.Float64bits of 0 to 1
+	timeWarp     [3]float64       // Extra phase time built up by bending, in seconds
 }
 
 // NewAudioManager creates a new audio manager
@@ -70,6 +79,11 @@ func (am *AudioManager) LoadMIDITrack(notes []MIDINote) error {
 
 // StartPlayback begins audio playback
 func (am *AudioManager) StartPlayback() error {
+	return am.StartPlaybackAt(0, 1)
+}
+
+// StartPlaybackAt begins audio playback from a song time at a playback speed
+func (am *AudioManager) StartPlaybackAt(songTime float64, speed float64) error {
 	fmt.Printf("DEBUG: StartPlayback called - initialized=%v, musicStream=%v\n", 
 		am.isInitialized, am.musicStream != nil)
 	
@@ -83,7 +97,11 @@ func (am *AudioManager) StartPlayback() error {
 	}
 	
 	am.startTime = time.Now()
+	am.songOffset = songTime
+	am.speed = speed
 	am.musicStream.startTime = am.startTime
+	am.musicStream.songOffset = songTime
+	am.musicStream.speed = speed
 	am.musicStream.currentSample = 0
 	
 	fmt.Printf("DEBUG: Audio startTime set to %v, notes count: %d\n", 
@@ -117,7 +135,7 @@ func (am *AudioManager) StopPlayback() {
 // Update updates the audio manager state
 func (am *AudioManager) Update() {
 	if am.isPlaying && !am.startTime.IsZero() {
-		am.currentTime = time.Since(am.startTime).Seconds()
+		am.currentTime = am.songOffset + time.Since(am.startTime).Seconds()*am.speed
 	}
 }
 
@@ -136,6 +154,14 @@ func (am *AudioManager) SetVolume(volume float64) {
 	am.volume = volume
 }
 
+// SetPitchBend bends the notes of a lane down by up to WHAMMY_BEND_SEMITONES, for amounts from 0 to 1
+func (am *AudioManager) SetPitchBend(lane int, amount float64) {
+	if am.musicStream == nil || lane < 0 || lane >= len(am.musicStream.pitchBends) {
+		return
+	}
+	am.musicStream.pitchBends[lane].Store(math.Float64bits(amount))
+}
+
 // IsPlaying returns whether audio is currently playing
 func (am *AudioManager) IsPlaying() bool {
 	return am.isPlaying
@@ -154,7 +180,10 @@ func (ms *MIDIAudioStreamer) Stream(samples [][2]float64) (n int, ok bool) {
 		return 0, false
 	}
 	
-	currentTime := time.Since(ms.startTime).Seconds()
+	// Notes are picked by song time, which runs slower in practice, while waves
+	// follow real time so slowed down notes keep their pitch
+	elapsed := time.Since(ms.startTime).Seconds()
+	currentTime := ms.songOffset + elapsed*ms.speed
 	
 	// Debug: Print timing info less frequently
 	if ms.currentSample%(int64(ms.sampleRate)*5) == 0 { // Every 5 seconds
@@ -168,12 +197,24 @@ func (ms *MIDIAudioStreamer) Stream(samples [][2]float64) (n int, ok bool) {
 			currentTime, activeNotes)
 	}
 	
+	// Bent lanes play slower, so their phase falls behind a little every sample
+	var warpPerSample [3]float64
+	for lane := range ms.pitchBends {
+		bend := math.Float64frombits(ms.pitchBends[lane].Load())
+		ratio := math.Pow(2.0, -WHAMMY_BEND_SEMITONES*bend/12.0)
+		warpPerSample[lane] = (ratio - 1) / float64(ms.sampleRate)
+	}
+	
 	for i := range samples {
 		// Calculate the time for this sample
-		sampleTime := currentTime + float64(i)/float64(ms.sampleRate)
+		realTime := elapsed + float64(i)/float64(ms.sampleRate)
+		sampleTime := ms.songOffset + realTime*ms.speed
+		for lane := range ms.timeWarp {
+			ms.timeWarp[lane] += warpPerSample[lane]
+		}
 		
 		// Generate audio by synthesizing active MIDI notes
-		left, right := ms.synthesizeAtTime(sampleTime)
+		left, right := ms.synthesizeAtTime(sampleTime, realTime)
 		
 		samples[i][0] = left
 		samples[i][1] = right
@@ -189,15 +230,15 @@ func (ms *MIDIAudioStreamer) Err() error {
 	return nil
 }
 
-// synthesizeAtTime generates audio samples for a specific time
-func (ms *MIDIAudioStreamer) synthesizeAtTime(currentTime float64) (float64, float64) {
+// synthesizeAtTime generates audio samples for a song time, with waves at a real time
+func (ms *MIDIAudioStreamer) synthesizeAtTime(currentTime float64, waveTime float64) (float64, float64) {
 	var left, right float64
 	var activeNoteCount int
 	
 	// Simple test tone (440Hz for first 2 seconds) to verify audio works
 	if currentTime >= 0 && currentTime < 2.0 {
 		testFreq := 440.0 // A4 note
-		testPhase := 2 * math.Pi * testFreq * currentTime
+		testPhase := 2 * math.Pi * testFreq * waveTime
 		testSample := 0.2 * math.Sin(testPhase)
 		left += testSample
 		right += testSample
@@ -216,8 +257,12 @@ func (ms *MIDIAudioStreamer) synthesizeAtTime(currentTime float64) (float64, flo
 			// Convert MIDI pitch to frequency
 			frequency := midiToFrequency(note.Pitch)
 			
-			// Generate sine wave
-			phase := 2 * math.Pi * frequency * currentTime
+			// Generate sine wave, bent by the whammy
+			phaseTime := waveTime
+			if note.Lane >= 0 && note.Lane < len(ms.timeWarp) {
+				phaseTime += ms.timeWarp[note.Lane]
+			}
+			phase := 2 * math.Pi * frequency * phaseTime
 			amplitude := 0.2 // Reduced to avoid overload with test tone
 			
 			// Simple envelope (fade in/out to avoid clicks)
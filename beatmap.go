@@ -0,0 +1,188 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Default tempo map for charts without one
+const (
+	DEFAULT_BPM            = 120.0
+	DEFAULT_BEATS_PER_BAR  = 4
+	DEFAULT_TICKS_PER_BEAT = 480
+)
+
+// Beat is one beat of the song's tempo map
+type Beat struct {
+	Time    float64 // Seconds
+	Measure int     // Bar number, 1 for the first bar of the song and below 1 for count-in beats
+	Index   int     // Beat within the measure, 0 for the downbeat
+	BPM     float64 // Quarter notes per minute at this beat
+}
+
+// IsDownbeat returns whether the beat starts a measure
+func (b Beat) IsDownbeat() bool {
+	return b.Index == 0
+}
+
+// DefaultBeatMap returns 4/4 beats at DEFAULT_BPM covering the given duration
+func DefaultBeatMap(duration float64) []Beat {
+	interval := 60 / DEFAULT_BPM
+	beats := make([]Beat, 0, int(duration/interval)+1)
+	for i := 0; float64(i)*interval <= duration; i++ {
+		beats = append(beats, Beat{
+			Time:    float64(i) * interval,
+			Measure: 1 + i/DEFAULT_BEATS_PER_BAR,
+			Index:   i % DEFAULT_BEATS_PER_BAR,
+			BPM:     DEFAULT_BPM,
+		})
+	}
+	return beats
+}
+
+// offsetBeats shifts a beat map by offset seconds and keeps the beats from 0 to
+// duration, continuing the first bar's tempo backwards to fill any lead-in
+func offsetBeats(beats []Beat, offset, duration float64) []Beat {
+	shifted := make([]Beat, 0, len(beats))
+	for _, beat := range beats {
+		beat.Time += offset
+		if beat.Time >= 0 && beat.Time <= duration {
+			shifted = append(shifted, beat)
+		}
+	}
+	if len(shifted) < 2 {
+		return shifted
+	}
+	
+	// Count-in beats before the first one, with the same spacing and bar length
+	first := shifted[0]
+	interval := shifted[1].Time - first.Time
+	beatsPerBar := DEFAULT_BEATS_PER_BAR
+	for k := 1; k < len(shifted); k++ {
+		if shifted[k].IsDownbeat() {
+			beatsPerBar = shifted[k-1].Index + 1
+			break
+		}
+	}
+	countIn := make([]Beat, 0)
+	measure, index := first.Measure, first.Index
+	for t := first.Time - interval; t >= 0 && interval > 0; t -= interval {
+		index--
+		if index < 0 {
+			index = beatsPerBar - 1
+			measure--
+		}
+		countIn = append(countIn, Beat{Time: t, Measure: measure, Index: index, BPM: first.BPM})
+	}
+	for i, j := 0, len(countIn)-1; i < j; i, j = i+1, j-1 {
+		countIn[i], countIn[j] = countIn[j], countIn[i]
+	}
+	
+	return append(countIn, shifted...)
+}
+
+// Beats returns the beat map of the loaded song in game time
+func (g *Game) Beats() []Beat {
+	return g.beats
+}
+
+// BeatsBetween returns the beats from one song time up to another
+func (g *Game) BeatsBetween(from, to float64) []Beat {
+	start := sort.Search(len(g.beats), func(i int) bool { return g.beats[i].Time >= from })
+	end := sort.Search(len(g.beats), func(i int) bool { return g.beats[i].Time > to })
+	if start >= end {
+		return nil
+	}
+	return g.beats[start:end]
+}
+
+// BPMAt returns the tempo at a song time
+func (g *Game) BPMAt(time float64) float64 {
+	i := sort.Search(len(g.beats), func(i int) bool { return g.beats[i].Time > time })
+	if i == 0 {
+		if len(g.beats) > 0 {
+			return g.beats[0].BPM
+		}
+		return DEFAULT_BPM
+	}
+	return g.beats[i-1].BPM
+}
+
+// TempoMap is a MIDI file's tick resolution with its tempo and time signature changes
+type TempoMap struct {
+	TicksPerBeat   int // Ticks per quarter note
+	Tempos         []tempoChange
+	TimeSignatures []timeSignature
+	EndTick        int // Tick of the file's last event
+}
+
+// DefaultTempoMap returns a 4/4 tempo map at DEFAULT_BPM for charts without a file
+func DefaultTempoMap() TempoMap {
+	return TempoMap{
+		TicksPerBeat:   DEFAULT_TICKS_PER_BEAT,
+		Tempos:         []tempoChange{{Tick: 0, MicrosPerBeat: int(60000000 / DEFAULT_BPM)}},
+		TimeSignatures: []timeSignature{{Tick: 0, Numerator: DEFAULT_BEATS_PER_BAR, Denominator: 4}},
+	}
+}
+
+// TicksToSeconds converts a tick to song time, following every tempo change before it
+func (m TempoMap) TicksToSeconds(ticks int) float64 {
+	if m.TicksPerBeat <= 0 {
+		return 0
+	}
+	
+	tempos := m.Tempos
+	if len(tempos) == 0 {
+		tempos = DefaultTempoMap().Tempos
+	}
+	seconds := 0.0
+	for i, change := range tempos {
+		if change.Tick >= ticks {
+			break
+		}
+		end := ticks
+		if i+1 < len(tempos) && tempos[i+1].Tick < ticks {
+			end = tempos[i+1].Tick
+		}
+		seconds += float64(end-change.Tick) * float64(change.MicrosPerBeat) / (float64(m.TicksPerBeat) * 1000000.0)
+	}
+	return seconds
+}
+
+// TempoAt returns the microseconds per quarter note at a tick
+func (m TempoMap) TempoAt(tick int) int {
+	tempo := int(60000000 / DEFAULT_BPM)
+	for _, change := range m.Tempos {
+		if change.Tick > tick {
+			break
+		}
+		tempo = change.MicrosPerBeat
+	}
+	return tempo
+}
+
+// SecondsToTicks converts song time to the nearest tick, following every tempo change before it
+func (m TempoMap) SecondsToTicks(seconds float64) int {
+	if seconds <= 0 || m.TicksPerBeat <= 0 {
+		return 0
+	}
+	
+	tempos := m.Tempos
+	if len(tempos) == 0 {
+		tempos = DefaultTempoMap().Tempos
+	}
+	elapsed := 0.0
+	for i, change := range tempos {
+		secondsPerTick := float64(change.MicrosPerBeat) / (float64(m.TicksPerBeat) * 1000000.0)
+		if i+1 < len(tempos) {
+			next := tempos[i+1]
+			length := float64(next.Tick-change.Tick) * secondsPerTick
+			if elapsed+length <= seconds {
+				elapsed += length
+				continue
+			}
+		}
+		return change.Tick + int(math.Round((seconds-elapsed)/secondsPerTick))
+	}
+	return 0
+}
\ No newline at end of file
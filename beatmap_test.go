@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// midiEvent encodes a delta time followed by raw event bytes
+func midiEvent(delta int, data ...byte) []byte {
+	encoded := []byte{byte(delta & 0x7F)}
+	for delta >>= 7; delta > 0; delta >>= 7 {
+		encoded = append([]byte{byte(delta&0x7F) | 0x80}, encoded...)
+	}
+	return append(encoded, data...)
+}
+
+// midiTrackChunk wraps events in an MTrk chunk ending with an end of track event
+func midiTrackChunk(events ...[]byte) []byte {
+	body := make([]byte, 0)
+	for _, event := range events {
+		body = append(body, event...)
+	}
+	body = append(body, midiEvent(0, 0xFF, 0x2F, 0x00)...)
+	
+	chunk := []byte("MTrk")
+	chunk = binary.BigEndian.AppendUint32(chunk, uint32(len(body)))
+	return append(chunk, body...)
+}
+
+// writeMIDIFile writes a format 1 file with the given tracks and returns its path
+func writeMIDIFile(t *testing.T, ticksPerBeat int, tracks ...[]byte) string {
+	t.Helper()
+	
+	data := []byte("MThd")
+	data = binary.BigEndian.AppendUint32(data, 6)
+	data = binary.BigEndian.AppendUint16(data, 1)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(tracks)))
+	data = binary.BigEndian.AppendUint16(data, uint16(ticksPerBeat))
+	for _, track := range tracks {
+		data = append(data, track...)
+	}
+	
+	path := filepath.Join(t.TempDir(), "song.mid")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBeatMapFollowsTempoAndTimeSignature(t *testing.T) {
+	// 120 BPM in 4/4, 60 BPM from bar 2 and 3/4 from bar 3
+	tempoTrack := midiTrackChunk(
+		midiEvent(0, 0xFF, 0x58, 0x04, 4, 2, 24, 8),
+		midiEvent(0, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20),
+		midiEvent(1920, 0xFF, 0x51, 0x03, 0x0F, 0x42, 0x40),
+		midiEvent(1920, 0xFF, 0x58, 0x04, 3, 2, 24, 8),
+	)
+	noteTrack := midiTrackChunk(
+		midiEvent(0, 0x90, 64, 100),
+		midiEvent(5760, 0x80, 64, 0),
+	)
+	
+	mp := NewMIDIProcessor()
+	if err := mp.LoadMIDI(writeMIDIFile(t, 480, tempoTrack, noteTrack)); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	
+	want := []Beat{
+		{0, 1, 0, 120}, {0.5, 1, 1, 120}, {1, 1, 2, 120}, {1.5, 1, 3, 120},
+		{2, 2, 0, 60}, {3, 2, 1, 60}, {4, 2, 2, 60}, {5, 2, 3, 60},
+		{6, 3, 0, 60}, {7, 3, 1, 60}, {8, 3, 2, 60},
+		{9, 4, 0, 60}, {10, 4, 1, 60},
+	}
+	beats := mp.BeatMap()
+	if len(beats) != len(want) {
+		t.Fatalf("got %d beats, want %d: %+v", len(beats), len(want), beats)
+	}
+	for i, beat := range beats {
+		if math.Abs(beat.Time-want[i].Time) > 1e-9 || beat.Measure != want[i].Measure ||
+			beat.Index != want[i].Index || math.Abs(beat.BPM-want[i].BPM) > 1e-9 {
+			t.Errorf("beat %d = %+v, want %+v", i, beat, want[i])
+		}
+	}
+	
+	// Notes spanning a tempo change are timed through it
+	track, err := mp.FindGuitarTrack()
+	if err != nil {
+		t.Fatalf("FindGuitarTrack failed: %v", err)
+	}
+	if len(track.Notes) != 1 || math.Abs(track.Notes[0].Duration-10) > 1e-9 {
+		t.Errorf("notes = %+v, want one note lasting 10s", track.Notes)
+	}
+}
+
+func TestGameBeatsIncludeCountIn(t *testing.T) {
+	clock := NewManualClock()
+	game := NewHeadlessGame(NewScriptedInput(nil), clock)
+	if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes([]MIDINote{laneNote(0, 0.0, 0.1)})); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	
+	// The first note is moved to 2s, so four count-in beats of bar 0 come before it
+	countIn := game.BeatsBetween(0, 1.9)
+	if len(countIn) != 4 {
+		t.Fatalf("got %d count-in beats, want 4: %+v", len(countIn), countIn)
+	}
+	for i, beat := range countIn {
+		if beat.Measure != 0 || beat.Index != i || math.Abs(beat.Time-float64(i)*0.5) > 1e-9 {
+			t.Errorf("count-in beat %d = %+v", i, beat)
+		}
+	}
+	
+	first := game.BeatsBetween(2, 2)
+	if len(first) != 1 || !first[0].IsDownbeat() || first[0].Measure != 1 {
+		t.Errorf("the first note should land on the downbeat of bar 1, got %+v", first)
+	}
+	if bpm := game.BPMAt(5); bpm != DEFAULT_BPM {
+		t.Errorf("BPMAt = %.1f, want %.1f", bpm, DEFAULT_BPM)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Bot constants
+const (
+	BOT_MAX_TIMING_ERROR = 0.08  // Timing standard deviation in seconds at skill 0
+	BOT_MIN_HOLD         = 0.03  // Shortest time the bot holds a key
+	BOT_RELEASE_GAP      = 0.001 // Gap between releasing and pressing the same lane again
+	BOT_STAR_POWER_DELAY = 0.05  // How long after completing a phrase the bot activates Star Power
+)
+
+// Bot plays a chart automatically by generating lane events from the game notes
+type Bot struct {
+	Skill        float64          // 1.0 plays perfectly, 0.0 has the largest timing error
+	UseStarPower bool             // Activate Star Power as soon as the meter allows
+	Judgement    JudgementProfile // Decides which notes are sustains
+	rng          *rand.Rand
+}
+
+// NewBot creates a bot with the given skill level between 0 and 1
+func NewBot(skill float64, seed int64) *Bot {
+	if skill < 0 {
+		skill = 0
+	} else if skill > 1 {
+		skill = 1
+	}
+	
+	return &Bot{
+		Skill:        skill,
+		UseStarPower: true,
+		Judgement:    StandardJudgement(),
+		rng:          rand.New(rand.NewSource(seed)),
+	}
+}
+
+// TimingStdDev returns the standard deviation of the bot's timing error in seconds
+func (b *Bot) TimingStdDev() float64 {
+	return (1 - b.Skill) * BOT_MAX_TIMING_ERROR
+}
+
+// timingError returns a random timing error for one press or release
+func (b *Bot) timingError() float64 {
+	stdDev := b.TimingStdDev()
+	if stdDev == 0 {
+		return 0
+	}
+	return b.rng.NormFloat64() * stdDev
+}
+
+// GenerateEvents creates press and release events that play the given notes
+func (b *Bot) GenerateEvents(notes []GameNote) []InputEvent {
+	// Group notes by lane so presses and releases in a lane never overlap
+	laneNotes := make(map[int][]GameNote)
+	for _, note := range notes {
+		laneNotes[note.Lane] = append(laneNotes[note.Lane], note)
+	}
+	
+	// Visit lanes in order so a seed always produces the same events
+	lanes := make([]int, 0, len(laneNotes))
+	for lane := range laneNotes {
+		lanes = append(lanes, lane)
+	}
+	sort.Ints(lanes)
+	
+	events := make([]InputEvent, 0, len(notes)*2)
+	for _, lane := range lanes {
+		notesInLane := laneNotes[lane]
+		sort.Slice(notesInLane, func(i, j int) bool {
+			return notesInLane[i].StartTime < notesInLane[j].StartTime
+		})
+		
+		// Plan presses first so each release can be cut short before the next press
+		presses := make([]float64, len(notesInLane))
+		for i, note := range notesInLane {
+			presses[i] = note.StartTime + b.timingError()
+		}
+		
+		lastRelease := -1.0
+		for i, note := range notesInLane {
+			press := presses[i]
+			if press <= lastRelease {
+				// The previous note in this lane is still held, this one cannot be played
+				continue
+			}
+			
+			hold := note.Duration
+			if hold < BOT_MIN_HOLD {
+				hold = BOT_MIN_HOLD
+			}
+			release := note.StartTime + hold + b.timingError()
+			if release < press+BOT_MIN_HOLD {
+				release = press + BOT_MIN_HOLD
+			}
+			if i+1 < len(notesInLane) && release > presses[i+1]-BOT_RELEASE_GAP {
+				release = presses[i+1] - BOT_RELEASE_GAP
+			}
+			if release <= press {
+				// Two notes at the same time in one lane, only the first can be played
+				continue
+			}
+			
+			events = append(events,
+				InputEvent{Time: press, Lane: lane, Pressed: true},
+				InputEvent{Time: release, Lane: lane, Pressed: false},
+			)
+			lastRelease = release
+		}
+	}
+	
+	if b.UseStarPower {
+		events = append(events, b.starPowerEvents(notes)...)
+	}
+	
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time < events[j].Time
+	})
+	return events
+}
+
+// starPowerEvents plans Star Power activations assuming every phrase is completed
+func (b *Bot) starPowerEvents(notes []GameNote) []InputEvent {
+	// A phrase completes when its last note is judged
+	completions := make(map[int]float64)
+	for _, note := range notes {
+		if note.StarPhrase < 0 {
+			continue
+		}
+		judgeTime := note.StartTime
+		if b.Judgement.IsSustain(note.Duration) {
+			judgeTime += note.Duration
+		}
+		if judgeTime > completions[note.StarPhrase] {
+			completions[note.StarPhrase] = judgeTime
+		}
+	}
+	
+	times := make([]float64, 0, len(completions))
+	for _, completion := range completions {
+		times = append(times, completion)
+	}
+	sort.Float64s(times)
+	
+	events := make([]InputEvent, 0)
+	meter := 0.0
+	activeUntil := -1.0
+	for _, completion := range times {
+		if completion < activeUntil {
+			// Completing a phrase while active extends Star Power
+			activeUntil += STAR_POWER_PER_PHRASE / STAR_POWER_DRAIN_RATE
+			continue
+		}
+		
+		meter += STAR_POWER_PER_PHRASE
+		if meter > 1.0 {
+			meter = 1.0
+		}
+		if meter >= STAR_POWER_MIN_METER {
+			activation := completion + BOT_STAR_POWER_DELAY
+			events = append(events, InputEvent{Time: activation, Action: ActionStarPower, Pressed: true})
+			activeUntil = activation + meter/STAR_POWER_DRAIN_RATE
+			meter = 0
+		}
+	}
+	
+	return events
+}
+
+// CheckChartWithBot plays the chart headlessly with a perfect bot and returns
+// every note it could not hit perfectly, which points at unplayable note layouts
+func CheckChartWithBot(midiProcessor *MIDIProcessor) ([]GameNote, GameResult, error) {
+	clock := NewManualClock()
+	game := NewHeadlessGame(nil, clock)
+	if err := game.LoadMIDITrack(midiProcessor); err != nil {
+		return nil, GameResult{}, err
+	}
+	bot := NewBot(1.0, 0)
+	bot.UseStarPower = false // Keep scores comparable with the maximum score
+	game.SetAutoplay(bot)
+	game.SetNoFail(true) // Judge every note even if the bot falls behind
+	
+	Simulate(game, clock, HEADLESS_FPS)
+	
+	problems := make([]GameNote, 0)
+	for _, note := range game.gameNotes {
+		if !note.IsHit || note.HitAccuracy != Perfect {
+			problems = append(problems, note)
+		}
+	}
+	
+	return problems, game.Result(), nil
+}
+
+// PrintBotCheck prints the notes a perfect bot could not hit
+func PrintBotCheck(problems []GameNote, result GameResult) {
+	laneNames := []string{"A", "W", "D"}
+	
+	fmt.Printf("=== AUTOPLAY CHECK ===\n")
+	fmt.Printf("Notes: %d, Perfect: %d, Good: %d, OK: %d, Missed: %d\n",
+		result.TotalNotes, result.PerfectHits, result.GoodHits, result.OKHits, result.MissedHits)
+	
+	for _, note := range problems {
+		fmt.Printf("Lane %s at %.3fs (duration %.3fs): %s\n",
+			laneNames[note.Lane], note.StartTime, note.Duration, note.HitAccuracy)
+	}
+	
+	if len(problems) == 0 {
+		fmt.Printf("Every note can be hit\n")
+	} else {
+		fmt.Printf("%d notes cannot be hit perfectly\n", len(problems))
+	}
+	fmt.Printf("======================\n")
+}
\ No newline at end of file
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPerfectBotHitsEveryNote(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.25, 1.0),
+		laneNote(2, 0.5, 0.1),
+		laneNote(0, 0.6, 0.1),
+		laneNote(0, 0.75, 0.5),
+	}
+	
+	problems, result, err := CheckChartWithBot(NewMIDIProcessorFromNotes(notes))
+	if err != nil {
+		t.Fatalf("CheckChartWithBot failed: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("perfect bot missed %d notes: %+v", len(problems), problems)
+	}
+	if result.PerfectHits != int32(len(notes)) {
+		t.Errorf("PerfectHits = %d, want %d", result.PerfectHits, len(notes))
+	}
+}
+
+func TestBotFindsCollapsedChord(t *testing.T) {
+	// Both pitches map to the middle lane at the same time
+	notes := []MIDINote{
+		{Pitch: 62, Velocity: 100, StartTime: 0.0, Duration: 0.1},
+		{Pitch: 67, Velocity: 100, StartTime: 0.0, Duration: 0.1},
+	}
+	
+	problems, _, err := CheckChartWithBot(NewMIDIProcessorFromNotes(notes))
+	if err != nil {
+		t.Fatalf("CheckChartWithBot failed: %v", err)
+	}
+	if len(problems) != 1 {
+		t.Errorf("found %d unplayable notes, want 1", len(problems))
+	}
+}
+
+func TestBotEventsAreDeterministic(t *testing.T) {
+	notes := []GameNote{
+		{StartTime: 1.0, Duration: 0.1, Lane: 0},
+		{StartTime: 1.5, Duration: 0.8, Lane: 1},
+		{StartTime: 2.0, Duration: 0.1, Lane: 2},
+	}
+	
+	first := NewBot(0.5, 42).GenerateEvents(notes)
+	second := NewBot(0.5, 42).GenerateEvents(notes)
+	if len(first) != len(second) {
+		t.Fatalf("event counts differ: %d and %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("event %d differs: %+v and %+v", i, first[i], second[i])
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// ControllerMapper runs the controller mapping screen, where each control is
+// rebound by pressing a button or moving an axis
+type ControllerMapper struct {
+	gamepad   *GamepadInput
+	selected  Control
+	listening bool      // Waiting for a button or axis to bind to the selected control
+	axisRest  []float32 // Axis positions when listening started
+	message   string
+}
+
+// NewControllerMapper creates the mapping screen for a gamepad
+func NewControllerMapper(gamepad *GamepadInput) *ControllerMapper {
+	return &ControllerMapper{
+		gamepad: gamepad,
+	}
+}
+
+// Selected returns the highlighted control
+func (m *ControllerMapper) Selected() Control {
+	return m.selected
+}
+
+// IsListening returns whether the mapper is waiting for a new binding
+func (m *ControllerMapper) IsListening() bool {
+	return m.listening
+}
+
+// Message returns the status line shown under the bindings
+func (m *ControllerMapper) Message() string {
+	return m.message
+}
+
+// Update handles input on the mapping screen, navigating with the menu controls
+// of the keyboard or controller, and returns true when the player leaves it
+func (m *ControllerMapper) Update(menuPressed func(Control) bool) bool {
+	if m.listening {
+		if rl.IsKeyPressed(rl.KeyBackspace) {
+			m.listening = false
+			m.message = "Cancelled"
+			return false
+		}
+		if binding, ok := m.captureBinding(); ok {
+			m.bind(binding)
+		}
+		return false
+	}
+	
+	switch {
+	case menuPressed(ControlMenuUp):
+		m.selected = (m.selected + controlCount - 1) % controlCount
+	case menuPressed(ControlMenuDown):
+		m.selected = (m.selected + 1) % controlCount
+	case menuPressed(ControlMenuConfirm):
+		if !m.gamepad.IsConnected() {
+			m.message = "Connect a controller first"
+			return false
+		}
+		m.startListening()
+	case rl.IsKeyPressed(rl.KeyDelete):
+		m.bind(Binding{})
+	case rl.IsKeyPressed(rl.KeyR):
+		m.save(DefaultControllerProfile(m.gamepad.Name()), "Restored default bindings")
+	case menuPressed(ControlMenuBack):
+		return true
+	}
+	return false
+}
+
+// startListening waits for the next button press or axis movement
+func (m *ControllerMapper) startListening() {
+	m.listening = true
+	m.message = fmt.Sprintf("Press a button or move an axis for %s", m.selected)
+	
+	// Remember where the axes rest, triggers rest at -1 rather than 0
+	count := rl.GetGamepadAxisCount(m.gamepad.gamepad)
+	m.axisRest = make([]float32, count)
+	for axis := int32(0); axis < count; axis++ {
+		m.axisRest[axis] = rl.GetGamepadAxisMovement(m.gamepad.gamepad, axis)
+	}
+}
+
+// captureBinding returns the button pressed or axis moved since listening started
+func (m *ControllerMapper) captureBinding() (Binding, bool) {
+	for button := int32(rl.GamepadButtonLeftFaceUp); button <= rl.GamepadButtonRightThumb; button++ {
+		if rl.IsGamepadButtonPressed(m.gamepad.gamepad, button) {
+			return ButtonBinding(button), true
+		}
+	}
+	
+	for axis, rest := range m.axisRest {
+		delta := rl.GetGamepadAxisMovement(m.gamepad.gamepad, int32(axis)) - rest
+		if math.Abs(float64(delta)) > AXIS_THRESHOLD {
+			direction := float32(1)
+			if delta < 0 {
+				direction = -1
+			}
+			return AxisBinding(int32(axis), direction), true
+		}
+	}
+	
+	return Binding{}, false
+}
+
+// bind sets the selected control's binding and saves the profile
+func (m *ControllerMapper) bind(binding Binding) {
+	m.listening = false
+	
+	profile := m.gamepad.Profile()
+	updated := ControllerProfile{Name: profile.Name, Bindings: make(map[string]Binding)}
+	for key, existing := range profile.Bindings {
+		updated.Bindings[key] = existing
+	}
+	updated.Set(m.selected, binding)
+	if err := updated.Validate(); err != nil {
+		m.message = fmt.Sprintf("Not bound: %v", err)
+		return
+	}
+	
+	m.save(updated, fmt.Sprintf("%s bound to %s", m.selected, binding))
+}
+
+// save makes a profile the active one and writes it to disk
+func (m *ControllerMapper) save(profile ControllerProfile, message string) {
+	m.message = message
+	if err := m.gamepad.SetProfile(profile); err != nil {
+		fmt.Printf("Warning: Failed to save controller profile: %v\n", err)
+		m.message = "Failed to save controller profile"
+	}
+}
\ No newline at end of file
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Controller constants
+const (
+	CONTROLLERS_FILE = "controllers.json"
+	AXIS_THRESHOLD   = 0.5 // Axis movement that counts as pressing an axis binding
+	GAMEPAD_INDEX    = 0   // Gamepad used for play
+)
+
+// Control is a game function that can be bound to a controller button or axis
+type Control int
+
+const (
+	ControlFret1 Control = iota
+	ControlFret2
+	ControlFret3
+	ControlStrumUp
+	ControlStrumDown
+	ControlStarPower
+	ControlTilt
+	ControlWhammy
+	ControlMenuUp
+	ControlMenuDown
+	ControlMenuLeft
+	ControlMenuRight
+	ControlMenuConfirm
+	ControlMenuBack
+	controlCount
+)
+
+// controlInfo holds the profile key and display label of each control
+var controlInfo = [controlCount]struct {
+	Key   string
+	Label string
+}{
+	{"fret1", "Fret 1 (A)"},
+	{"fret2", "Fret 2 (W)"},
+	{"fret3", "Fret 3 (D)"},
+	{"strum_up", "Strum Up"},
+	{"strum_down", "Strum Down"},
+	{"star_power", "Star Power"},
+	{"tilt", "Tilt (Star Power)"},
+	{"whammy", "Whammy"},
+	{"menu_up", "Menu Up"},
+	{"menu_down", "Menu Down"},
+	{"menu_left", "Menu Left"},
+	{"menu_right", "Menu Right"},
+	{"menu_confirm", "Menu Confirm"},
+	{"menu_back", "Menu Back"},
+}
+
+// String returns the display label of a control
+func (c Control) String() string {
+	if c < 0 || c >= controlCount {
+		return "Unknown"
+	}
+	return controlInfo[c].Label
+}
+
+// fretCount is the number of fret controls, one per lane
+const fretCount = int(ControlFret3-ControlFret1) + 1
+
+// fretControl returns the control for a lane's fret
+func fretControl(lane int) Control {
+	return ControlFret1 + Control(lane)
+}
+
+// BindingKind is the kind of controller input a binding reads
+type BindingKind int
+
+const (
+	BindNone BindingKind = iota
+	BindButton
+	BindAxis
+)
+
+// Binding maps a control to a gamepad button or one direction of an axis
+type Binding struct {
+	Kind      BindingKind `json:"kind"`
+	Index     int32       `json:"index"`
+	Direction float32     `json:"direction,omitempty"` // 1 or -1 for axes
+}
+
+// ButtonBinding creates a binding to a gamepad button
+func ButtonBinding(button int32) Binding {
+	return Binding{Kind: BindButton, Index: button}
+}
+
+// AxisBinding creates a binding to one direction of a gamepad axis
+func AxisBinding(axis int32, direction float32) Binding {
+	return Binding{Kind: BindAxis, Index: axis, Direction: direction}
+}
+
+// String returns a short description of the binding
+func (b Binding) String() string {
+	switch b.Kind {
+	case BindButton:
+		return fmt.Sprintf("Button %d", b.Index)
+	case BindAxis:
+		sign := "+"
+		if b.Direction < 0 {
+			sign = "-"
+		}
+		return fmt.Sprintf("Axis %d%s", b.Index, sign)
+	default:
+		return "Unbound"
+	}
+}
+
+// Value reads the binding from a gamepad, from 0 (released) to 1 (fully pressed)
+func (b Binding) Value(gamepad int32) float32 {
+	switch b.Kind {
+	case BindButton:
+		if rl.IsGamepadButtonDown(gamepad, b.Index) {
+			return 1
+		}
+		return 0
+	case BindAxis:
+		value := rl.GetGamepadAxisMovement(gamepad, b.Index) * b.Direction
+		if value < 0 {
+			return 0
+		}
+		if value > 1 {
+			return 1
+		}
+		return value
+	default:
+		return 0
+	}
+}
+
+// IsDown returns whether the binding is pressed on a gamepad
+func (b Binding) IsDown(gamepad int32) bool {
+	return b.Kind != BindNone && b.Value(gamepad) > AXIS_THRESHOLD
+}
+
+// menuKeys are the keyboard keys for the menu controls
+var menuKeys = map[Control][]int32{
+	ControlMenuUp:      {rl.KeyUp},
+	ControlMenuDown:    {rl.KeyDown},
+	ControlMenuLeft:    {rl.KeyLeft},
+	ControlMenuRight:   {rl.KeyRight},
+	ControlMenuConfirm: {rl.KeySpace, rl.KeyEnter},
+	ControlMenuBack:    {rl.KeyBackspace},
+}
+
+// MenuPressed returns whether a menu control was pressed this frame on the keyboard or gamepad
+func (g *Game) MenuPressed(control Control) bool {
+	for _, key := range menuKeys[control] {
+		if rl.IsKeyPressed(key) {
+			return true
+		}
+	}
+	return g.gamepad != nil && g.gamepad.IsPressed(control)
+}
+
+// ControllerProfile holds the bindings for one kind of controller
+type ControllerProfile struct {
+	Name     string             `json:"name"`
+	Bindings map[string]Binding `json:"bindings"` // Control key -> binding
+}
+
+// DefaultControllerProfile returns bindings for an Xbox-style gamepad or guitar
+// controller. Menus confirm with Start and go back with X so no fret triggers them.
+func DefaultControllerProfile(name string) ControllerProfile {
+	profile := ControllerProfile{
+		Name:     name,
+		Bindings: make(map[string]Binding),
+	}
+	profile.Set(ControlFret1, ButtonBinding(rl.GamepadButtonRightFaceDown))
+	profile.Set(ControlFret2, ButtonBinding(rl.GamepadButtonRightFaceRight))
+	profile.Set(ControlFret3, ButtonBinding(rl.GamepadButtonRightFaceUp))
+	profile.Set(ControlStrumUp, ButtonBinding(rl.GamepadButtonLeftFaceUp))
+	profile.Set(ControlStrumDown, ButtonBinding(rl.GamepadButtonLeftFaceDown))
+	profile.Set(ControlStarPower, ButtonBinding(rl.GamepadButtonMiddleLeft))
+	profile.Set(ControlTilt, AxisBinding(rl.GamepadAxisRightY, -1))
+	profile.Set(ControlWhammy, AxisBinding(rl.GamepadAxisRightX, 1))
+	profile.Set(ControlMenuUp, ButtonBinding(rl.GamepadButtonLeftFaceUp))
+	profile.Set(ControlMenuDown, ButtonBinding(rl.GamepadButtonLeftFaceDown))
+	profile.Set(ControlMenuLeft, ButtonBinding(rl.GamepadButtonLeftFaceLeft))
+	profile.Set(ControlMenuRight, ButtonBinding(rl.GamepadButtonLeftFaceRight))
+	profile.Set(ControlMenuConfirm, ButtonBinding(rl.GamepadButtonMiddleRight))
+	profile.Set(ControlMenuBack, ButtonBinding(rl.GamepadButtonRightFaceLeft))
+	return profile
+}
+
+// fretConflict returns the fret bound to the same input as a menu control
+func (p ControllerProfile) fretConflict(menu Control) (Control, bool) {
+	binding := p.Get(menu)
+	if binding.Kind == BindNone {
+		return 0, false
+	}
+	for lane := 0; lane < fretCount; lane++ {
+		if p.Get(fretControl(lane)) == binding {
+			return fretControl(lane), true
+		}
+	}
+	return 0, false
+}
+
+// Validate returns an error if a fret shares its binding with a menu control,
+// since menus are read while playing and in the editor
+func (p ControllerProfile) Validate() error {
+	for control := ControlMenuUp; control <= ControlMenuBack; control++ {
+		if fret, ok := p.fretConflict(control); ok {
+			return fmt.Errorf("%s and %s are both bound to %s", fret, control, p.Get(control))
+		}
+	}
+	return nil
+}
+
+// Get returns the binding for a control
+func (p ControllerProfile) Get(control Control) Binding {
+	return p.Bindings[controlInfo[control].Key]
+}
+
+// Set changes the binding for a control
+func (p ControllerProfile) Set(control Control, binding Binding) {
+	p.Bindings[controlInfo[control].Key] = binding
+}
+
+// ProfileStore keeps controller profiles by controller name in a JSON file
+type ProfileStore struct {
+	path     string
+	Profiles map[string]ControllerProfile `json:"profiles"`
+}
+
+// LoadProfileStore loads the controller profiles, starting empty if the file does not exist
+func LoadProfileStore(path string) (*ProfileStore, error) {
+	store := &ProfileStore{
+		path:     path,
+		Profiles: make(map[string]ControllerProfile),
+	}
+	
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read controller profiles: %v", err)
+	}
+	
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to decode controller profiles: %v", err)
+	}
+	if store.Profiles == nil {
+		store.Profiles = make(map[string]ControllerProfile)
+	}
+	
+	// Repair profiles saved before menu controls were kept off the frets
+	for name := range store.Profiles {
+		store.Profiles[name] = store.ProfileFor(name)
+	}
+	
+	return store, nil
+}
+
+// LoadUserProfileStore loads the controller profiles from the user data directory
+func LoadUserProfileStore() (*ProfileStore, error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return LoadProfileStore(filepath.Join(dataDir, CONTROLLERS_FILE))
+}
+
+// Save writes the controller profiles to disk, refusing profiles that do not validate
+func (s *ProfileStore) Save() error {
+	for _, profile := range s.Profiles {
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("invalid controller profile %q: %v", profile.Name, err)
+		}
+	}
+	
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode controller profiles: %v", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write controller profiles: %v", err)
+	}
+	return nil
+}
+
+// ProfileFor returns the saved profile for a controller, or the default bindings
+func (s *ProfileStore) ProfileFor(name string) ControllerProfile {
+	if profile, ok := s.Profiles[name]; ok {
+		if profile.Bindings == nil {
+			profile.Bindings = make(map[string]Binding)
+		}
+		
+		// Fill in controls added since the profile was saved
+		defaults := DefaultControllerProfile(name)
+		for key, binding := range defaults.Bindings {
+			if _, ok := profile.Bindings[key]; !ok {
+				profile.Bindings[key] = binding
+			}
+		}
+		
+		// Menu controls saved on a fret go back to their defaults, or are unbound
+		for control := ControlMenuUp; control <= ControlMenuBack; control++ {
+			if _, ok := profile.fretConflict(control); ok {
+				profile.Set(control, defaults.Get(control))
+			}
+			if _, ok := profile.fretConflict(control); ok {
+				profile.Set(control, Binding{})
+			}
+		}
+		return profile
+	}
+	return DefaultControllerProfile(name)
+}
+
+// SetProfile stores a profile under its controller name
+func (s *ProfileStore) SetProfile(profile ControllerProfile) {
+	s.Profiles[profile.Name] = profile
+}
\ No newline at end of file
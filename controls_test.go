@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CONTROLLERS_FILE)
+	store, err := LoadProfileStore(path)
+	if err != nil {
+		t.Fatalf("LoadProfileStore failed: %v", err)
+	}
+	
+	profile := DefaultControllerProfile("Guitar Controller")
+	profile.Set(ControlWhammy, AxisBinding(4, -1))
+	delete(profile.Bindings, controlInfo[ControlMenuBack].Key)
+	store.SetProfile(profile)
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	
+	loaded, err := LoadProfileStore(path)
+	if err != nil {
+		t.Fatalf("LoadProfileStore failed: %v", err)
+	}
+	got := loaded.ProfileFor("Guitar Controller")
+	if whammy := got.Get(ControlWhammy); whammy != AxisBinding(4, -1) {
+		t.Errorf("whammy = %s, want Axis 4-", whammy)
+	}
+	// Controls missing from a saved profile fall back to the defaults
+	if back := got.Get(ControlMenuBack); back != DefaultControllerProfile("").Get(ControlMenuBack) {
+		t.Errorf("menu back = %s, want the default binding", back)
+	}
+	
+	// Unknown controllers get the default bindings
+	other := loaded.ProfileFor("Other Pad")
+	if other.Get(ControlWhammy) != DefaultControllerProfile("").Get(ControlWhammy) {
+		t.Errorf("unknown controller should use the default whammy binding")
+	}
+}
+
+func TestCompositeInputMergesLanes(t *testing.T) {
+	keyboard := NewScriptedInput([]InputEvent{
+		{Time: 1.0, Lane: 0, Pressed: true},
+		{Time: 2.0, Lane: 0, Pressed: false},
+	})
+	gamepad := NewScriptedInput([]InputEvent{
+		{Time: 1.5, Lane: 0, Pressed: true},
+		{Time: 1.5, Action: ActionStrum, Pressed: true},
+		{Time: 3.0, Lane: 0, Pressed: false},
+	})
+	input := NewCompositeInput(keyboard, gamepad)
+	
+	events := input.Poll(2.5)
+	want := []InputEvent{
+		{Time: 1.0, Lane: 0, Pressed: true},
+		{Time: 1.5, Action: ActionStrum, Pressed: true},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events %+v, want %+v", len(events), events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+	if !input.IsLaneDown(0) {
+		t.Errorf("lane 0 should still be held on the gamepad")
+	}
+	
+	// The lane is released once neither source holds it
+	events = input.Poll(3.0)
+	if len(events) != 1 || events[0].Pressed {
+		t.Errorf("got %+v, want a single release", events)
+	}
+}
+
+func TestControllerProfileKeepsMenusOffFrets(t *testing.T) {
+	if err := DefaultControllerProfile("").Validate(); err != nil {
+		t.Errorf("default profile does not validate: %v", err)
+	}
+	
+	profile := DefaultControllerProfile("Gamepad")
+	profile.Set(ControlMenuBack, profile.Get(ControlFret2))
+	if err := profile.Validate(); err == nil {
+		t.Errorf("Validate accepted menu back on the fret 2 button")
+	}
+	
+	path := filepath.Join(t.TempDir(), CONTROLLERS_FILE)
+	store, err := LoadProfileStore(path)
+	if err != nil {
+		t.Fatalf("LoadProfileStore failed: %v", err)
+	}
+	store.SetProfile(profile)
+	if err := store.Save(); err == nil {
+		t.Errorf("Save accepted a profile with menu back on a fret")
+	}
+	
+	// Profiles saved with menus on frets are repaired when loaded
+	data, err := json.Marshal(store)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	loaded, err := LoadProfileStore(path)
+	if err != nil {
+		t.Fatalf("LoadProfileStore failed: %v", err)
+	}
+	if back := loaded.ProfileFor("Gamepad").Get(ControlMenuBack); back != DefaultControllerProfile("").Get(ControlMenuBack) {
+		t.Errorf("menu back = %s, want the default binding", back)
+	}
+	if err := loaded.Save(); err != nil {
+		t.Errorf("Save of the repaired profiles failed: %v", err)
+	}
+}
\ No newline at end of file
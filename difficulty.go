@@ -0,0 +1,75 @@
+package main
+
+// Difficulty represents the chosen difficulty level
+type Difficulty int
+
+const (
+	DifficultyEasy Difficulty = iota
+	DifficultyMedium
+	DifficultyHard
+	DifficultyExpert
+	difficultyCount
+)
+
+// String returns the display name of a difficulty
+func (d Difficulty) String() string {
+	switch d {
+	case DifficultyEasy:
+		return "Easy"
+	case DifficultyMedium:
+		return "Medium"
+	case DifficultyHard:
+		return "Hard"
+	case DifficultyExpert:
+		return "Expert"
+	default:
+		return "Unknown"
+	}
+}
+
+// Next returns the difficulty a step away, wrapping around at either end
+func (d Difficulty) Next(step int) Difficulty {
+	next := (int(d) + step) % int(difficultyCount)
+	if next < 0 {
+		next += int(difficultyCount)
+	}
+	return Difficulty(next)
+}
+
+// DifficultySettings holds the gameplay tuning for a difficulty
+type DifficultySettings struct {
+	RockMeterGain  float64 // Rock meter gained by a Perfect hit, less for Good and OK hits
+	RockMeterDrain float64 // Rock meter lost by a missed note
+	
+	// Ghost presses (no note in the hit window) and wrong-lane presses
+	PenalizeGhostPresses bool    // Count them, break the combo and drain the rock meter
+	GhostPressDrain      float64 // Rock meter lost by each one
+}
+
+// difficultySettings tunes each difficulty, harder ones drain the rock meter faster
+var difficultySettings = map[Difficulty]DifficultySettings{
+	DifficultyEasy: {
+		RockMeterGain: 0.04, RockMeterDrain: 0.05,
+		PenalizeGhostPresses: false,
+	},
+	DifficultyMedium: {
+		RockMeterGain: 0.03, RockMeterDrain: 0.08,
+		PenalizeGhostPresses: true, GhostPressDrain: 0.02,
+	},
+	DifficultyHard: {
+		RockMeterGain: 0.025, RockMeterDrain: 0.1,
+		PenalizeGhostPresses: true, GhostPressDrain: 0.03,
+	},
+	DifficultyExpert: {
+		RockMeterGain: 0.02, RockMeterDrain: 0.13,
+		PenalizeGhostPresses: true, GhostPressDrain: 0.04,
+	},
+}
+
+// Settings returns the gameplay tuning for a difficulty
+func (d Difficulty) Settings() DifficultySettings {
+	if settings, ok := difficultySettings[d]; ok {
+		return settings
+	}
+	return difficultySettings[DifficultyMedium]
+}
\ No newline at end of file
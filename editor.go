@@ -0,0 +1,751 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Editor constants
+const (
+	EDITOR_MAX_UNDO     = 200    // Oldest edits are forgotten past this many
+	EDITOR_DEFAULT_SNAP = 3      // Index into editorSnaps, sixteenth notes
+	EDITOR_END_PADDING  = 4.0    // Seconds of empty timeline after the last note
+	EDITOR_VELOCITY     = 100    // Velocity of the notes placed in the editor
+	EDITOR_BACKUP_EXT   = ".bak" // Added to the chart's path for the copy kept before saving
+)
+
+// editorSnaps are the grid divisions per beat the editor can snap to
+var editorSnaps = []int{1, 2, 3, 4, 6, 8}
+
+// editorLanePitches are the pitches given to placed notes, read back into the same lanes
+var editorLanePitches = [3]int{52, 64, 76}
+
+// EditorNote is a note being edited with the pitch and velocity it is saved with
+type EditorNote struct {
+	GameNote
+	Pitch    int
+	Velocity int
+	Forced   bool // Type is saved as a marker instead of being detected by the game
+}
+
+// Editor edits a chart on a timeline snapped to its beat grid. Times are song
+// time as read from the file, without the lead-in the game adds.
+type Editor struct {
+	path     string
+	tempoMap TempoMap
+	beats    []Beat
+	notes    []EditorNote // Sorted by start time, then lane
+	
+	// Chart data kept as it was read and written back on save
+	track     MIDITrack  // Name, channel and instrument of the guitar track
+	dropped   []MIDINote // Notes outside the guitar pitch range
+	starPower []Phrase
+	sections  []Section
+	
+	cursor   float64 // Song time at the hit line
+	snap     int     // Index into editorSnaps
+	view     Modifiers
+	selected int // Index of the selected note, -1 if none
+	
+	// Stretching a sustain by dragging from where the mouse was clicked
+	dragging   bool
+	dragOrigin float64 // Grid line that was clicked
+	dragMoved  bool    // The mouse has left that grid line
+	dragEdited bool    // The drag already has an undo step
+	
+	undoStack      [][]EditorNote
+	redoStack      [][]EditorNote
+	dirty          bool
+	saved          bool // Saved at least once since opening
+	confirmDiscard bool // Closing with unsaved changes was asked for once
+	
+	// Playback from the cursor
+	audioManager *AudioManager
+	clock        Clock
+	playing      bool
+	playStart    time.Time
+	playFrom     float64
+}
+
+// NewEditor opens the guitar track of a loaded chart for editing
+func NewEditor(midiProcessor *MIDIProcessor, audioManager *AudioManager, clock Clock) (*Editor, error) {
+	// Saving the test chart loaded in place of an unreadable file would overwrite it
+	if midiProcessor.FilePath() != "" && !midiProcessor.Parsed() {
+		return nil, fmt.Errorf("%s could not be parsed", midiProcessor.FilePath())
+	}
+	track, err := midiProcessor.FindGuitarTrack()
+	if err != nil {
+		return nil, err
+	}
+	
+	e := &Editor{
+		path:         midiProcessor.FilePath(),
+		tempoMap:     midiProcessor.TempoMap(),
+		beats:        midiProcessor.BeatMap(),
+		track:        MIDITrack{Name: track.Name, Channel: track.Channel, Instrument: track.Instrument, IsGuitar: true},
+		dropped:      midiProcessor.DroppedNotes(),
+		starPower:    track.StarPowerPhrases,
+		snap:         EDITOR_DEFAULT_SNAP,
+		selected:     -1,
+		audioManager: audioManager,
+		clock:        clock,
+	}
+	if !track.AutoSections {
+		e.sections = track.Sections
+	}
+	if e.tempoMap.TicksPerBeat <= 0 {
+		e.tempoMap = DefaultTempoMap()
+	}
+	
+	// Notes under forced HOPO, forced strum and tap phrases keep their type as edits are made
+	inPhrase := func(start float64, phrases []Phrase) bool {
+		for _, phrase := range phrases {
+			if start >= phrase.StartTime && start < phrase.EndTime {
+				return true
+			}
+		}
+		return false
+	}
+	e.notes = make([]EditorNote, 0, len(track.Notes))
+	for _, midiNote := range track.Notes {
+		note := EditorNote{
+			GameNote: GameNote{
+				StartTime:  midiNote.StartTime,
+				Duration:   midiNote.Duration,
+				Lane:       midiNote.Lane,
+				IsActive:   true,
+				StarPhrase: -1,
+				Section:    -1,
+			},
+			Pitch:    midiNote.Pitch,
+			Velocity: midiNote.Velocity,
+			Forced: inPhrase(midiNote.StartTime, track.TapPhrases) ||
+				inPhrase(midiNote.StartTime, track.ForcedStrums) || inPhrase(midiNote.StartTime, track.ForcedHOPOs),
+		}
+		e.notes = append(e.notes, note)
+	}
+	
+	// Show the types the game plays, detected from spacing with the markers on top
+	notes := e.gameNotes()
+	assignNoteTypes(notes, track.ForcedHOPOs, track.ForcedStrums, track.TapPhrases)
+	for i := range e.notes {
+		e.notes[i].Type = notes[i].Type
+	}
+	e.sortNotes()
+	
+	if len(e.beats) == 0 {
+		e.beats = DefaultBeatMap(e.Duration())
+	}
+	
+	return e, nil
+}
+
+// Notes returns the chart's notes in song time
+func (e *Editor) Notes() []EditorNote {
+	return e.notes
+}
+
+// Path returns the file the chart is saved to
+func (e *Editor) Path() string {
+	return e.path
+}
+
+// Cursor returns the song time at the hit line
+func (e *Editor) Cursor() float64 {
+	return e.cursor
+}
+
+// Selected returns the index of the selected note, -1 if none
+func (e *Editor) Selected() int {
+	return e.selected
+}
+
+// IsDirty returns whether there are unsaved changes
+func (e *Editor) IsDirty() bool {
+	return e.dirty
+}
+
+// IsPlaying returns whether the chart is playing from the cursor
+func (e *Editor) IsPlaying() bool {
+	return e.playing
+}
+
+// View returns the scroll speed the timeline is drawn at
+func (e *Editor) View() Modifiers {
+	return e.view
+}
+
+// Zoom stretches or squeezes the timeline by scroll speed steps
+func (e *Editor) Zoom(steps int) {
+	e.view.AdjustSpeed(steps)
+}
+
+// Duration returns the length of the timeline
+func (e *Editor) Duration() float64 {
+	end := 0.0
+	for _, note := range e.notes {
+		end = max(end, note.StartTime+note.Duration)
+	}
+	if len(e.beats) > 0 {
+		end = max(end, e.beats[len(e.beats)-1].Time)
+	}
+	return end + EDITOR_END_PADDING
+}
+
+// Beats returns the beats of the chart in song time
+func (e *Editor) Beats() []Beat {
+	return e.beats
+}
+
+// BeatAt returns the last beat at or before a song time
+func (e *Editor) BeatAt(t float64) (Beat, bool) {
+	i := sort.Search(len(e.beats), func(i int) bool { return e.beats[i].Time > t })
+	if i == 0 {
+		return Beat{}, false
+	}
+	return e.beats[i-1], true
+}
+
+// SnapDivision returns how many grid lines each beat is split into
+func (e *Editor) SnapDivision() int {
+	return editorSnaps[e.snap]
+}
+
+// SnapName returns the grid size as a note value, like "1/16"
+func (e *Editor) SnapName() string {
+	return fmt.Sprintf("1/%d", 4*e.SnapDivision())
+}
+
+// ChangeSnap makes the grid finer or coarser
+func (e *Editor) ChangeSnap(step int) {
+	e.snap = min(max(e.snap+step, 0), len(editorSnaps)-1)
+}
+
+// beatSpan returns the start and length of the beat containing a song time,
+// continuing the first and last beats' spacing outside the beat map
+func (e *Editor) beatSpan(t float64) (float64, float64) {
+	if len(e.beats) < 2 {
+		interval := 60 / DEFAULT_BPM
+		return math.Floor(t/interval) * interval, interval
+	}
+	
+	i := sort.Search(len(e.beats), func(i int) bool { return e.beats[i].Time > t }) - 1
+	switch {
+	case i < 0:
+		interval := e.beats[1].Time - e.beats[0].Time
+		return e.beats[0].Time - math.Ceil((e.beats[0].Time-t)/interval)*interval, interval
+	case i >= len(e.beats)-1:
+		last := e.beats[len(e.beats)-1]
+		interval := last.Time - e.beats[len(e.beats)-2].Time
+		return last.Time + math.Floor((t-last.Time)/interval)*interval, interval
+	default:
+		return e.beats[i].Time, e.beats[i+1].Time - e.beats[i].Time
+	}
+}
+
+// GridStep returns the spacing of the grid at a song time
+func (e *Editor) GridStep(t float64) float64 {
+	_, interval := e.beatSpan(t)
+	return interval / float64(e.SnapDivision())
+}
+
+// Snap returns the grid line nearest to a song time
+func (e *Editor) Snap(t float64) float64 {
+	start, interval := e.beatSpan(t)
+	step := interval / float64(e.SnapDivision())
+	line := math.Round((t - start) / step)
+	if line >= float64(e.SnapDivision()) {
+		// The next beat's own time, so notes snapped from either side match
+		start, _ = e.beatSpan(start + interval + 1e-6)
+		line = 0
+	}
+	return max(start+line*step, 0)
+}
+
+// Scrub moves the cursor by grid steps, forwards for positive steps
+func (e *Editor) Scrub(steps int) {
+	t := e.Snap(e.cursor)
+	for ; steps > 0; steps-- {
+		t = e.Snap(t + e.GridStep(t))
+	}
+	for ; steps < 0; steps++ {
+		t = e.Snap(t - e.GridStep(t-1e-6))
+	}
+	e.SetCursor(t)
+}
+
+// SetCursor moves the cursor to a song time inside the timeline
+func (e *Editor) SetCursor(t float64) {
+	e.cursor = min(max(t, 0), e.Duration())
+}
+
+// TimeAt returns the song time at a height on the flat playfield
+func (e *Editor) TimeAt(l Layout, y float32) float64 {
+	return e.cursor + float64(l.HitLine-y)/float64(l.NoteSpeed(e.view))
+}
+
+// PointAt returns the lane and song time under a point on the flat playfield
+func (e *Editor) PointAt(l Layout, x, y float32) (int, float64, bool) {
+	for lane, rect := range l.Lanes {
+		if x >= rect.X && x < rect.X+rect.Width {
+			return lane, e.TimeAt(l, y), true
+		}
+	}
+	return 0, 0, false
+}
+
+// noteAt returns the index of the note in a lane whose gem or sustain covers a
+// song time, -1 if there is none
+func (e *Editor) noteAt(lane int, t float64) int {
+	tolerance := e.GridStep(t) / 2
+	for i, note := range e.notes {
+		if note.Lane == lane && t >= note.StartTime-tolerance && t <= note.StartTime+max(note.Duration, tolerance) {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOf returns the index of the note in a lane starting at a song time, -1 if there is none
+func (e *Editor) indexOf(lane int, start float64) int {
+	for i, note := range e.notes {
+		if note.Lane == lane && math.Abs(note.StartTime-start) < 1e-9 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Click selects the note under a point or places one on the grid there, and
+// starts stretching its sustain until Release
+func (e *Editor) Click(lane int, t float64) {
+	e.dragEdited = false
+	if index := e.noteAt(lane, t); index >= 0 {
+		e.selected = index
+	} else {
+		e.PlaceNote(lane, t)
+		e.dragEdited = true // Placing the note saved the undo step
+	}
+	e.dragging = e.selected >= 0
+	e.dragOrigin = e.Snap(t)
+	e.dragMoved = false
+}
+
+// Drag stretches the sustain of the clicked note to a song time, once the mouse
+// has moved off the grid line it was clicked on
+func (e *Editor) Drag(t float64) {
+	if !e.dragging {
+		return
+	}
+	end := e.Snap(t)
+	if !e.dragMoved && end == e.dragOrigin {
+		return
+	}
+	e.dragMoved = true
+	
+	if !e.dragEdited {
+		e.pushUndo()
+		e.dragEdited = true
+	}
+	e.setSustainEnd(e.selected, end)
+}
+
+// Release ends a drag
+func (e *Editor) Release() {
+	e.dragging = false
+}
+
+// PlaceNote adds a note on the grid line nearest a song time and selects it,
+// or selects the note already there
+func (e *Editor) PlaceNote(lane int, t float64) {
+	if lane < 0 || lane >= len(editorLanePitches) {
+		return
+	}
+	start := e.Snap(t)
+	if index := e.indexOf(lane, start); index >= 0 {
+		e.selected = index
+		return
+	}
+	
+	e.pushUndo()
+	e.notes = append(e.notes, EditorNote{
+		GameNote: GameNote{
+			StartTime:  start,
+			Lane:       lane,
+			IsActive:   true,
+			StarPhrase: -1,
+			Section:    -1,
+		},
+		Pitch:    editorLanePitches[lane],
+		Velocity: EDITOR_VELOCITY,
+	})
+	e.sortNotes()
+	e.selected = e.indexOf(lane, start)
+	e.assignTypes()
+}
+
+// ToggleNoteAtCursor places a note in a lane at the cursor, or deletes the one there
+func (e *Editor) ToggleNoteAtCursor(lane int) {
+	if index := e.indexOf(lane, e.Snap(e.cursor)); index >= 0 {
+		e.DeleteNote(index)
+		return
+	}
+	e.PlaceNote(lane, e.cursor)
+}
+
+// DeleteAt deletes the note under a point
+func (e *Editor) DeleteAt(lane int, t float64) {
+	e.DeleteNote(e.noteAt(lane, t))
+}
+
+// DeleteSelected deletes the selected note
+func (e *Editor) DeleteSelected() {
+	e.DeleteNote(e.selected)
+}
+
+// DeleteNote deletes a note by index
+func (e *Editor) DeleteNote(index int) {
+	if index < 0 || index >= len(e.notes) {
+		return
+	}
+	e.pushUndo()
+	e.notes = append(e.notes[:index], e.notes[index+1:]...)
+	e.selected = -1
+	e.assignTypes()
+}
+
+// AdjustSustain lengthens or shortens the selected note's sustain by grid steps
+func (e *Editor) AdjustSustain(steps int) {
+	if e.selected < 0 {
+		return
+	}
+	note := e.notes[e.selected]
+	end := note.StartTime + note.Duration
+	if note.Duration == 0 {
+		end = note.StartTime
+	}
+	for ; steps > 0; steps-- {
+		end = e.Snap(end + e.GridStep(end))
+	}
+	for ; steps < 0; steps++ {
+		end = e.Snap(end - e.GridStep(end-1e-6))
+	}
+	
+	e.pushUndo()
+	e.setSustainEnd(e.selected, end)
+}
+
+// setSustainEnd makes a note last until a song time, or removes its sustain
+// when the time is not after its start
+func (e *Editor) setSustainEnd(index int, end float64) {
+	note := &e.notes[index]
+	note.Duration = max(end-note.StartTime, 0)
+	e.dirty = true
+}
+
+// ToggleSelectedType makes the selected note a HOPO or tap, or back to a strum.
+// A type the game would not detect from the note's spacing is forced.
+func (e *Editor) ToggleSelectedType(noteType NoteType) {
+	if e.selected < 0 {
+		return
+	}
+	e.pushUndo()
+	note := &e.notes[e.selected]
+	if note.Type == noteType {
+		note.Type = NoteStrum
+	} else {
+		note.Type = noteType
+	}
+	note.Forced = note.Type != e.detectedTypes()[e.selected]
+}
+
+// assignTypes gives the notes whose type is not forced the type the game detects
+func (e *Editor) assignTypes() {
+	detected := e.detectedTypes()
+	for i := range e.notes {
+		if !e.notes[i].Forced {
+			e.notes[i].Type = detected[i]
+		}
+	}
+}
+
+// detectedTypes returns the type the game detects for each note from note spacing alone
+func (e *Editor) detectedTypes() []NoteType {
+	notes := e.gameNotes()
+	assignNoteTypes(notes, nil, nil, nil)
+	types := make([]NoteType, len(notes))
+	for i, note := range notes {
+		types[i] = note.Type
+	}
+	return types
+}
+
+// gameNotes returns a copy of the notes as the game holds them
+func (e *Editor) gameNotes() []GameNote {
+	notes := make([]GameNote, len(e.notes))
+	for i, note := range e.notes {
+		notes[i] = note.GameNote
+	}
+	return notes
+}
+
+// pushUndo saves the notes before an edit
+func (e *Editor) pushUndo() {
+	e.undoStack = append(e.undoStack, copyNotes(e.notes))
+	if len(e.undoStack) > EDITOR_MAX_UNDO {
+		e.undoStack = e.undoStack[1:]
+	}
+	e.redoStack = nil
+	e.dirty = true
+	e.confirmDiscard = false
+}
+
+// Undo reverts the last edit
+func (e *Editor) Undo() {
+	if len(e.undoStack) == 0 {
+		return
+	}
+	e.redoStack = append(e.redoStack, copyNotes(e.notes))
+	e.notes = e.undoStack[len(e.undoStack)-1]
+	e.undoStack = e.undoStack[:len(e.undoStack)-1]
+	e.selected = -1
+	e.dirty = true
+}
+
+// Redo applies the last undone edit again
+func (e *Editor) Redo() {
+	if len(e.redoStack) == 0 {
+		return
+	}
+	e.undoStack = append(e.undoStack, copyNotes(e.notes))
+	e.notes = e.redoStack[len(e.redoStack)-1]
+	e.redoStack = e.redoStack[:len(e.redoStack)-1]
+	e.selected = -1
+	e.dirty = true
+}
+
+// sortNotes keeps notes in time order, keeping the selection on the same note
+func (e *Editor) sortNotes() {
+	var selected *EditorNote
+	if e.selected >= 0 && e.selected < len(e.notes) {
+		selected = &EditorNote{}
+		*selected = e.notes[e.selected]
+	}
+	sort.SliceStable(e.notes, func(i, j int) bool {
+		if e.notes[i].StartTime != e.notes[j].StartTime {
+			return e.notes[i].StartTime < e.notes[j].StartTime
+		}
+		return e.notes[i].Lane < e.notes[j].Lane
+	})
+	if selected != nil {
+		e.selected = e.indexOf(selected.Lane, selected.StartTime)
+	}
+}
+
+// copyNotes returns a copy of a note slice
+func copyNotes(notes []EditorNote) []EditorNote {
+	copied := make([]EditorNote, len(notes))
+	copy(copied, notes)
+	return copied
+}
+
+// MIDINotes returns the chart's notes as MIDI notes with the pitches they were read with
+func (e *Editor) MIDINotes() []MIDINote {
+	notes := make([]MIDINote, 0, len(e.notes))
+	for _, note := range e.notes {
+		notes = append(notes, MIDINote{
+			Pitch:     note.Pitch,
+			Velocity:  note.Velocity,
+			StartTime: note.StartTime,
+			Duration:  note.Duration,
+			Lane:      note.Lane,
+		})
+	}
+	return notes
+}
+
+// TogglePlayback plays the chart with the synth from the cursor, or stops it
+func (e *Editor) TogglePlayback() {
+	if e.playing {
+		e.StopPlayback()
+		return
+	}
+	
+	e.playing = true
+	e.playStart = e.clock.Now()
+	e.playFrom = e.cursor
+	if e.audioManager != nil {
+		if err := e.audioManager.LoadMIDITrack(e.MIDINotes()); err != nil {
+			fmt.Printf("Warning: Failed to load chart audio: %v\n", err)
+			return
+		}
+		if err := e.audioManager.StartPlaybackAt(e.playFrom, 1); err != nil {
+			fmt.Printf("Warning: Failed to start audio playback: %v\n", err)
+		}
+	}
+}
+
+// StopPlayback stops playing, leaving the cursor where playback got to
+func (e *Editor) StopPlayback() {
+	e.playing = false
+	if e.audioManager != nil {
+		e.audioManager.StopPlayback()
+	}
+}
+
+// Update moves the cursor along with playback
+func (e *Editor) Update() {
+	if !e.playing {
+		return
+	}
+	e.SetCursor(e.playFrom + e.clock.Now().Sub(e.playStart).Seconds())
+	if e.cursor >= e.Duration() {
+		e.StopPlayback()
+	}
+}
+
+// BackupPath returns where the chart file is copied before it is first saved over
+func (e *Editor) BackupPath() string {
+	return e.path + EDITOR_BACKUP_EXT
+}
+
+// Save writes the chart back to its MIDI file. Only the guitar track, tempo map
+// and sections are written, so the file is first copied to BackupPath unless a
+// copy is already there from an earlier session.
+func (e *Editor) Save() error {
+	if e.path == "" {
+		return fmt.Errorf("chart has no file to save to")
+	}
+	if _, err := os.Stat(e.BackupPath()); os.IsNotExist(err) {
+		data, err := os.ReadFile(e.path)
+		if err != nil {
+			return fmt.Errorf("failed to read chart for backup: %v", err)
+		}
+		if err := os.WriteFile(e.BackupPath(), data, 0644); err != nil {
+			return fmt.Errorf("failed to back up chart: %v", err)
+		}
+		fmt.Printf("Kept the original chart in %s\n", e.BackupPath())
+	}
+	if err := NewMIDIWriter(e.tempoMap).WriteFile(e.path, []MIDITrack{e.chartTrack()}); err != nil {
+		return err
+	}
+	e.dirty = false
+	e.saved = true
+	fmt.Printf("Saved chart to %s\n", e.path)
+	return nil
+}
+
+// RequestClose returns whether the editor can be closed, asking once for
+// confirmation when there are unsaved changes
+func (e *Editor) RequestClose() bool {
+	if !e.dirty || e.confirmDiscard {
+		return true
+	}
+	e.confirmDiscard = true
+	return false
+}
+
+// IsConfirmingDiscard returns whether closing again will discard unsaved changes
+func (e *Editor) IsConfirmingDiscard() bool {
+	return e.confirmDiscard
+}
+
+// chartTrack returns the edited chart as a guitar track, with a marker note over
+// each note whose type is forced and the notes outside the guitar range as they were read
+func (e *Editor) chartTrack() MIDITrack {
+	track := e.track
+	track.Notes = e.MIDINotes()
+	track.StarPowerPhrases = e.starPower
+	track.Sections = e.sections
+	
+	// A sustain running into the next note in its lane would overlap it in the file
+	for i := range track.Notes {
+		note := &track.Notes[i]
+		for _, next := range track.Notes[i+1:] {
+			if next.Lane == note.Lane && next.StartTime > note.StartTime {
+				note.Duration = min(note.Duration, next.StartTime-note.StartTime)
+				break
+			}
+		}
+	}
+	track.Notes = append(track.Notes, e.dropped...)
+	
+	for _, note := range e.notes {
+		if !note.Forced {
+			continue
+		}
+		// A marker needs a length, one tick is enough to cover the note's start
+		phrase := Phrase{StartTime: note.StartTime, EndTime: note.StartTime + e.tickLength(note.StartTime)}
+		switch note.Type {
+		case NoteStrum:
+			track.ForcedStrums = append(track.ForcedStrums, phrase)
+		case NoteHOPO:
+			track.ForcedHOPOs = append(track.ForcedHOPOs, phrase)
+		case NoteTap:
+			track.TapPhrases = append(track.TapPhrases, phrase)
+		}
+	}
+	return track
+}
+
+// tickLength returns the length of one tick at a song time
+func (e *Editor) tickLength(t float64) float64 {
+	tempo := e.tempoMap.TempoAt(e.tempoMap.SecondsToTicks(t))
+	return float64(tempo) / (float64(e.tempoMap.TicksPerBeat) * 1000000.0)
+}
+
+// OpenEditor opens the selected song in the chart editor
+func (g *Game) OpenEditor() error {
+	if err := g.LoadSelectedSong(); err != nil {
+		return err
+	}
+	if g.midiProcessor == nil {
+		return fmt.Errorf("no song loaded")
+	}
+	
+	editor, err := NewEditor(g.midiProcessor, g.audioManager, g.clock)
+	if err != nil {
+		return err
+	}
+	g.editor = editor
+	g.state = StateEditor
+	return nil
+}
+
+// Editor returns the open chart editor, nil if none
+func (g *Game) Editor() *Editor {
+	return g.editor
+}
+
+// CloseEditor leaves the editor for song select, reloading the song so a saved
+// chart is played as edited
+func (g *Game) CloseEditor() {
+	if g.editor == nil {
+		return
+	}
+	g.editor.StopPlayback()
+	
+	midiProcessor := g.midiProcessor
+	if g.editor.saved {
+		midiProcessor = NewMIDIProcessor()
+		if err := midiProcessor.LoadMIDI(g.editor.Path()); err != nil {
+			fmt.Printf("Failed to reload chart: %v\n", err)
+			midiProcessor = g.midiProcessor
+		}
+	}
+	
+	// Playback replaced the song's audio with the chart's
+	if err := g.LoadMIDITrack(midiProcessor); err != nil {
+		fmt.Printf("Failed to reload chart: %v\n", err)
+	}
+	if g.selectedSong >= 0 && g.selectedSong < len(g.songs) {
+		g.songs[g.selectedSong].Hash = g.songHash
+		g.songs[g.selectedSong].Rating = RateSong(g.songs[g.selectedSong].Path)
+	}
+	
+	g.editor = nil
+	g.state = StateSongSelect
+}
\ No newline at end of file
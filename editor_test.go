@@ -0,0 +1,312 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestChart writes a chart at 120 BPM that drops to 60 BPM at bar 2, with a
+// note on every beat of bar 1, a bass note outside the guitar range and a named
+// section, and opens it in the editor
+func openTestChart(t *testing.T) (*Editor, *ManualClock) {
+	t.Helper()
+	
+	tempoTrack := midiTrackChunk(
+		midiEvent(0, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20),
+		midiEvent(0, 0xFF, 0x06, 0x05, 'I', 'n', 't', 'r', 'o'),
+		midiEvent(1920, 0xFF, 0x51, 0x03, 0x0F, 0x42, 0x40),
+		midiEvent(1920, 0xFF, 0x01, 0x00), // Empty text event so the song lasts to bar 3
+	)
+	noteTrack := midiTrackChunk(
+		midiEvent(0, 0x90, 52, 100), midiEvent(0, 0x90, 30, 80),
+		midiEvent(240, 0x80, 52, 0), midiEvent(0, 0x80, 30, 0),
+		midiEvent(240, 0x90, 67, 90), midiEvent(240, 0x80, 67, 0),
+		midiEvent(240, 0x90, 76, 100), midiEvent(240, 0x80, 76, 0),
+		midiEvent(240, 0x90, 64, 100), midiEvent(480, 0x80, 64, 0),
+	)
+	
+	mp := NewMIDIProcessor()
+	if err := mp.LoadMIDI(writeMIDIFile(t, 480, tempoTrack, noteTrack)); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	clock := NewManualClock()
+	editor, err := NewEditor(mp, nil, clock)
+	if err != nil {
+		t.Fatalf("NewEditor failed: %v", err)
+	}
+	return editor, clock
+}
+
+func TestEditorSnapsToBeatGrid(t *testing.T) {
+	editor, _ := openTestChart(t)
+	
+	if editor.SnapName() != "1/16" {
+		t.Errorf("SnapName() = %q, want 1/16 by default", editor.SnapName())
+	}
+	if got := editor.Snap(0.3); got != 0.25 {
+		t.Errorf("Snap(0.3) = %v, want 0.25", got)
+	}
+	// Beats are twice as long after the tempo drops at 2s
+	if got := editor.GridStep(2.5); got != 0.25 {
+		t.Errorf("GridStep(2.5) = %v, want 0.25 at 60 BPM", got)
+	}
+	if got := editor.Snap(1.999); got != 2 {
+		t.Errorf("Snap(1.999) = %v, want the bar 2 downbeat", got)
+	}
+	
+	editor.ChangeSnap(-10)
+	if editor.SnapName() != "1/4" {
+		t.Errorf("SnapName() = %q, want the coarsest grid 1/4", editor.SnapName())
+	}
+	editor.Scrub(5)
+	if got := editor.Cursor(); got != 3 {
+		t.Errorf("Cursor() = %v after five beats, want 3 (four at 120 BPM, one at 60)", got)
+	}
+	editor.Scrub(-2)
+	if got := editor.Cursor(); got != 1.5 {
+		t.Errorf("Cursor() = %v after two beats back, want 1.5", got)
+	}
+}
+
+func TestEditorPlaceDeleteUndoRedo(t *testing.T) {
+	editor, _ := openTestChart(t)
+	count := len(editor.Notes())
+	
+	editor.PlaceNote(0, 0.51)
+	if len(editor.Notes()) != count+1 {
+		t.Fatalf("PlaceNote left %d notes, want %d", len(editor.Notes()), count+1)
+	}
+	placed := editor.Notes()[editor.Selected()]
+	if placed.Lane != 0 || placed.StartTime != 0.5 {
+		t.Errorf("placed note = lane %d at %v, want lane 0 snapped to 0.5", placed.Lane, placed.StartTime)
+	}
+	
+	// Placing on an existing note selects it
+	editor.PlaceNote(0, 0.49)
+	if len(editor.Notes()) != count+1 {
+		t.Errorf("placing on a note added another, %d notes", len(editor.Notes()))
+	}
+	
+	editor.DeleteAt(1, 0.5)
+	if len(editor.Notes()) != count {
+		t.Fatalf("DeleteAt left %d notes, want %d", len(editor.Notes()), count)
+	}
+	
+	editor.Undo()
+	if len(editor.Notes()) != count+1 {
+		t.Errorf("Undo left %d notes, want the deleted note back", len(editor.Notes()))
+	}
+	editor.Undo()
+	if len(editor.Notes()) != count {
+		t.Errorf("second Undo left %d notes, want the placed note gone", len(editor.Notes()))
+	}
+	editor.Redo()
+	editor.Redo()
+	if len(editor.Notes()) != count {
+		t.Errorf("Redo twice left %d notes, want %d", len(editor.Notes()), count)
+	}
+	if !editor.IsDirty() {
+		t.Errorf("editor should have unsaved changes")
+	}
+	if editor.RequestClose() || !editor.RequestClose() {
+		t.Errorf("closing with unsaved changes should ask once, then close")
+	}
+}
+
+func TestEditorDragStretchesSustain(t *testing.T) {
+	editor, _ := openTestChart(t)
+	count := len(editor.Notes())
+	
+	// Clicking a sustain without moving keeps its length
+	editor.Click(1, 1.5)
+	editor.Drag(1.52)
+	editor.Release()
+	if note := editor.Notes()[editor.Selected()]; note.StartTime != 1.5 || note.Duration != 0.5 {
+		t.Errorf("clicked note = %+v, want the sustain at 1.5 still 0.5s long", note)
+	}
+	
+	// A new note dragged upwards becomes a sustain in one undo step
+	editor.Click(2, 0.75)
+	editor.Drag(1.0)
+	editor.Drag(1.26)
+	editor.Release()
+	note := editor.Notes()[editor.Selected()]
+	if note.StartTime != 0.75 || note.Duration != 0.5 {
+		t.Errorf("dragged note = %+v, want it at 0.75 lasting 0.5s", note)
+	}
+	editor.Undo()
+	if len(editor.Notes()) != count {
+		t.Errorf("Undo left %d notes, want the placed note gone", len(editor.Notes()))
+	}
+	
+	// Keyboard stretching goes a grid step at a time and stops at zero
+	editor.PlaceNote(0, 3)
+	editor.AdjustSustain(2)
+	if note := editor.Notes()[editor.Selected()]; note.Duration != 0.5 {
+		t.Errorf("Duration = %v after two steps at 60 BPM, want 0.5", note.Duration)
+	}
+	editor.AdjustSustain(-5)
+	if note := editor.Notes()[editor.Selected()]; note.Duration != 0 {
+		t.Errorf("Duration = %v, want no sustain", note.Duration)
+	}
+}
+
+func TestEditorSaveRoundTrip(t *testing.T) {
+	editor, _ := openTestChart(t)
+	
+	editor.PlaceNote(1, 2.5)
+	editor.ToggleSelectedType(NoteTap)
+	editor.PlaceNote(2, 0.75)
+	editor.ToggleSelectedType(NoteHOPO)
+	editor.AdjustSustain(2)
+	original, err := os.ReadFile(editor.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if editor.IsDirty() {
+		t.Errorf("editor should be clean after saving")
+	}
+	
+	// The file as it was before the first save is kept, later saves leave it alone
+	if err := editor.Save(); err != nil {
+		t.Fatalf("second Save failed: %v", err)
+	}
+	if backup, err := os.ReadFile(editor.BackupPath()); err != nil || string(backup) != string(original) {
+		t.Errorf("backup at %s does not hold the original file (%v)", editor.BackupPath(), err)
+	}
+	
+	mp := NewMIDIProcessor()
+	if err := mp.LoadMIDI(editor.Path()); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	reloaded, err := NewEditor(mp, nil, NewManualClock())
+	if err != nil {
+		t.Fatalf("NewEditor failed: %v", err)
+	}
+	
+	want, got := editor.Notes(), reloaded.Notes()
+	if len(got) != len(want) {
+		t.Fatalf("reloaded %d notes, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Lane != want[i].Lane || got[i].Type != want[i].Type ||
+			got[i].Pitch != want[i].Pitch || got[i].Velocity != want[i].Velocity ||
+			math.Abs(got[i].StartTime-want[i].StartTime) > 1e-6 || math.Abs(got[i].Duration-want[i].Duration) > 1e-6 {
+			t.Errorf("note %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	
+	// Notes read from the file keep their pitch, placed notes get their lane's
+	if note := got[1]; note.Pitch != 67 || note.Velocity != 90 {
+		t.Errorf("note at 0.5s = pitch %d velocity %d, want 67 at 90 as read", note.Pitch, note.Velocity)
+	}
+	if note := got[len(got)-1]; note.Pitch != editorLanePitches[1] || note.Velocity != EDITOR_VELOCITY {
+		t.Errorf("placed note = pitch %d velocity %d, want the lane pitch", note.Pitch, note.Velocity)
+	}
+	dropped := mp.DroppedNotes()
+	if len(dropped) != 1 || dropped[0].Pitch != 30 || dropped[0].Velocity != 80 || dropped[0].Duration != 0.25 {
+		t.Errorf("DroppedNotes() = %+v, want the bass note kept", dropped)
+	}
+	
+	// The tempo map and sections survive
+	if got := reloaded.GridStep(2.5); got != 0.25 {
+		t.Errorf("GridStep(2.5) = %v after reloading, want 0.25", got)
+	}
+	track, _ := mp.FindGuitarTrack()
+	if len(track.Sections) != 1 || track.Sections[0].Name != "Intro" || track.AutoSections {
+		t.Errorf("Sections = %+v, want the Intro marker", track.Sections)
+	}
+}
+
+func TestEditorShowsAndUnflagsDetectedHOPOs(t *testing.T) {
+	editor, _ := openTestChart(t)
+	editor.ChangeSnap(2) // Eighth of a beat, 0.125s at 60 BPM
+	
+	editor.PlaceNote(0, 2)
+	editor.PlaceNote(1, 2.125)
+	note := editor.Notes()[editor.Selected()]
+	if note.Type != NoteHOPO || note.Forced {
+		t.Fatalf("note 0.125s after another lane = %s (forced %v), want a detected HOPO", note.Type, note.Forced)
+	}
+	
+	// Un-flagging a detected HOPO forces it to be strummed
+	editor.ToggleSelectedType(NoteHOPO)
+	if note := editor.Notes()[editor.Selected()]; note.Type != NoteStrum || !note.Forced {
+		t.Fatalf("toggled note = %s (forced %v), want a forced strum", note.Type, note.Forced)
+	}
+	if err := editor.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	
+	mp := NewMIDIProcessor()
+	if err := mp.LoadMIDI(editor.Path()); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	game := NewHeadlessGame(NewScriptedInput(nil), NewManualClock())
+	if err := game.LoadMIDITrack(mp); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	for _, note := range game.gameNotes {
+		if note.Lane == 1 && note.Type != NoteStrum {
+			t.Errorf("game plays the un-flagged note as a %s, want a strum", note.Type)
+		}
+	}
+}
+
+func TestEditorPlaybackMovesCursor(t *testing.T) {
+	editor, clock := openTestChart(t)
+	editor.SetCursor(1)
+	
+	editor.TogglePlayback()
+	clock.Advance(1500 * time.Millisecond)
+	editor.Update()
+	if got := editor.Cursor(); math.Abs(got-2.5) > 1e-9 {
+		t.Errorf("Cursor() = %v after 1.5s of playback from 1s, want 2.5", got)
+	}
+	
+	editor.TogglePlayback()
+	clock.Advance(time.Second)
+	editor.Update()
+	if editor.IsPlaying() || math.Abs(editor.Cursor()-2.5) > 1e-9 {
+		t.Errorf("playback should stop with the cursor where it got to, at %v", editor.Cursor())
+	}
+}
+
+func TestEditorPointAt(t *testing.T) {
+	editor, _ := openTestChart(t)
+	editor.SetCursor(2)
+	l := NewLayout(DESIGN_WIDTH*2, DESIGN_HEIGHT*2)
+	
+	lane, at, ok := editor.PointAt(l, l.LaneCenter(1), l.HitLine-l.Scaled(NOTE_SPEED))
+	if !ok || lane != 1 || math.Abs(at-3) > 1e-6 {
+		t.Errorf("PointAt = lane %d at %v (%v), want lane 1 a second after the cursor", lane, at, ok)
+	}
+	if _, _, ok := editor.PointAt(l, 1, l.HitLine); ok {
+		t.Errorf("PointAt left of the lanes should miss")
+	}
+}
+
+func TestEditorRefusesUnparsedChart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.mid")
+	if err := os.WriteFile(path, []byte("not a MIDI file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// The game falls back to test notes, which must not be saved over the file
+	mp := NewMIDIProcessor()
+	if err := mp.LoadMIDI(path); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	if mp.Parsed() {
+		t.Fatalf("Parsed() = true for a file that is not MIDI")
+	}
+	if _, err := NewEditor(mp, nil, NewManualClock()); err == nil {
+		t.Errorf("NewEditor should refuse the test chart loaded in place of the file")
+	}
+}
\ No newline at end of file
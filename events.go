@@ -0,0 +1,42 @@
+package main
+
+// COMBO_MILESTONE is the combo step that publishes EventComboMilestone
+const COMBO_MILESTONE = 50
+
+// GameEventType identifies what happened in a GameEvent
+type GameEventType int
+
+const (
+	EventRunStarted     GameEventType = iota // A new run started
+	EventNoteHit                             // A note's start was hit, Offset holds the timing
+	EventNoteMissed                          // A note scrolled past without being hit
+	EventGhostPress                          // A penalized press that hit no note
+	EventComboMilestone                      // The combo reached a multiple of COMBO_MILESTONE
+)
+
+// GameEvent describes something that happened during play, for feedback that is not part of scoring
+type GameEvent struct {
+	Type     GameEventType
+	Time     float64 // Song time of the event in seconds
+	Lane     int
+	Accuracy HitAccuracy
+	Offset   float64 // Signed hit timing in seconds, negative is early
+	Combo    int32
+}
+
+// EventBus delivers game events to subscribers synchronously, in the order they subscribed
+type EventBus struct {
+	handlers []func(GameEvent)
+}
+
+// Subscribe registers a handler called for every published event
+func (b *EventBus) Subscribe(handler func(GameEvent)) {
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish calls every subscribed handler with the event
+func (b *EventBus) Publish(event GameEvent) {
+	for _, handler := range b.handlers {
+		handler(event)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGameEventsReportHitOffsets(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.5, 0.1),
+		laneNote(2, 1.0, 0.1),
+	}
+	events := append(Press(0, firstNoteTime-0.03, 0.05), Press(1, firstNoteTime+0.5+0.07, 0.05)...)
+	
+	clock := NewManualClock()
+	game := NewHeadlessGame(NewScriptedInput(events), clock)
+	if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes(notes)); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	received := make([]GameEvent, 0)
+	game.events.Subscribe(func(event GameEvent) {
+		received = append(received, event)
+	})
+	
+	Simulate(game, clock, HEADLESS_FPS)
+	
+	want := []struct {
+		kind     GameEventType
+		lane     int
+		accuracy HitAccuracy
+		offset   float64
+	}{
+		{EventRunStarted, 0, Miss, 0},
+		{EventNoteHit, 0, Perfect, -0.03},
+		{EventNoteHit, 1, Good, 0.07},
+		{EventNoteMissed, 2, Miss, 0},
+	}
+	if len(received) != len(want) {
+		t.Fatalf("received %d events, want %d: %+v", len(received), len(want), received)
+	}
+	for i, w := range want {
+		got := received[i]
+		if got.Type != w.kind || got.Lane != w.lane || got.Accuracy != w.accuracy || math.Abs(got.Offset-w.offset) > 1e-9 {
+			t.Errorf("event %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if offset := game.gameNotes[1].HitOffset; math.Abs(offset-0.07) > 1e-9 {
+		t.Errorf("HitOffset = %.3f, want 0.070", offset)
+	}
+}
+
+func TestHitFeedback(t *testing.T) {
+	game := newGame(nil, NewManualClock())
+	feedback := NewHitFeedback(game)
+	
+	for i := 0; i < HIT_TICK_COUNT+5; i++ {
+		game.events.Publish(GameEvent{Type: EventNoteHit, Lane: i % 3, Accuracy: Good, Offset: float64(i) / 1000})
+	}
+	count := feedback.TickCount()
+	if count != HIT_TICK_COUNT {
+		t.Fatalf("TickCount() = %d, want %d", count, HIT_TICK_COUNT)
+	}
+	if feedback.Tick(0).Offset != 0.005 || feedback.Tick(count-1).Offset != float64(HIT_TICK_COUNT+4)/1000 {
+		t.Errorf("ticks should keep the latest hits oldest first, got %.3f to %.3f",
+			feedback.Tick(0).Offset, feedback.Tick(count-1).Offset)
+	}
+	// The renderer reads the ticks every frame, so reading them must not allocate
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := 0; i < feedback.TickCount(); i++ {
+			_ = feedback.Tick(i)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("reading the ticks allocated %.0f times, want 0", allocs)
+	}
+	// One popup per lane at most
+	if len(feedback.popups) != 3 {
+		t.Errorf("len(popups) = %d, want 3", len(feedback.popups))
+	}
+	
+	game.events.Publish(GameEvent{Type: EventRunStarted})
+	if feedback.TickCount() != 0 || len(feedback.popups) != 0 {
+		t.Errorf("a new run should clear the feedback")
+	}
+}
+
+func TestJudgementText(t *testing.T) {
+	tests := []struct {
+		accuracy HitAccuracy
+		offset   float64
+		want     string
+	}{
+		{Perfect, 0.01, "PERFECT"},
+		{Good, 0.032, "GOOD +32ms LATE"},
+		{OK, -0.12, "OK -120ms EARLY"},
+		{Miss, 0, "MISS"},
+	}
+	
+	for _, tt := range tests {
+		if got := judgementText(tt.accuracy, tt.offset); got != tt.want {
+			t.Errorf("judgementText(%v, %.3f) = %q, want %q", tt.accuracy, tt.offset, got, tt.want)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Hit feedback constants
+const (
+	JUDGEMENT_POPUP_TIME = 0.6 // Seconds a judgement stays on screen
+	JUDGEMENT_POPUP_RISE = 40  // Pixels a judgement floats up while fading
+	HIT_TICK_COUNT       = 20  // Recent hits shown on the early/late bar
+	HIT_TICK_BAR_WIDTH   = 240
+)
+
+// judgementPopup is floating judgement text above a lane
+type judgementPopup struct {
+	Text  string
+	Color rl.Color
+	Lane  int
+	Time  float64 // Song time the popup appeared
+}
+
+// hitTick is one recent hit on the early/late bar
+type hitTick struct {
+	Offset   float64
+	Accuracy HitAccuracy
+}
+
+// HitFeedback collects judgement popups and recent hit offsets from game events
+type HitFeedback struct {
+	popups []judgementPopup
+	ticks  [HIT_TICK_COUNT]hitTick // Ring buffer of the latest hits
+	next   int
+	count  int
+}
+
+// NewHitFeedback creates hit feedback that listens to the game's events
+func NewHitFeedback(game *Game) *HitFeedback {
+	feedback := &HitFeedback{}
+	game.events.Subscribe(feedback.handle)
+	return feedback
+}
+
+// handle updates the feedback for one game event
+func (f *HitFeedback) handle(event GameEvent) {
+	switch event.Type {
+	case EventRunStarted:
+		f.popups = f.popups[:0]
+		f.next = 0
+		f.count = 0
+	case EventNoteHit:
+		f.showPopup(event.Lane, judgementText(event.Accuracy, event.Offset), accuracyColor(event.Accuracy), event.Time)
+		f.ticks[f.next] = hitTick{Offset: event.Offset, Accuracy: event.Accuracy}
+		f.next = (f.next + 1) % HIT_TICK_COUNT
+		if f.count < HIT_TICK_COUNT {
+			f.count++
+		}
+	case EventNoteMissed:
+		f.showPopup(event.Lane, "MISS", accuracyColor(Miss), event.Time)
+	case EventGhostPress:
+		f.showPopup(event.Lane, "GHOST", rl.Orange, event.Time)
+	}
+}
+
+// showPopup replaces the lane's popup so judgements never stack up
+func (f *HitFeedback) showPopup(lane int, text string, color rl.Color, time float64) {
+	popup := judgementPopup{Text: text, Color: color, Lane: lane, Time: time}
+	for i := range f.popups {
+		if f.popups[i].Lane == lane {
+			f.popups[i] = popup
+			return
+		}
+	}
+	f.popups = append(f.popups, popup)
+}
+
+// TickCount returns how many recent hits are kept
+func (f *HitFeedback) TickCount() int {
+	return f.count
+}
+
+// Tick returns the i-th recent hit, oldest first, read straight from the ring buffer
+func (f *HitFeedback) Tick(i int) hitTick {
+	start := f.next - f.count + HIT_TICK_COUNT
+	return f.ticks[(start+i)%HIT_TICK_COUNT]
+}
+
+// judgementText formats a judgement with its timing, like "GOOD +32ms LATE"
+func judgementText(accuracy HitAccuracy, offset float64) string {
+	label := strings.ToUpper(accuracy.String())
+	if accuracy == Perfect || accuracy == Miss {
+		return label
+	}
+	
+	ms := int(math.Round(offset * 1000))
+	direction := "LATE"
+	if ms < 0 {
+		direction = "EARLY"
+	}
+	return fmt.Sprintf("%s %+dms %s", label, ms, direction)
+}
+
+// accuracyColor returns the color used for an accuracy everywhere in the UI
+func accuracyColor(accuracy HitAccuracy) rl.Color {
+	switch accuracy {
+	case Perfect:
+		return rl.Gold
+	case Good:
+		return rl.Green
+	case OK:
+		return rl.Blue
+	default:
+		return rl.Red
+	}
+}
+
+// drawJudgementPopups draws the floating judgements above the hit line
+func (r *Renderer) drawJudgementPopups() {
+	now := r.game.currentTime
+	for _, popup := range r.feedback.popups {
+		age := now - popup.Time
+		if age < 0 || age > JUDGEMENT_POPUP_TIME || popup.Lane < 0 || popup.Lane >= len(r.game.lanes) {
+			continue
+		}
+		progress := float32(age / JUDGEMENT_POPUP_TIME)
+		
+		hitX, hitY, _ := r.hitPoint(popup.Lane)
+		size := r.layout.Font(20)
+		textWidth := rl.MeasureText(popup.Text, size)
+		x := int32(hitX) - textWidth/2
+		y := int32(hitY - r.layout.Scaled(60+JUDGEMENT_POPUP_RISE*progress))
+		rl.DrawText(popup.Text, x, y, size, rl.ColorAlpha(popup.Color, 1-progress))
+	}
+}
+
+// drawHitTickBar draws the latest hit offsets on a bar below the hit line, early
+// hits left of the center, with the judgement windows shaded behind them
+func (r *Renderer) drawHitTickBar() {
+	judgement := r.game.Judgement()
+	l := r.layout
+	halfWidth := l.Scaled(HIT_TICK_BAR_WIDTH / 2)
+	centerX := float32(l.CenterX())
+	_, hitY, _ := r.hitPoint(1)
+	y := int32(hitY + l.Scaled(45))
+	height := l.Px(10)
+	
+	// The bar spans the widest side of the OK window
+	scale := halfWidth / float32(math.Max(judgement.OKEarly, judgement.OKLate))
+	zone := func(early, late float64, color rl.Color) {
+		left := centerX - float32(early)*scale
+		width := float32(early+late) * scale
+		rl.DrawRectangle(int32(left), y, int32(width), height, rl.ColorAlpha(color, 0.35))
+	}
+	zone(judgement.OKEarly, judgement.OKLate, accuracyColor(OK))
+	zone(judgement.GoodEarly, judgement.GoodLate, accuracyColor(Good))
+	zone(judgement.PerfectEarly, judgement.PerfectLate, accuracyColor(Perfect))
+	rl.DrawLine(int32(centerX), y-4, int32(centerX), y+height+4, rl.White)
+	
+	// Newer ticks are brighter
+	count := r.feedback.TickCount()
+	for i := 0; i < count; i++ {
+		tick := r.feedback.Tick(i)
+		x := int32(centerX + float32(tick.Offset)*scale)
+		alpha := float32(i+1) / float32(count)
+		rl.DrawRectangle(x-1, y-l.Px(3), 2, height+l.Px(6), rl.ColorAlpha(accuracyColor(tick.Accuracy), alpha))
+	}
+	
+	rl.DrawText("EARLY", int32(centerX-halfWidth)-l.Px(50), y-l.Px(2), l.Font(12), rl.Gray)
+	rl.DrawText("LATE", int32(centerX+halfWidth)+l.Px(10), y-l.Px(2), l.Font(12), rl.Gray)
+}
\ No newline at end of file
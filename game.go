@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
 	"time"
 	
 	rl "github.com/gen2brain/raylib-go/raylib"
@@ -12,14 +15,18 @@ type GameState int
 
 const (
 	StateMenu GameState = iota
+	StateSongSelect
 	StatePlaying
 	StateGameOver
+	StateFailed
+	StateControllerMap
+	StateModifiers
+	StatePracticeMenu
+	StateEditor
 )
 
 // Game represents the main game state
 type Game struct {
-	screenWidth    int32
-	screenHeight   int32
 	midiProcessor  *MIDIProcessor
 	audioManager   *AudioManager
 	gameNotes      []GameNote
@@ -28,10 +35,45 @@ type Game struct {
 	maxCombo       int32
 	state          GameState
 	gameStartTime  time.Time
+	timeOffset     float64 // Song time at gameStartTime
+	playbackSpeed  float64 // Song seconds per real second, slower in practice
 	currentTime    float64
 	songDuration   float64
-	hitLine        float32 // Y position of the hit line
 	lanes          [3]Lane
+	input          InputSource // Input driving the current run
+	playerInput    InputSource // Input used when not replaying or on autoplay
+	clock          Clock
+	bot            *Bot // Autoplay bot, nil when the player is playing
+	gamepad        *GamepadInput     // nil for headless games
+	mapper         *ControllerMapper // Controller mapping screen
+	strumMode      bool    // Lane keys are held frets and the strum bar judges them
+	fretsDown      [3]bool // Frets held at the time of the event being handled
+	lastFretHit    float64 // Song time of the last note hit by fretting alone
+	
+	// Whammy
+	whammy          float32 // Whammy position from 0 to 1
+	whammyChargedTo float64 // Song time Star Power from whammy has been added up to
+	
+	// Replays
+	songHash       string
+	recordedEvents []InputEvent
+	recordReplays  bool    // Save a replay file when the game ends
+	replay         *Replay // Replay being played back, nil for live play
+	lastReplayPath string
+	
+	// Song selection and scores
+	songs          []SongEntry
+	selectedSong   int
+	difficulty     Difficulty
+	scores         *ScoreDatabase // nil when scores are not saved
+	playerName     string
+	lastScoreRank  int // Rank of the last run in the leaderboard, 0 if not saved
+	judgement      JudgementProfile // Timing windows the next runs are judged with
+	
+	// Scroll speed and highway modifiers
+	modifiers        Modifiers
+	selectedModifier ModifierOption // Highlighted row on the modifiers menu
+	laneMap          [3]int         // Lane each chart lane's notes are currently in
 	
 	// Statistics
 	perfectHits    int32
@@ -39,6 +81,40 @@ type Game struct {
 	okHits         int32
 	missedHits     int32
 	totalNotes     int32
+	maxScore       int32     // Score for a perfect run of the loaded chart
+	hitOffsets     []float64 // Signed timing of each hit in seconds, negative is early
+	
+	// Notifies the renderer of hits and misses
+	events EventBus
+	
+	// Presses that hit no note, counted on difficulties that penalize them
+	ghostPresses     int32 // Presses with no note in the hit window
+	wrongLanePresses int32 // Presses while a note in another lane was in the hit window
+	
+	// Tempo map and named parts of the song in game time
+	beats        []Beat
+	sections     []Section
+	sectionStats []SectionStats // Judgements in each section during the run
+	
+	// Practice mode
+	practice               PracticeSettings
+	practicing             bool // The run loops the practice section
+	selectedPracticeOption PracticeOption
+	practiceLoops          int
+	lastLoopAccuracy       float64
+	
+	// Chart editor, nil unless it is open
+	editor *Editor
+	
+	// Star Power
+	starPhrases      []StarPhrase
+	starPowerMeter   float64 // Meter while Star Power is not active, 0 to 1
+	starPowerEndTime float64 // Song time when active Star Power runs out
+	
+	// Rock meter
+	rockMeter float64 // 0 to 1, the song fails when it runs out
+	noFail    bool    // Keep playing when the rock meter runs out
+	failTime  float64 // Song time the run was failed at
 }
 
 // GameNote represents a note in the game
@@ -46,24 +122,53 @@ type GameNote struct {
 	StartTime    float64
 	Duration     float64
 	Lane         int
-	Y            float32 // Current Y position on screen
-	Width        float32
-	Height       float32
 	IsActive     bool
 	IsHit        bool
 	HitAccuracy  HitAccuracy
+	HitOffset    float64 // Signed timing of the hit in seconds, negative is early
 	
 	// Sustained note tracking
 	IsPressed       bool    // Whether the key is currently pressed for this note
 	PressStartTime  float64 // When the key was first pressed for this note
 	IsBeingHeld     bool    // Whether the note is being held correctly
 	SustainProgress float64 // How much of the sustain has been completed (0.0 to 1.0)
+	
+	// Star Power phrase this note belongs to, -1 if none
+	StarPhrase int
+	
+	// Song section this note is in, -1 if none
+	Section int
+	
+	// How the note has to be played
+	Type NoteType
+}
+
+// GameResult summarizes the outcome of a run
+type GameResult struct {
+	Score       int32 `json:"score"`
+	MaxCombo    int32 `json:"max_combo"`
+	PerfectHits int32 `json:"perfect_hits"`
+	GoodHits    int32 `json:"good_hits"`
+	OKHits      int32 `json:"ok_hits"`
+	MissedHits  int32 `json:"missed_hits"`
+	TotalNotes  int32 `json:"total_notes"`
+	MaxScore    int32 `json:"max_score"`
+	Failed      bool  `json:"failed,omitempty"`
+	
+	GhostPresses     int32 `json:"ghost_presses"`
+	WrongLanePresses int32 `json:"wrong_lane_presses"`
+}
+
+// Accuracy returns the percentage of notes that were hit
+func (r GameResult) Accuracy() float64 {
+	if r.TotalNotes == 0 {
+		return 0
+	}
+	return float64(r.PerfectHits+r.GoodHits+r.OKHits) / float64(r.TotalNotes) * 100
 }
 
 // Lane represents one of the three game lanes
 type Lane struct {
-	X         float32
-	Width     float32
 	IsPressed bool
 	KeyCode   int32
 }
@@ -78,16 +183,25 @@ const (
 	Perfect
 )
 
+// String returns the display name of a hit accuracy
+func (a HitAccuracy) String() string {
+	switch a {
+	case Perfect:
+		return "Perfect"
+	case Good:
+		return "Good"
+	case OK:
+		return "OK"
+	default:
+		return "Miss"
+	}
+}
+
 // Game constants
 const (
-	SCREEN_WIDTH     = 800
-	SCREEN_HEIGHT    = 600
-	LANE_WIDTH       = 200
-	NOTE_HEIGHT      = 40
-	HIT_LINE_Y       = 500
-	NOTE_SPEED       = 200   // pixels per second
 	GAME_DURATION    = 30.0  // Game duration in seconds
 	COUNTDOWN_TIME   = 3.0   // Countdown before game starts
+	NOTE_LINGER_TIME = 0.75  // Seconds a note stays active after its start time
 )
 
 // NewGame creates a new game instance
@@ -100,15 +214,54 @@ func NewGame() *Game {
 		// Continue without audio
 	}
 	
+	game := newGame(audioManager, realClock{})
+	game.recordReplays = true
+	game.playerName = currentPlayerName()
+	
+	scores, err := LoadUserScoreDatabase()
+	if err != nil {
+		fmt.Printf("Warning: Failed to load scores: %v\n", err)
+	} else {
+		game.scores = scores
+	}
+	keyboard := NewKeyboardInput([]int32{
+		game.lanes[0].KeyCode,
+		game.lanes[1].KeyCode,
+		game.lanes[2].KeyCode,
+	}, rl.KeySpace, []int32{rl.KeyUp, rl.KeyDown}, rl.KeyLeftShift)
+	
+	// Keyboard and gamepad can be used at the same time
+	profiles, err := LoadUserProfileStore()
+	if err != nil {
+		fmt.Printf("Warning: Failed to load controller profiles: %v\n", err)
+		profiles = &ProfileStore{Profiles: make(map[string]ControllerProfile)}
+	}
+	game.gamepad = NewGamepadInput(GAMEPAD_INDEX, profiles)
+	game.mapper = NewControllerMapper(game.gamepad)
+	game.playerInput = NewCompositeInput(keyboard, game.gamepad)
+	
+	return game
+}
+
+// NewHeadlessGame creates a game without audio or a window, driven by the given input and clock
+func NewHeadlessGame(input InputSource, clock Clock) *Game {
+	game := newGame(nil, clock)
+	game.playerInput = input
+	return game
+}
+
+// newGame sets up the game state shared by windowed and headless games
+func newGame(audioManager *AudioManager, clock Clock) *Game {
 	game := &Game{
-		screenWidth:   SCREEN_WIDTH,
-		screenHeight:  SCREEN_HEIGHT,
-		audioManager:  audioManager,
-		hitLine:      HIT_LINE_Y,
+		audioManager: audioManager,
+		clock:        clock,
 		score:        0,
 		combo:        0,
 		maxCombo:     0,
 		state:        StateMenu,
+		difficulty:   DifficultyMedium,
+		judgement:    StandardJudgement(),
+		laneMap:      [3]int{0, 1, 2},
 		songDuration: 0,
 		perfectHits:  0,
 		goodHits:     0,
@@ -118,9 +271,9 @@ func NewGame() *Game {
 	}
 	
 	// Initialize lanes
-	game.lanes[0] = Lane{X: 100, Width: LANE_WIDTH, KeyCode: rl.KeyA}  // A key
-	game.lanes[1] = Lane{X: 300, Width: LANE_WIDTH, KeyCode: rl.KeyW}  // W key  
-	game.lanes[2] = Lane{X: 500, Width: LANE_WIDTH, KeyCode: rl.KeyD}  // D key
+	game.lanes[0] = Lane{KeyCode: rl.KeyA} // A key
+	game.lanes[1] = Lane{KeyCode: rl.KeyW} // W key
+	game.lanes[2] = Lane{KeyCode: rl.KeyD} // D key
 	
 	return game
 }
@@ -128,6 +281,8 @@ func NewGame() *Game {
 // LoadMIDITrack loads notes from the MIDI processor
 func (g *Game) LoadMIDITrack(midiProcessor *MIDIProcessor) error {
 	g.midiProcessor = midiProcessor
+	g.songHash = midiProcessor.SongHash()
+	g.laneMap = [3]int{0, 1, 2}
 	
 	// Find guitar track
 	guitarTrack, err := midiProcessor.FindGuitarTrack()
@@ -169,8 +324,6 @@ func (g *Game) LoadMIDITrack(midiProcessor *MIDIProcessor) error {
 			StartTime: adjustedStartTime,
 			Duration:  adjustedDuration,
 			Lane:      midiNote.Lane,
-			Width:     LANE_WIDTH - 20, // Leave some margin
-			Height:    NOTE_HEIGHT,
 			IsActive:  true,
 			IsHit:     false,
 		}
@@ -183,8 +336,34 @@ func (g *Game) LoadMIDITrack(midiProcessor *MIDIProcessor) error {
 		g.gameNotes = append(g.gameNotes, gameNote)
 	}
 	
+	// Chart phrases use the same time offset as the notes
+	offsetPhrases := func(phrases []Phrase) []Phrase {
+		offset := make([]Phrase, 0, len(phrases))
+		for _, phrase := range phrases {
+			offset = append(offset, Phrase{
+				StartTime: phrase.StartTime - earliestNoteTime + 2.0,
+				EndTime:   phrase.EndTime - earliestNoteTime + 2.0,
+			})
+		}
+		return offset
+	}
+	g.assignStarPhrases(offsetPhrases(guitarTrack.StarPowerPhrases))
+	assignNoteTypes(g.gameNotes, offsetPhrases(guitarTrack.ForcedHOPOs),
+		offsetPhrases(guitarTrack.ForcedStrums), offsetPhrases(guitarTrack.TapPhrases))
+	
 	g.songDuration = GAME_DURATION // Set to exactly 30 seconds
+	
+	// Beats use the same time offset as the notes
+	beats := midiProcessor.BeatMap()
+	if len(beats) == 0 {
+		beats = DefaultBeatMap(g.songDuration + earliestNoteTime)
+	}
+	g.beats = offsetBeats(beats, 2.0-earliestNoteTime, g.songDuration)
+	g.sections = offsetSections(guitarTrack.Sections, 2.0-earliestNoteTime, g.songDuration)
+	g.assignSections()
+	g.resetPractice()
 	g.totalNotes = int32(len(g.gameNotes))
+	g.maxScore = g.maxPossibleScore()
 	
 	fmt.Printf("Loaded %d game notes from guitar track, song duration: %.1fs\n", 
 		len(g.gameNotes), g.songDuration)
@@ -219,7 +398,7 @@ func (g *Game) LoadMIDITrack(midiProcessor *MIDIProcessor) error {
 // StartGame starts the game
 func (g *Game) StartGame() {
 	g.state = StatePlaying
-	g.gameStartTime = time.Now()
+	g.gameStartTime = g.clock.Now()
 	g.currentTime = 0
 	g.score = 0
 	g.combo = 0
@@ -228,16 +407,51 @@ func (g *Game) StartGame() {
 	g.goodHits = 0
 	g.okHits = 0
 	g.missedHits = 0
+	g.recordedEvents = make([]InputEvent, 0)
+	g.lastReplayPath = ""
+	g.lastScoreRank = 0
+	g.hitOffsets = make([]float64, 0)
+	g.sectionStats = make([]SectionStats, len(g.sections))
+	g.ghostPresses = 0
+	g.wrongLanePresses = 0
+	g.fretsDown = [3]bool{}
+	g.lastFretHit = -1
+	g.whammy = 0
+	g.whammyChargedTo = 0
+	g.resetStarPower()
+	g.rockMeter = ROCK_METER_START
+	g.failTime = 0
+	g.events.Publish(GameEvent{Type: EventRunStarted})
 	
 	// Reset all notes
 	for i := range g.gameNotes {
 		g.gameNotes[i].IsActive = true
 		g.gameNotes[i].IsHit = false
+		g.gameNotes[i].IsPressed = false
+		g.gameNotes[i].IsBeingHeld = false
+		g.gameNotes[i].SustainProgress = 0
+	}
+	
+	// Shuffled runs get a new lane order, replays and practice loops keep theirs
+	if g.modifiers.Shuffle && g.replay == nil && !(g.practicing && g.practiceLoops > 0) {
+		g.modifiers.ShuffleSeed = g.clock.Now().UnixNano()
 	}
+	g.applyLaneMap()
+	
+	// Practice plays only part of the song, maybe slowed down
+	g.totalNotes = int32(len(g.gameNotes))
+	g.timeOffset = 0
+	g.playbackSpeed = 1
+	if g.practicing {
+		g.windowPractice()
+		g.whammyChargedTo = g.currentTime
+	}
+	
+	g.selectInput()
 	
 	// Start audio playback
 	if g.audioManager != nil {
-		err := g.audioManager.StartPlayback()
+		err := g.audioManager.StartPlaybackAt(g.timeOffset, g.playbackSpeed)
 		if err != nil {
 			fmt.Printf("Warning: Failed to start audio playback: %v\n", err)
 		}
@@ -246,6 +460,79 @@ func (g *Game) StartGame() {
 	fmt.Println("Game started!")
 }
 
+// selectInput picks the input source for a new run
+func (g *Game) selectInput() {
+	switch {
+	case g.replay != nil:
+		g.input = NewScriptedInput(g.replay.Events)
+	case g.bot != nil:
+		g.bot.Judgement = g.judgement
+		
+		// Only play the notes in the run, so practice loops can restart the script
+		notes := make([]GameNote, 0, len(g.gameNotes))
+		for _, note := range g.gameNotes {
+			if note.IsActive {
+				notes = append(notes, note)
+			}
+		}
+		events := g.bot.GenerateEvents(notes)
+		if g.strumMode {
+			events = StrumEvents(events)
+		}
+		g.input = NewScriptedInput(events)
+	default:
+		// Rewind scripted input so headless runs can be played again
+		if resettable, ok := g.playerInput.(interface{ Reset() }); ok {
+			resettable.Reset()
+		}
+		g.input = g.playerInput
+	}
+}
+
+// SetReplay switches the game to play back a recorded replay
+func (g *Game) SetReplay(replay *Replay) {
+	g.replay = replay
+	g.practicing = false
+	g.difficulty = replay.Settings.Difficulty
+	g.noFail = replay.Settings.NoFail
+	g.strumMode = replay.Settings.StrumMode
+	g.SetJudgement(replay.Settings.Judgement)
+	g.modifiers = replay.Settings.Modifiers
+}
+
+// SetAutoplay lets the bot play the next runs, or gives control back to the player when nil
+func (g *Game) SetAutoplay(bot *Bot) {
+	g.bot = bot
+}
+
+// IsAutoplay returns whether the bot is playing
+func (g *Game) IsAutoplay() bool {
+	return g.bot != nil && g.replay == nil
+}
+
+// IsReplay returns whether the game is playing back a replay
+func (g *Game) IsReplay() bool {
+	return g.replay != nil
+}
+
+// Result returns a summary of the current run
+func (g *Game) Result() GameResult {
+	return GameResult{
+		Score:       g.score,
+		MaxCombo:    g.maxCombo,
+		PerfectHits: g.perfectHits,
+		GoodHits:    g.goodHits,
+		OKHits:      g.okHits,
+		MissedHits:  g.missedHits,
+		TotalNotes:  g.totalNotes,
+		MaxScore:    g.maxScore,
+		Failed:      g.IsFailed(),
+		
+		GhostPresses:     g.ghostPresses,
+		WrongLanePresses: g.wrongLanePresses,
+	}
+}
+
 // IsPlaying returns whether the game is currently playing
 func (g *Game) IsPlaying() bool {
 	return g.state == StatePlaying
@@ -266,42 +553,153 @@ func (g *Game) EndGame() {
 	}
 	
 	fmt.Printf("Game ended! Final score: %d, Max combo: %d\n", g.score, g.maxCombo)
+	
+	// Record live runs
+	if g.recordReplays && !g.IsReplay() && !g.IsAutoplay() {
+		g.saveReplay()
+		g.saveScore()
+	}
+}
+
+// saveReplay saves a replay of the run if it was played live
+func (g *Game) saveReplay() {
+	if !g.recordReplays || g.IsReplay() || g.IsAutoplay() {
+		return
+	}
+	
+	path, err := SaveReplayToDataDir(g.BuildReplay())
+	if err != nil {
+		fmt.Printf("Warning: Failed to save replay: %v\n", err)
+		return
+	}
+	g.lastReplayPath = path
+	fmt.Printf("Replay saved to %s\n", path)
+}
+
+// saveScore records the finished run in the score database
+func (g *Game) saveScore() {
+	if g.scores == nil {
+		return
+	}
+	
+	entry := ScoreEntry{
+		Player:     g.playerName,
+		Result:     g.Result(),
+		Date:       time.Now(),
+		ReplayPath: g.lastReplayPath,
+		Judgement:  g.judgement,
+		Modifiers:  g.modifiers,
+	}
+	g.lastScoreRank = g.scores.AddScore(g.songHash, g.difficulty, entry)
+	
+	if err := g.scores.Save(); err != nil {
+		fmt.Printf("Warning: Failed to save scores: %v\n", err)
+	}
+}
+
+// SetSongs sets the songs available on the song select screen
+func (g *Game) SetSongs(songs []SongEntry, selectedPath string) {
+	g.songs = songs
+	g.selectedSong = 0
+	for i, song := range songs {
+		if filepath.Clean(song.Path) == filepath.Clean(selectedPath) {
+			g.selectedSong = i
+		}
+	}
+}
+
+// SelectedSong returns the highlighted song on the song select screen
+func (g *Game) SelectedSong() (SongEntry, bool) {
+	if g.selectedSong < 0 || g.selectedSong >= len(g.songs) {
+		return SongEntry{}, false
+	}
+	return g.songs[g.selectedSong], true
+}
+
+// MoveSongSelection moves the song select highlight, wrapping around the list
+func (g *Game) MoveSongSelection(step int) {
+	if len(g.songs) == 0 {
+		return
+	}
+	g.selectedSong = (g.selectedSong + step + len(g.songs)) % len(g.songs)
+}
+
+// CycleDifficulty changes the selected difficulty
+func (g *Game) CycleDifficulty(step int) {
+	g.difficulty = g.difficulty.Next(step)
+}
+
+// LoadSelectedSong loads the highlighted song if it is not already loaded
+func (g *Game) LoadSelectedSong() error {
+	song, ok := g.SelectedSong()
+	if !ok || song.Hash == g.songHash {
+		return nil
+	}
+	
+	midiProcessor := NewMIDIProcessor()
+	if err := midiProcessor.LoadMIDI(song.Path); err != nil {
+		return err
+	}
+	return g.LoadMIDITrack(midiProcessor)
 }
 
 // Update updates the game state
 func (g *Game) Update(deltaTime float32) {
+	// The editor plays back on its own clock
+	if g.state == StateEditor && g.editor != nil {
+		g.editor.Update()
+		return
+	}
 	if !g.IsPlaying() {
 		return
 	}
 	
 	// Update current time
-	g.currentTime = time.Since(g.gameStartTime).Seconds()
+	g.currentTime = g.timeOffset + g.clock.Now().Sub(g.gameStartTime).Seconds()*g.playbackSpeed
 	
 	// Update audio manager
 	if g.audioManager != nil {
 		g.audioManager.Update()
 	}
 	
+	// Practice loops instead of finishing
+	if g.practicing && g.currentTime > g.practice.End+PRACTICE_LEAD_OUT {
+		g.restartPracticeLoop()
+		return
+	}
+	
 	// Check if song is finished
-	if g.currentTime > g.songDuration {
+	if !g.practicing && g.currentTime > g.songDuration {
 		g.EndGame()
 		return
 	}
 	
 	// Update input
 	g.updateInput()
+	if !g.IsPlaying() {
+		return // Failed on a misjudged input
+	}
+	
+	// Whammy charges Star Power up to the end of held sustains, so do it before they complete
+	g.chargeWhammy(g.currentTime)
 	
 	// Update notes
 	g.updateNotes(deltaTime)
 	
 	// Update sustained notes
 	g.updateSustainedNotes()
+	g.updatePitchBend()
 	
 	// Check for missed notes
 	g.checkMissedNotes()
+	if !g.IsPlaying() {
+		return
+	}
 	
 	// Check if all notes are processed
-	g.checkAllNotesProcessed()
+	if !g.practicing {
+		g.checkAllNotesProcessed()
+	}
 }
 
 // checkAllNotesProcessed checks if all notes have been hit or missed
@@ -313,25 +711,62 @@ func (g *Game) checkAllNotesProcessed() {
 	}
 }
 
-// updateInput handles keyboard input
+// updateInput handles input from the game's input source
 func (g *Game) updateInput() {
+	if g.input == nil {
+		return
+	}
+	
+	frameTime := g.currentTime
+	events := g.input.Poll(frameTime)
+	
 	for i := range g.lanes {
-		lane := &g.lanes[i]
-		lane.IsPressed = rl.IsKeyDown(lane.KeyCode)
-		
-		// Check for key press events
-		if rl.IsKeyPressed(lane.KeyCode) {
-			g.handleKeyPress(i)
+		g.lanes[i].IsPressed = g.input.IsLaneDown(i)
+	}
+	
+	for _, event := range events {
+		// Events after a failure in the same frame are dropped
+		if !g.IsPlaying() {
+			break
+		}
+		if event.Action == ActionLane && (event.Lane < 0 || event.Lane >= len(g.lanes)) {
+			continue
 		}
 		
-		// Check for key release events
-		if rl.IsKeyReleased(lane.KeyCode) {
-			g.handleKeyRelease(i)
+		// Judge each event at the time it happened, not at the frame time
+		g.currentTime = event.Time
+		g.chargeWhammy(event.Time)
+		g.recordedEvents = append(g.recordedEvents, event)
+		if event.Action == ActionLane {
+			g.fretsDown[event.Lane] = event.Pressed
+		}
+		switch {
+		case event.Action == ActionStarPower:
+			if event.Pressed {
+				g.activateStarPower()
+			}
+		case event.Action == ActionStrum:
+			if event.Pressed && g.strumMode {
+				g.handleStrum()
+			}
+		case event.Action == ActionWhammy:
+			g.whammy = quantizeWhammy(event.Value)
+		case event.Pressed && g.strumMode:
+			g.handleFretPress(event.Lane)
+		case event.Pressed:
+			g.handleKeyPress(event.Lane)
+		case g.strumMode:
+			g.handleKeyRelease(event.Lane)
+			g.handleFretRelease(event.Lane)
+		default:
+			g.handleKeyRelease(event.Lane)
 		}
 	}
+	g.currentTime = frameTime
 }
 
-// updateNotes updates the position of all notes
+// updateNotes retires notes once they are well past the hit line. Where notes
+// are drawn is up to the renderer.
 func (g *Game) updateNotes(deltaTime float32) {
 	for i := range g.gameNotes {
 		note := &g.gameNotes[i]
@@ -339,12 +774,8 @@ func (g *Game) updateNotes(deltaTime float32) {
 			continue
 		}
 		
-		// Calculate note position based on timing
-		timeUntilHit := note.StartTime - g.currentTime
-		note.Y = g.hitLine - float32(timeUntilHit*NOTE_SPEED)
-		
-		// Remove notes that are off screen
-		if note.Y > float32(g.screenHeight)+50 {
+		// Keep sustains that are still being held
+		if g.currentTime-note.StartTime > NOTE_LINGER_TIME && !note.IsPressed {
 			note.IsActive = false
 		}
 	}
@@ -363,15 +794,7 @@ func (g *Game) updateSustainedNotes() {
 		
 		if lanePressed {
 			// Update sustain progress based on how far through the note we are
-			noteElapsed := g.currentTime - note.StartTime
-			note.SustainProgress = noteElapsed / note.Duration
-			
-			// Clamp to valid range
-			if note.SustainProgress < 0 {
-				note.SustainProgress = 0
-			} else if note.SustainProgress > 1.0 {
-				note.SustainProgress = 1.0
-			}
+			g.updateSustainProgress(note)
 			
 			// Keep the note marked as being held correctly
 			note.IsBeingHeld = true
@@ -388,13 +811,10 @@ func (g *Game) updateSustainedNotes() {
 				
 				// Award score based on how well it was held
 				accuracy := note.HitAccuracy
-				g.addScore(accuracy)
+				g.addScore(note, accuracy)
 				
 				// Bonus for sustained notes
-				if note.SustainProgress > 0.8 {
-					bonusPoints := int32(50 * note.SustainProgress)
-					g.score += bonusPoints
-				}
+				g.score += sustainBonus(note.SustainProgress) * g.ScoreMultiplier()
 				
 				// Auto-completed sustained note
 			}
@@ -404,30 +824,75 @@ func (g *Game) updateSustainedNotes() {
 			note.IsBeingHeld = false
 			note.IsHit = true
 			note.HitAccuracy = Miss
-			g.addScore(Miss)
+			g.addScore(note, Miss)
+			g.events.Publish(GameEvent{Type: EventNoteMissed, Time: g.currentTime, Lane: note.Lane, Accuracy: Miss})
 			// Sustained note released too early
 		}
 	}
 }
 
-// isSustainedNote checks if a note is a sustained note (duration > 0.3 seconds)
+// updateSustainProgress sets how much of a held note has been sustained at the current time
+func (g *Game) updateSustainProgress(note *GameNote) {
+	noteElapsed := g.currentTime - note.StartTime
+	note.SustainProgress = noteElapsed / note.Duration
+	
+	// Clamp to valid range
+	if note.SustainProgress < 0 {
+		note.SustainProgress = 0
+	} else if note.SustainProgress > 1.0 {
+		note.SustainProgress = 1.0
+	}
+}
+
+// sustainBonus returns the bonus points for holding a sustain, which need at least 80% of the duration
+func sustainBonus(progress float64) int32 {
+	if progress <= 0.8 {
+		return 0
+	}
+	// Round so float error at the very end of a note doesn't cost a point
+	return int32(math.Round(50 * progress))
+}
+
+// isSustainedNote checks if a note is long enough to be held under the judgement profile
 func (g *Game) isSustainedNote(note *GameNote) bool {
-	return note.Duration > 0.3
+	return g.judgement.IsSustain(note.Duration)
 }
 
-// handleKeyPress handles when a key is pressed
+// handleKeyPress handles when a key is pressed, which frets and strums the lane
 func (g *Game) handleKeyPress(laneIndex int) {
+	g.judgePress(laneIndex, true)
+}
+
+// judgePress judges a press in a lane and returns whether it hit a note. Presses
+// without a strum can only hit HOPOs and taps and are never penalized.
+func (g *Game) judgePress(laneIndex int, strummed bool) bool {
 	// Find the closest note in this lane
 	closestNote := g.findClosestNote(laneIndex)
 	if closestNote == nil {
-		return
+		if strummed {
+			g.penalizePress(laneIndex)
+		}
+		return false
 	}
 	
 	// Calculate hit accuracy for the start of the note
 	timeDiff := g.currentTime - closestNote.StartTime
 	accuracy := g.calculateAccuracy(timeDiff)
+	if !strummed && (accuracy == Miss || !g.canHitWithoutStrum(closestNote)) {
+		return false
+	}
 	
 	if accuracy != Miss {
+		g.hitOffsets = append(g.hitOffsets, timeDiff)
+		closestNote.HitOffset = timeDiff
+		g.events.Publish(GameEvent{
+			Type:     EventNoteHit,
+			Time:     g.currentTime,
+			Lane:     laneIndex,
+			Accuracy: accuracy,
+			Offset:   timeDiff,
+		})
+		
 		if g.isSustainedNote(closestNote) {
 			// For sustained notes, mark as pressed and start tracking
 			closestNote.IsPressed = true
@@ -439,10 +904,50 @@ func (g *Game) handleKeyPress(laneIndex int) {
 			// For short notes, score immediately
 			closestNote.IsHit = true
 			closestNote.HitAccuracy = accuracy
-			g.addScore(accuracy)
+			g.addScore(closestNote, accuracy)
 			fmt.Printf("Hit! Lane: %d, Accuracy: %v, Score: %d\n", laneIndex, accuracy, g.score)
 		}
+		return true
+	}
+	
+	// Too early for the next note in this lane
+	g.penalizePress(laneIndex)
+	return false
+}
+
+// penalizePress counts a press that hit no note, breaking the combo and draining
+// the rock meter on difficulties that penalize them
+func (g *Game) penalizePress(laneIndex int) {
+	settings := g.difficulty.Settings()
+	if !settings.PenalizeGhostPresses {
+		return
+	}
+	
+	if g.noteInWindowOutsideLane(laneIndex) {
+		g.wrongLanePresses++
+		fmt.Printf("Wrong lane! Lane: %d\n", laneIndex)
+	} else {
+		g.ghostPresses++
+		fmt.Printf("Ghost press! Lane: %d\n", laneIndex)
+	}
+	
+	g.combo = 0
+	g.drainRockMeter(settings.GhostPressDrain)
+	g.events.Publish(GameEvent{Type: EventGhostPress, Time: g.currentTime, Lane: laneIndex, Accuracy: Miss})
+}
+
+// noteInWindowOutsideLane returns whether a note in another lane could be hit right now
+func (g *Game) noteInWindowOutsideLane(laneIndex int) bool {
+	for i := range g.gameNotes {
+		note := &g.gameNotes[i]
+		if !note.IsActive || note.IsHit || note.IsPressed || note.Lane == laneIndex {
+			continue
+		}
+		if g.calculateAccuracy(g.currentTime-note.StartTime) != Miss {
+			return true
+		}
 	}
+	return false
 }
 
 // handleKeyRelease handles when a key is released
@@ -456,8 +961,10 @@ func (g *Game) handleKeyRelease(laneIndex int) {
 		
 		// Check if this is a proper release at the end of a sustained note
 		noteEndTime := note.StartTime + note.Duration
-		releaseTimeDiff := g.currentTime - noteEndTime
-		releaseAccuracy := g.calculateAccuracy(releaseTimeDiff)
+		releasedEarly := noteEndTime-g.currentTime > g.judgement.ReleaseWindow
+		
+		// Measure progress at the moment of release rather than the last frame
+		g.updateSustainProgress(note)
 		
 		// Complete the sustained note
 		note.IsPressed = false
@@ -466,7 +973,7 @@ func (g *Game) handleKeyRelease(laneIndex int) {
 		
 		// Calculate final score based on start accuracy and release accuracy
 		finalAccuracy := note.HitAccuracy // Start with the initial hit accuracy
-		if releaseAccuracy == Miss {
+		if releasedEarly {
 			// Poor release timing, downgrade the score
 			if finalAccuracy == Perfect {
 				finalAccuracy = Good
@@ -476,13 +983,10 @@ func (g *Game) handleKeyRelease(laneIndex int) {
 		}
 		
 		// Award points for completing the sustained note
-		g.addScore(finalAccuracy)
+		g.addScore(note, finalAccuracy)
 		
 		// Bonus points for sustained notes held correctly
-		if note.SustainProgress > 0.8 { // If held for at least 80% of duration
-			bonusPoints := int32(50 * note.SustainProgress)
-			g.score += bonusPoints
-		}
+		g.score += sustainBonus(note.SustainProgress) * g.ScoreMultiplier()
 		
 		// Sustained note completed
 		break // Only handle one note per release
@@ -502,7 +1006,7 @@ func (g *Game) findClosestNote(laneIndex int) *GameNote {
 		}
 		
 		distance := note.StartTime - g.currentTime
-		if distance < minDistance && distance > -0.2 { // Allow 200ms window after note
+		if distance < minDistance && distance >= -g.judgement.OKLate { // Allow the late window after note
 			minDistance = distance
 			closestNote = note
 		}
@@ -511,37 +1015,30 @@ func (g *Game) findClosestNote(laneIndex int) *GameNote {
 	return closestNote
 }
 
-// calculateAccuracy calculates hit accuracy based on timing difference
+// calculateAccuracy calculates hit accuracy based on timing difference, negative for early hits
 func (g *Game) calculateAccuracy(timeDiff float64) HitAccuracy {
-	absTimeDiff := timeDiff
-	if absTimeDiff < 0 {
-		absTimeDiff = -absTimeDiff
-	}
-	
-	if absTimeDiff <= 0.05 { // 50ms
-		return Perfect
-	} else if absTimeDiff <= 0.1 { // 100ms
-		return Good
-	} else if absTimeDiff <= 0.15 { // 150ms
-		return OK
-	} else {
-		return Miss
-	}
+	return g.judgement.Judge(timeDiff)
 }
 
-// addScore adds score based on hit accuracy
-func (g *Game) addScore(accuracy HitAccuracy) {
+// addScore adds score for a judged note based on hit accuracy
+func (g *Game) addScore(note *GameNote, accuracy HitAccuracy) {
+	// Nothing is judged once the song has failed
+	if !g.IsPlaying() {
+		return
+	}
+	
+	points := int32(0)
 	switch accuracy {
 	case Perfect:
-		g.score += 100
+		points = 100
 		g.combo++
 		g.perfectHits++
 	case Good:
-		g.score += 75
+		points = 75
 		g.combo++
 		g.goodHits++
 	case OK:
-		g.score += 50
+		points = 50
 		g.combo++
 		g.okHits++
 	case Miss:
@@ -553,27 +1050,65 @@ func (g *Game) addScore(accuracy HitAccuracy) {
 	if g.combo > g.maxCombo {
 		g.maxCombo = g.combo
 	}
+	if accuracy != Miss && g.combo%COMBO_MILESTONE == 0 {
+		g.events.Publish(GameEvent{Type: EventComboMilestone, Time: g.currentTime, Lane: note.Lane, Combo: g.combo})
+	}
+	
+	// Multiplier steps up with the combo and drops back to 1x on a miss
+	g.score += points * g.ScoreMultiplier()
 	
-	// Combo bonus
-	if g.combo > 10 {
-		g.score += int32(g.combo / 10)
+	g.updateStarPhrase(note, accuracy)
+	g.updateRockMeter(accuracy)
+	g.recordSectionJudgement(note, accuracy)
+}
+
+// maxPossibleScore returns the score for hitting every note perfectly and holding
+// every sustain, without Star Power
+func (g *Game) maxPossibleScore() int32 {
+	// Short notes are scored when hit, sustains when they end
+	judgeTimes := make([]float64, len(g.gameNotes))
+	order := make([]int, len(g.gameNotes))
+	for i := range g.gameNotes {
+		order[i] = i
+		judgeTimes[i] = g.gameNotes[i].StartTime
+		if g.isSustainedNote(&g.gameNotes[i]) {
+			judgeTimes[i] += g.gameNotes[i].Duration
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return judgeTimes[order[a]] < judgeTimes[order[b]]
+	})
+	
+	maxScore := int32(0)
+	for combo, i := range order {
+		multiplier := comboMultiplier(int32(combo + 1))
+		maxScore += 100 * multiplier
+		
+		// Full sustain bonus
+		if g.isSustainedNote(&g.gameNotes[i]) {
+			maxScore += sustainBonus(1.0) * multiplier
+		}
 	}
+	return maxScore
 }
 
 // checkMissedNotes checks for notes that were missed
 func (g *Game) checkMissedNotes() {
 	for i := range g.gameNotes {
 		note := &g.gameNotes[i]
-		if !note.IsActive || note.IsHit {
+		// Sustained notes being held are judged by updateSustainedNotes
+		if !note.IsActive || note.IsHit || note.IsPressed {
 			continue
 		}
 		
 		// If note is too far past the hit line, mark as missed
-		if g.currentTime > note.StartTime+0.2 { // 200ms grace period
+		if g.currentTime > note.StartTime+g.judgement.OKLate { // Past the late window
 			note.IsHit = true
 			note.HitAccuracy = Miss
-			g.addScore(Miss)
-			fmt.Printf("Missed note in lane %d\n", note.Lane)
+			g.addScore(note, Miss)
+			g.events.Publish(GameEvent{Type: EventNoteMissed, Time: g.currentTime, Lane: note.Lane, Accuracy: Miss})
+			fmt.Printf("Missed note in lane %d (ghost presses: %d, wrong lane: %d)\n",
+				note.Lane, g.ghostPresses, g.wrongLanePresses)
 		}
 	}
 }
\ No newline at end of file
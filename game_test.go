@@ -0,0 +1,248 @@
+package main
+
+import (
+	"testing"
+)
+
+// Notes are offset so the earliest one starts at 2.0s of game time
+const firstNoteTime = 2.0
+
+// newHeadless loads the notes into a headless game that will play the scripted events.
+// Setup functions run before the chart is loaded, to add phrases or change settings.
+func newHeadless(t *testing.T, notes []MIDINote, events []InputEvent, setup ...func(*MIDIProcessor, *Game)) (*Game, *ManualClock) {
+	t.Helper()
+	
+	midiProcessor := NewMIDIProcessorFromNotes(notes)
+	clock := NewManualClock()
+	game := NewHeadlessGame(NewScriptedInput(events), clock)
+	for _, apply := range setup {
+		apply(midiProcessor, game)
+	}
+	if err := game.LoadMIDITrack(midiProcessor); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	return game, clock
+}
+	
+// runHeadless loads the notes into a headless game and plays the scripted events
+func runHeadless(t *testing.T, notes []MIDINote, events []InputEvent, setup ...func(*MIDIProcessor, *Game)) *Game {
+	t.Helper()
+	
+	game, clock := newHeadless(t, notes, events, setup...)
+	Simulate(game, clock, HEADLESS_FPS)
+	return game
+}
+
+// laneNote creates a MIDI note whose pitch maps to the given lane
+func laneNote(lane int, start float64, duration float64) MIDINote {
+	pitches := []int{50, 65, 80}
+	return MIDINote{Pitch: pitches[lane], Velocity: 100, StartTime: start, Duration: duration}
+}
+
+func TestPerfectHits(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.5, 0.1),
+		laneNote(2, 1.0, 0.1),
+	}
+	events := make([]InputEvent, 0)
+	events = append(events, Press(0, firstNoteTime, 0.05)...)
+	events = append(events, Press(1, firstNoteTime+0.5, 0.05)...)
+	events = append(events, Press(2, firstNoteTime+1.0, 0.05)...)
+	
+	game := runHeadless(t, notes, events)
+	
+	if game.perfectHits != 3 {
+		t.Errorf("perfectHits = %d, want 3", game.perfectHits)
+	}
+	if game.score != 300 {
+		t.Errorf("score = %d, want 300", game.score)
+	}
+	if game.maxCombo != 3 {
+		t.Errorf("maxCombo = %d, want 3", game.maxCombo)
+	}
+	if !game.IsGameOver() {
+		t.Errorf("game should end once every note is processed")
+	}
+}
+
+func TestAccuracyWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  float64
+		want    HitAccuracy
+		score   int32
+		counter func(g *Game) int32
+	}{
+		{"perfect early", -0.04, Perfect, 100, func(g *Game) int32 { return g.perfectHits }},
+		{"good late", 0.07, Good, 75, func(g *Game) int32 { return g.goodHits }},
+		{"ok early", -0.12, OK, 50, func(g *Game) int32 { return g.okHits }},
+		{"too late", 0.18, Miss, 0, func(g *Game) int32 { return g.missedHits }},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := []MIDINote{laneNote(1, 0.0, 0.1)}
+			game := runHeadless(t, notes, Press(1, firstNoteTime+tt.offset, 0.05))
+			
+			if game.gameNotes[0].HitAccuracy != tt.want {
+				t.Errorf("accuracy = %v, want %v", game.gameNotes[0].HitAccuracy, tt.want)
+			}
+			if game.score != tt.score {
+				t.Errorf("score = %d, want %d", game.score, tt.score)
+			}
+			if got := tt.counter(game); got != 1 {
+				t.Errorf("counter = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestMissedNotes(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(2, 0.5, 0.1),
+	}
+	
+	game := runHeadless(t, notes, nil)
+	
+	if game.missedHits != 2 {
+		t.Errorf("missedHits = %d, want 2", game.missedHits)
+	}
+	if game.score != 0 || game.combo != 0 {
+		t.Errorf("score = %d, combo = %d, want 0 and 0", game.score, game.combo)
+	}
+	for i, note := range game.gameNotes {
+		if !note.IsHit || note.HitAccuracy != Miss {
+			t.Errorf("note %d should be marked as missed", i)
+		}
+	}
+}
+
+func TestWrongLaneDoesNotHit(t *testing.T) {
+	notes := []MIDINote{laneNote(0, 0.0, 0.1)}
+	
+	game := runHeadless(t, notes, Press(2, firstNoteTime, 0.05))
+	
+	if game.missedHits != 1 || game.perfectHits != 0 {
+		t.Errorf("missedHits = %d, perfectHits = %d, want 1 and 0", game.missedHits, game.perfectHits)
+	}
+	if game.wrongLanePresses != 1 || game.ghostPresses != 0 {
+		t.Errorf("wrongLanePresses = %d, ghostPresses = %d, want 1 and 0", game.wrongLanePresses, game.ghostPresses)
+	}
+}
+
+func TestGhostPressPenalties(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.5, 0.1),
+		laneNote(2, 1.0, 0.1),
+	}
+	events := make([]InputEvent, 0)
+	events = append(events, Press(0, firstNoteTime, 0.05)...)
+	events = append(events, Press(2, firstNoteTime+0.25, 0.05)...) // Nothing to hit
+	events = append(events, Press(1, firstNoteTime+0.5, 0.05)...)
+	events = append(events, Press(2, firstNoteTime+1.0, 0.05)...)
+	
+	for _, difficulty := range []Difficulty{DifficultyEasy, DifficultyMedium} {
+		t.Run(difficulty.String(), func(t *testing.T) {
+			clock := NewManualClock()
+			game := NewHeadlessGame(NewScriptedInput(events), clock)
+			if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes(notes)); err != nil {
+				t.Fatalf("LoadMIDITrack failed: %v", err)
+			}
+			game.difficulty = difficulty
+			Simulate(game, clock, HEADLESS_FPS)
+			
+			penalized := difficulty.Settings().PenalizeGhostPresses
+			wantGhosts, wantCombo := int32(0), int32(3)
+			if penalized {
+				wantGhosts, wantCombo = 1, 2
+			}
+			if game.ghostPresses != wantGhosts {
+				t.Errorf("ghostPresses = %d, want %d", game.ghostPresses, wantGhosts)
+			}
+			if game.maxCombo != wantCombo {
+				t.Errorf("maxCombo = %d, want %d", game.maxCombo, wantCombo)
+			}
+			if fc := game.Result().IsFullCombo(); fc == penalized {
+				t.Errorf("IsFullCombo() = %v, want %v", fc, !penalized)
+			}
+		})
+	}
+}
+
+func TestComboMultiplierAndReset(t *testing.T) {
+	notes := make([]MIDINote, 0)
+	events := make([]InputEvent, 0)
+	for i := 0; i < 12; i++ {
+		start := float64(i) * 0.25
+		notes = append(notes, laneNote(i%3, start, 0.1))
+		events = append(events, Press(i%3, firstNoteTime+start, 0.05)...)
+	}
+	// A final note that is never played breaks the combo
+	notes = append(notes, laneNote(0, 4.0, 0.1))
+	
+	game := runHeadless(t, notes, events)
+	
+	// The multiplier steps up to 2x at a combo of 10
+	if game.score != 9*100+3*200 {
+		t.Errorf("score = %d, want %d", game.score, 9*100+3*200)
+	}
+	if game.maxCombo != 12 {
+		t.Errorf("maxCombo = %d, want 12", game.maxCombo)
+	}
+	if game.combo != 0 {
+		t.Errorf("combo = %d, want 0 after a miss", game.combo)
+	}
+}
+
+func TestSustainHeldToEnd(t *testing.T) {
+	notes := []MIDINote{laneNote(1, 0.0, 1.0)}
+	
+	game := runHeadless(t, notes, Press(1, firstNoteTime, 1.5))
+	
+	note := game.gameNotes[0]
+	if !note.IsHit || note.HitAccuracy != Perfect {
+		t.Fatalf("sustain should complete as perfect, got hit=%v accuracy=%v", note.IsHit, note.HitAccuracy)
+	}
+	if note.SustainProgress != 1.0 {
+		t.Errorf("SustainProgress = %.2f, want 1.0", note.SustainProgress)
+	}
+	// 100 for the hit plus the full 50 point sustain bonus
+	if game.score != 150 {
+		t.Errorf("score = %d, want 150", game.score)
+	}
+}
+
+func TestSustainReleasedEarly(t *testing.T) {
+	notes := []MIDINote{laneNote(1, 0.0, 1.0)}
+	
+	game := runHeadless(t, notes, Press(1, firstNoteTime, 0.3))
+	
+	// An early release downgrades the hit by one step and earns no bonus
+	if game.goodHits != 1 {
+		t.Errorf("goodHits = %d, want 1", game.goodHits)
+	}
+	if game.score != 75 {
+		t.Errorf("score = %d, want 75", game.score)
+	}
+}
+
+func TestNotesRetireByTime(t *testing.T) {
+	game := newGame(nil, NewManualClock())
+	game.gameNotes = []GameNote{
+		{StartTime: 1.0, Duration: 0.1, IsActive: true},
+		{StartTime: 1.0, Duration: 2.0, IsActive: true, IsPressed: true},
+	}
+	game.currentTime = 1.0 + NOTE_LINGER_TIME + 0.01
+	
+	game.updateNotes(0)
+	
+	if game.gameNotes[0].IsActive {
+		t.Errorf("a note past its linger time should retire")
+	}
+	if !game.gameNotes[1].IsActive {
+		t.Errorf("a held sustain should stay active")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"sort"
+	
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// GamepadInput reads frets, strum, Star Power and menu controls from a gamepad
+// or guitar controller through Raylib, using the profile saved for the controller
+type GamepadInput struct {
+	gamepad  int32
+	store    *ProfileStore
+	name     string
+	profile  ControllerProfile
+	down     [controlCount]bool
+	pressed  [controlCount]bool
+	released [controlCount]bool
+	
+	whammy     float32 // Rounded whammy position this frame
+	lastWhammy float32 // Whammy position last sent to the game
+}
+
+// NewGamepadInput creates an input source for a gamepad, with profiles from the store
+func NewGamepadInput(gamepad int32, store *ProfileStore) *GamepadInput {
+	return &GamepadInput{
+		gamepad: gamepad,
+		store:   store,
+		profile: store.ProfileFor(""),
+	}
+}
+
+// IsConnected returns whether the gamepad is available
+func (g *GamepadInput) IsConnected() bool {
+	return rl.IsGamepadAvailable(g.gamepad)
+}
+
+// Name returns the name of the connected controller
+func (g *GamepadInput) Name() string {
+	return g.name
+}
+
+// Profile returns the bindings in use
+func (g *GamepadInput) Profile() ControllerProfile {
+	return g.profile
+}
+
+// SetProfile changes the bindings in use and saves them for the connected
+// controller. Profiles that do not validate are refused.
+func (g *GamepadInput) SetProfile(profile ControllerProfile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	profile.Name = g.name
+	g.profile = profile
+	g.store.SetProfile(profile)
+	return g.store.Save()
+}
+
+// Update reads the controls for this frame. It must be called once per frame
+// before the gamepad is polled.
+func (g *GamepadInput) Update() {
+	connected := g.IsConnected()
+	
+	// Switch to the profile saved for a newly connected controller
+	name := ""
+	if connected {
+		name = rl.GetGamepadName(g.gamepad)
+	}
+	if name != g.name {
+		g.name = name
+		g.profile = g.store.ProfileFor(name)
+	}
+	
+	for control := Control(0); control < controlCount; control++ {
+		down := connected && g.profile.Get(control).IsDown(g.gamepad)
+		g.pressed[control] = down && !g.down[control]
+		g.released[control] = !down && g.down[control]
+		g.down[control] = down
+	}
+	g.whammy = quantizeWhammy(g.Value(ControlWhammy))
+}
+
+// IsPressed returns whether a control was pressed this frame
+func (g *GamepadInput) IsPressed(control Control) bool {
+	return g.pressed[control]
+}
+
+// Value returns the analog position of a control, from 0 to 1
+func (g *GamepadInput) Value(control Control) float32 {
+	if !g.IsConnected() {
+		return 0
+	}
+	return g.profile.Get(control).Value(g.gamepad)
+}
+
+// Poll returns the fret, strum and Star Power changes for the current frame
+func (g *GamepadInput) Poll(currentTime float64) []InputEvent {
+	events := make([]InputEvent, 0)
+	for lane := 0; lane < fretCount; lane++ {
+		control := fretControl(lane)
+		if g.pressed[control] {
+			events = append(events, InputEvent{Time: currentTime, Lane: lane, Pressed: true})
+		}
+		if g.released[control] {
+			events = append(events, InputEvent{Time: currentTime, Lane: lane, Pressed: false})
+		}
+	}
+	if g.pressed[ControlStarPower] || g.pressed[ControlTilt] {
+		events = append(events, InputEvent{Time: currentTime, Action: ActionStarPower, Pressed: true})
+	}
+	if g.pressed[ControlStrumUp] || g.pressed[ControlStrumDown] {
+		events = append(events, InputEvent{Time: currentTime, Action: ActionStrum, Pressed: true})
+	}
+	if g.whammy != g.lastWhammy {
+		events = append(events, InputEvent{Time: currentTime, Action: ActionWhammy, Value: g.whammy})
+		g.lastWhammy = g.whammy
+	}
+	return events
+}
+
+// IsLaneDown returns whether the lane's fret is held
+func (g *GamepadInput) IsLaneDown(lane int) bool {
+	if lane < 0 || lane >= fretCount {
+		return false
+	}
+	return g.down[fretControl(lane)]
+}
+
+// CompositeInput merges several input sources so they can be used at the same time.
+// A lane is down while it is held on any source.
+type CompositeInput struct {
+	sources  []InputSource
+	laneDown []map[int]bool // Per source
+}
+
+// NewCompositeInput creates an input source combining the given sources
+func NewCompositeInput(sources ...InputSource) *CompositeInput {
+	laneDown := make([]map[int]bool, len(sources))
+	for i := range laneDown {
+		laneDown[i] = make(map[int]bool)
+	}
+	return &CompositeInput{
+		sources:  sources,
+		laneDown: laneDown,
+	}
+}
+
+// anyDown returns whether a lane is held on any source
+func (c *CompositeInput) anyDown(lane int) bool {
+	for _, down := range c.laneDown {
+		if down[lane] {
+			return true
+		}
+	}
+	return false
+}
+
+// Poll returns the events of every source in time order, dropping lane presses and
+// releases that do not change whether the lane is held overall
+func (c *CompositeInput) Poll(currentTime float64) []InputEvent {
+	all := make([]InputEvent, 0)
+	sourceOf := make([]int, 0)
+	for i, source := range c.sources {
+		for _, event := range source.Poll(currentTime) {
+			all = append(all, event)
+			sourceOf = append(sourceOf, i)
+		}
+	}
+	
+	order := make([]int, len(all))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return all[order[a]].Time < all[order[b]].Time
+	})
+	
+	events := make([]InputEvent, 0, len(all))
+	for _, i := range order {
+		event := all[i]
+		if event.Action == ActionLane {
+			wasDown := c.anyDown(event.Lane)
+			c.laneDown[sourceOf[i]][event.Lane] = event.Pressed
+			if c.anyDown(event.Lane) == wasDown {
+				continue
+			}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// IsLaneDown returns whether the lane is held on any source
+func (c *CompositeInput) IsLaneDown(lane int) bool {
+	for _, source := range c.sources {
+		if source.IsLaneDown(lane) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset rewinds any resettable sources and forgets held lanes
+func (c *CompositeInput) Reset() {
+	for i, source := range c.sources {
+		if resettable, ok := source.(interface{ Reset() }); ok {
+			resettable.Reset()
+		}
+		c.laneDown[i] = make(map[int]bool)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"time"
+)
+
+// HEADLESS_FPS is the frame rate used when stepping a game without a window
+const HEADLESS_FPS = 60
+
+// Simulate starts the game and steps it at a fixed frame rate until it ends.
+// The game must have been created with the given manual clock.
+func Simulate(game *Game, clock *ManualClock, fps int) {
+	if fps <= 0 {
+		fps = HEADLESS_FPS
+	}
+	frameTime := time.Second / time.Duration(fps)
+	deltaTime := float32(frameTime.Seconds())
+	
+	game.StartGame()
+	
+	// Safety limit in case the game never reaches its end condition
+	maxFrames := int((game.songDuration + 1.0) * float64(fps))
+	for frame := 0; frame < maxFrames && game.IsPlaying(); frame++ {
+		clock.Advance(frameTime)
+		game.Update(deltaTime)
+	}
+	
+	if game.IsPlaying() {
+		game.EndGame()
+	}
+}
\ No newline at end of file
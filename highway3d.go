@@ -0,0 +1,229 @@
+package main
+
+import (
+	"math"
+	
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// HighwayView selects how the playfield is drawn
+type HighwayView int
+
+const (
+	ViewFlat HighwayView = iota // Top-down 2D lanes
+	View3D                      // Perspective highway
+)
+
+// String returns the view's display name
+func (v HighwayView) String() string {
+	if v == View3D {
+		return "3D"
+	}
+	return "Flat"
+}
+
+// Highway constants, in world units and seconds
+const (
+	HIGHWAY_LANE_SPACING = 1.0  // Distance between lane centers
+	HIGHWAY_SPEED        = 8.0  // Units a note travels per second
+	HIGHWAY_LENGTH       = 24.0 // Visible length of the highway ahead of the strike line
+	GEM_RADIUS           = 0.32
+	GEM_HEIGHT           = 0.12
+)
+
+// Highway3D draws the playfield as a fretboard receding into the distance
+type Highway3D struct {
+	camera rl.Camera3D
+	
+	// GPU resources, created on the first draw
+	loaded   bool
+	board    rl.Model
+	boardTex rl.Texture2D
+	gem      rl.Model
+}
+
+// NewHighway3D creates a highway with its camera behind and above the strike line
+func NewHighway3D() *Highway3D {
+	return &Highway3D{
+		camera: rl.Camera3D{
+			Position:   rl.NewVector3(0, 2.6, 3.2),
+			Target:     rl.NewVector3(0, 0, -5),
+			Up:         rl.NewVector3(0, 1, 0),
+			Fovy:       60,
+			Projection: rl.CameraPerspective,
+		},
+	}
+}
+
+// load creates the fretboard and gem models, which needs an open window
+func (h *Highway3D) load() {
+	if h.loaded {
+		return
+	}
+	
+	// Dark wood with lighter grain running along the neck
+	image := rl.GenImageColor(64, 256, rl.NewColor(46, 30, 20, 255))
+	for x := int32(3); x < 64; x += 7 {
+		rl.ImageDrawLine(image, x, 0, x+int32(x%3)-1, 255, rl.NewColor(62, 42, 28, 255))
+	}
+	h.boardTex = rl.LoadTextureFromImage(image)
+	rl.UnloadImage(image)
+	
+	boardWidth := float32(3*HIGHWAY_LANE_SPACING + 0.4)
+	h.board = rl.LoadModelFromMesh(rl.GenMeshPlane(boardWidth, HIGHWAY_LENGTH+2, 1, 1))
+	rl.SetMaterialTexture(h.board.Materials, rl.MapDiffuse, h.boardTex)
+	
+	h.gem = rl.LoadModelFromMesh(rl.GenMeshCylinder(GEM_RADIUS, GEM_HEIGHT, 24))
+	h.loaded = true
+}
+
+// Unload frees the highway's GPU resources
+func (h *Highway3D) Unload() {
+	if !h.loaded {
+		return
+	}
+	rl.UnloadModel(h.board)
+	rl.UnloadModel(h.gem)
+	rl.UnloadTexture(h.boardTex)
+	h.loaded = false
+}
+
+// laneX returns the world X of a lane's center
+func laneX(lane int) float32 {
+	return (float32(lane) - 1) * HIGHWAY_LANE_SPACING
+}
+
+// timeZ returns the world Z of something timeUntilHit seconds from the strike line
+func timeZ(timeUntilHit float64, speed float32) float32 {
+	return -float32(timeUntilHit) * speed
+}
+
+// highwaySpeed returns how many world units a note travels per second with the given modifiers
+func highwaySpeed(modifiers Modifiers) float32 {
+	if modifiers.ConstantTime {
+		return HIGHWAY_LENGTH / float32(modifiers.VisibleTime())
+	}
+	return HIGHWAY_SPEED * float32(modifiers.Speed())
+}
+
+// HitPoint returns where a lane crosses the strike line on screen and how wide it is there
+func (h *Highway3D) HitPoint(lane int) (float32, float32, float32) {
+	point := rl.GetWorldToScreen(rl.NewVector3(laneX(lane), 0, 0), h.camera)
+	left := rl.GetWorldToScreen(rl.NewVector3(laneX(lane)-HIGHWAY_LANE_SPACING/2, 0, 0), h.camera)
+	right := rl.GetWorldToScreen(rl.NewVector3(laneX(lane)+HIGHWAY_LANE_SPACING/2, 0, 0), h.camera)
+	return point.X, point.Y, right.X - left.X
+}
+
+// drawHighway draws the 3D playfield: board, beat lines, frets and notes
+func (r *Renderer) drawHighway() {
+	h := r.highway
+	h.load()
+	now := r.game.currentTime
+	modifiers := r.game.Modifiers()
+	speed := highwaySpeed(modifiers)
+	lookahead := float64(HIGHWAY_LENGTH / speed)
+	
+	rl.BeginMode3D(h.camera)
+	
+	// Fretboard, tinted blue while Star Power is active
+	boardTint := rl.White
+	if r.game.IsStarPowerActive() {
+		boardTint = rl.SkyBlue
+	}
+	rl.DrawModel(h.board, rl.NewVector3(0, 0, 1-HIGHWAY_LENGTH/2), 1, boardTint)
+	
+	// Lane dividers
+	halfWidth := float32(1.5 * HIGHWAY_LANE_SPACING)
+	for i := 0; i <= 3; i++ {
+		x := -halfWidth + float32(i)*HIGHWAY_LANE_SPACING
+		rl.DrawLine3D(rl.NewVector3(x, 0.01, 2), rl.NewVector3(x, 0.01, -HIGHWAY_LENGTH), rl.Gray)
+	}
+	
+	// Beat lines scroll with the notes, measure lines are thicker
+	for _, beat := range r.game.BeatsBetween(now, now+lookahead) {
+		z := timeZ(beat.Time-now, speed)
+		if beat.IsDownbeat() {
+			rl.DrawCube(rl.NewVector3(0, 0.01, z), 2*halfWidth, 0.01, 0.06, rl.LightGray)
+		} else {
+			rl.DrawLine3D(rl.NewVector3(-halfWidth, 0.01, z), rl.NewVector3(halfWidth, 0.01, z), rl.DarkGray)
+		}
+	}
+	
+	// Strike line and frets, pushed down when pressed
+	rl.DrawCube(rl.NewVector3(0, 0.01, 0), 2*halfWidth, 0.02, 0.05, rl.Red)
+	for i, lane := range r.game.lanes {
+		color := laneColors[i]
+		if !lane.IsPressed {
+			color = rl.ColorAlpha(color, 0.4)
+		}
+		y := -r.effects.FretOffset(i) * 0.01
+		rl.DrawCylinder(rl.NewVector3(laneX(i), y, 0), GEM_RADIUS+0.05, GEM_RADIUS+0.05, 0.06, 24, color)
+	}
+	
+	// Far notes first so near ones draw on top
+	for i := len(r.game.gameNotes) - 1; i >= 0; i-- {
+		note := &r.game.gameNotes[i]
+		if !note.IsActive || (note.IsHit && note.HitAccuracy != Miss) {
+			continue
+		}
+		timeUntilHit := note.StartTime - now
+		if timeUntilHit > lookahead {
+			continue
+		}
+		
+		// Hidden and sudden fade gems out on the way down, held sustains stay visible
+		visibility := float32(1)
+		if !note.IsPressed {
+			visibility = modifiers.NoteVisibility(timeUntilHit / lookahead)
+		}
+		if visibility <= 0 {
+			continue
+		}
+		r.drawGem3D(note, timeUntilHit, speed, visibility)
+	}
+	
+	rl.EndMode3D()
+}
+
+// drawGem3D draws a note and its sustain tail on the highway
+func (r *Renderer) drawGem3D(note *GameNote, timeUntilHit float64, speed, visibility float32) {
+	h := r.highway
+	color := fadeColor(r.noteColor(note), visibility)
+	white := fadeColor(rl.White, visibility)
+	x := laneX(note.Lane)
+	
+	// Sustain tail, eaten up to the strike line while held
+	if r.game.isSustainedNote(note) {
+		startZ := timeZ(timeUntilHit, speed)
+		if note.IsPressed {
+			startZ = min(startZ, 0)
+		}
+		endZ := max(timeZ(timeUntilHit+note.Duration, speed), -HIGHWAY_LENGTH)
+		if startZ > endZ {
+			tailX := x
+			if r.game.isWhammying(note) {
+				tailX += r.game.Whammy() * 0.05 * float32(math.Sin(rl.GetTime()*30))
+			}
+			rl.DrawCube(rl.NewVector3(tailX, 0.02, (startZ+endZ)/2), 0.14, 0.02, startZ-endZ, rl.ColorAlpha(color, 0.6*visibility))
+		}
+	}
+	
+	// Held sustains leave their gem on the strike line
+	if note.IsPressed {
+		return
+	}
+	position := rl.NewVector3(x, 0.02, timeZ(timeUntilHit, speed))
+	switch note.Type {
+	case NoteTap:
+		// Hollow gem with a bright rim
+		rl.DrawModel(h.gem, position, 1, fadeColor(rl.Black, visibility))
+		rl.DrawModelWires(h.gem, position, 1.05, color)
+	case NoteHOPO:
+		// Gem with a white cap
+		rl.DrawModel(h.gem, position, 1, color)
+		rl.DrawModel(h.gem, rl.NewVector3(x, 0.03+GEM_HEIGHT, position.Z), 0.55, white)
+	default:
+		rl.DrawModel(h.gem, position, 1, color)
+		rl.DrawModelWires(h.gem, position, 1, white)
+	}
+}
\ No newline at end of file
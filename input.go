@@ -0,0 +1,179 @@
+package main
+
+import (
+	"sort"
+	"time"
+	
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// InputAction identifies what an input event controls
+type InputAction int
+
+const (
+	ActionLane      InputAction = iota // A lane key, identified by the event's Lane
+	ActionStarPower                    // Star Power activation
+	ActionStrum                        // Strum bar, judges the held frets in strum mode
+	ActionWhammy                       // Whammy bar moved to the event's Value
+)
+
+// InputEvent represents a lane key or other game control being pressed or released
+type InputEvent struct {
+	Time    float64     `json:"t"` // Song time in seconds when the event happened
+	Action  InputAction `json:"a,omitempty"`
+	Lane    int         `json:"l"`
+	Pressed bool        `json:"p,omitempty"` // True for a press, false for a release
+	Value   float32     `json:"v,omitempty"` // Analog position from 0 to 1 for the whammy
+}
+
+// InputSource provides lane input to the game
+type InputSource interface {
+	// Poll returns the input events that happened up to the given song time
+	Poll(currentTime float64) []InputEvent
+	
+	// IsLaneDown returns whether the lane is currently held
+	IsLaneDown(lane int) bool
+}
+
+// Clock provides the current time to the game
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock reads the wall clock
+type realClock struct{}
+
+// Now returns the current wall-clock time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock is a clock that only moves when advanced, for headless runs
+type ManualClock struct {
+	now time.Time
+}
+
+// NewManualClock creates a manual clock starting at an arbitrary fixed time
+func NewManualClock() *ManualClock {
+	return &ManualClock{
+		now: time.Unix(0, 0),
+	}
+}
+
+// Now returns the clock's current time
+func (c *ManualClock) Now() time.Time {
+	return c.now
+}
+
+// Advance moves the clock forward
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// KeyboardInput reads lane input from the keyboard through Raylib
+type KeyboardInput struct {
+	keyCodes     []int32
+	starPowerKey int32
+	strumKeys    []int32
+	whammyKey    int32 // Full whammy while held
+}
+
+// NewKeyboardInput creates a keyboard input source for the given lane, Star Power, strum and whammy keys
+func NewKeyboardInput(keyCodes []int32, starPowerKey int32, strumKeys []int32, whammyKey int32) *KeyboardInput {
+	return &KeyboardInput{
+		keyCodes:     keyCodes,
+		starPowerKey: starPowerKey,
+		strumKeys:    strumKeys,
+		whammyKey:    whammyKey,
+	}
+}
+
+// Poll returns the key presses and releases for the current frame
+func (k *KeyboardInput) Poll(currentTime float64) []InputEvent {
+	events := make([]InputEvent, 0)
+	for lane, keyCode := range k.keyCodes {
+		if rl.IsKeyPressed(keyCode) {
+			events = append(events, InputEvent{Time: currentTime, Lane: lane, Pressed: true})
+		}
+		if rl.IsKeyReleased(keyCode) {
+			events = append(events, InputEvent{Time: currentTime, Lane: lane, Pressed: false})
+		}
+	}
+	if rl.IsKeyPressed(k.starPowerKey) {
+		events = append(events, InputEvent{Time: currentTime, Action: ActionStarPower, Pressed: true})
+	}
+	for _, keyCode := range k.strumKeys {
+		if rl.IsKeyPressed(keyCode) {
+			events = append(events, InputEvent{Time: currentTime, Action: ActionStrum, Pressed: true})
+			break // Strumming up and down together is one strum
+		}
+	}
+	if rl.IsKeyPressed(k.whammyKey) {
+		events = append(events, InputEvent{Time: currentTime, Action: ActionWhammy, Value: 1})
+	}
+	if rl.IsKeyReleased(k.whammyKey) {
+		events = append(events, InputEvent{Time: currentTime, Action: ActionWhammy, Value: 0})
+	}
+	return events
+}
+
+// IsLaneDown returns whether the lane's key is held
+func (k *KeyboardInput) IsLaneDown(lane int) bool {
+	if lane < 0 || lane >= len(k.keyCodes) {
+		return false
+	}
+	return rl.IsKeyDown(k.keyCodes[lane])
+}
+
+// ScriptedInput plays back a fixed timeline of lane events
+type ScriptedInput struct {
+	events   []InputEvent
+	next     int
+	laneDown map[int]bool
+}
+
+// NewScriptedInput creates an input source from a list of lane events
+func NewScriptedInput(events []InputEvent) *ScriptedInput {
+	sorted := make([]InputEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time < sorted[j].Time
+	})
+	
+	return &ScriptedInput{
+		events:   sorted,
+		laneDown: make(map[int]bool),
+	}
+}
+
+// Poll returns all scripted events up to the given song time
+func (s *ScriptedInput) Poll(currentTime float64) []InputEvent {
+	start := s.next
+	for s.next < len(s.events) && s.events[s.next].Time <= currentTime {
+		event := s.events[s.next]
+		if event.Action == ActionLane {
+			s.laneDown[event.Lane] = event.Pressed
+		}
+		s.next++
+	}
+	return s.events[start:s.next]
+}
+
+// IsLaneDown returns whether the lane is held at the last polled time
+func (s *ScriptedInput) IsLaneDown(lane int) bool {
+	return s.laneDown[lane]
+}
+
+// Reset rewinds the script to the beginning
+func (s *ScriptedInput) Reset() {
+	s.next = 0
+	s.laneDown = make(map[int]bool)
+}
+
+// Press is a helper that scripts a press at the given time and a release after hold seconds
+func Press(lane int, at float64, hold float64) []InputEvent {
+	return []InputEvent{
+		{Time: at, Lane: lane, Pressed: true},
+		{Time: at + hold, Lane: lane, Pressed: false},
+	}
+}
\ No newline at end of file
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// JudgementProfile defines every timing window used to judge a run, in seconds
+type JudgementProfile struct {
+	Name string `json:"name"`
+	
+	// Hit windows before (early) and after (late) a note's start
+	PerfectEarly float64 `json:"perfect_early"`
+	PerfectLate  float64 `json:"perfect_late"`
+	GoodEarly    float64 `json:"good_early"`
+	GoodLate     float64 `json:"good_late"`
+	OKEarly      float64 `json:"ok_early"`
+	OKLate       float64 `json:"ok_late"` // Notes not hit by the end of this window are missed
+	
+	// Sustains
+	SustainThreshold float64 `json:"sustain_threshold"` // Notes longer than this must be held
+	ReleaseWindow    float64 `json:"release_window"`    // Releasing earlier than this before the end downgrades the hit
+}
+
+// Built-in judgement profiles, loosest first
+var judgementProfiles = []JudgementProfile{
+	{
+		Name:         "Casual",
+		PerfectEarly: 0.07, PerfectLate: 0.07,
+		GoodEarly: 0.13, GoodLate: 0.13,
+		OKEarly: 0.18, OKLate: 0.2,
+		SustainThreshold: 0.3, ReleaseWindow: 0.25,
+	},
+	{
+		Name:         "Standard",
+		PerfectEarly: 0.05, PerfectLate: 0.05,
+		GoodEarly: 0.1, GoodLate: 0.1,
+		OKEarly: 0.15, OKLate: 0.15,
+		SustainThreshold: 0.3, ReleaseWindow: 0.15,
+	},
+	{
+		Name:         "Strict",
+		PerfectEarly: 0.035, PerfectLate: 0.03,
+		GoodEarly: 0.07, GoodLate: 0.06,
+		OKEarly: 0.1, OKLate: 0.09,
+		SustainThreshold: 0.3, ReleaseWindow: 0.08,
+	},
+}
+
+// StandardJudgement returns the default judgement profile
+func StandardJudgement() JudgementProfile {
+	return judgementProfiles[1]
+}
+
+// JudgementProfileByName returns a built-in profile, ignoring case
+func JudgementProfileByName(name string) (JudgementProfile, bool) {
+	for _, profile := range judgementProfiles {
+		if strings.EqualFold(profile.Name, name) {
+			return profile, true
+		}
+	}
+	return JudgementProfile{}, false
+}
+
+// ResolveJudgementProfile returns the built-in profile with a name, or else loads the name as a JSON file
+func ResolveJudgementProfile(name string) (JudgementProfile, error) {
+	if profile, ok := JudgementProfileByName(name); ok {
+		return profile, nil
+	}
+	return LoadJudgementProfile(name)
+}
+
+// LoadJudgementProfile reads a custom judgement profile from a JSON file
+func LoadJudgementProfile(path string) (JudgementProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return JudgementProfile{}, fmt.Errorf("failed to read judgement profile: %v", err)
+	}
+	
+	profile := StandardJudgement()
+	profile.Name = "Custom"
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return JudgementProfile{}, fmt.Errorf("failed to decode judgement profile: %v", err)
+	}
+	if err := profile.Validate(); err != nil {
+		return JudgementProfile{}, err
+	}
+	
+	return profile, nil
+}
+
+// Validate checks that every window is positive, each accuracy's window contains
+// the tighter ones and late notes are judged before they retire
+func (p JudgementProfile) Validate() error {
+	if p.PerfectEarly <= 0 || p.PerfectLate <= 0 {
+		return fmt.Errorf("judgement profile %s: perfect windows must be positive", p.Name)
+	}
+	if p.GoodEarly < p.PerfectEarly || p.GoodLate < p.PerfectLate {
+		return fmt.Errorf("judgement profile %s: good windows must contain the perfect windows", p.Name)
+	}
+	if p.OKEarly < p.GoodEarly || p.OKLate < p.GoodLate {
+		return fmt.Errorf("judgement profile %s: OK windows must contain the good windows", p.Name)
+	}
+	if p.OKLate >= NOTE_LINGER_TIME {
+		return fmt.Errorf("judgement profile %s: late OK window must end before notes retire after %.2fs", p.Name, NOTE_LINGER_TIME)
+	}
+	if p.SustainThreshold <= 0 || p.ReleaseWindow < 0 {
+		return fmt.Errorf("judgement profile %s: sustain threshold and release window must not be negative", p.Name)
+	}
+	return nil
+}
+
+// Judge returns the accuracy of a hit timeDiff seconds after the note, negative for early hits
+func (p JudgementProfile) Judge(timeDiff float64) HitAccuracy {
+	early, late := -timeDiff, timeDiff
+	switch {
+	case early > p.OKEarly || late > p.OKLate:
+		return Miss
+	case early > p.GoodEarly || late > p.GoodLate:
+		return OK
+	case early > p.PerfectEarly || late > p.PerfectLate:
+		return Good
+	default:
+		return Perfect
+	}
+}
+
+// IsSustain returns whether a note of the given duration has to be held
+func (p JudgementProfile) IsSustain(duration float64) bool {
+	return duration > p.SustainThreshold
+}
+
+// SetJudgement changes the judgement profile for the next runs
+func (g *Game) SetJudgement(profile JudgementProfile) {
+	g.judgement = profile
+	if g.midiProcessor != nil {
+		g.maxScore = g.maxPossibleScore() // Sustains depend on the profile
+	}
+}
+
+// Judgement returns the judgement profile in use
+func (g *Game) Judgement() JudgementProfile {
+	return g.judgement
+}
+
+// CycleJudgement switches to the next built-in profile
+func (g *Game) CycleJudgement() {
+	next := 0
+	for i, profile := range judgementProfiles {
+		if profile.Name == g.judgement.Name {
+			next = (i + 1) % len(judgementProfiles)
+		}
+	}
+	g.SetJudgement(judgementProfiles[next])
+}
\ No newline at end of file
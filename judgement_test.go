@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJudgementWindows(t *testing.T) {
+	strict, _ := JudgementProfileByName("strict")
+	tests := []struct {
+		name    string
+		profile JudgementProfile
+		offset  float64
+		want    HitAccuracy
+	}{
+		{"standard perfect", StandardJudgement(), 0.05, Perfect},
+		{"standard ok late", StandardJudgement(), 0.15, OK},
+		{"standard miss late", StandardJudgement(), 0.16, Miss},
+		{"strict perfect early", strict, -0.035, Perfect},
+		{"strict good late", strict, 0.035, Good},
+		{"strict ok early", strict, -0.095, OK},
+		{"strict miss late", strict, 0.095, Miss},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.Judge(tt.offset); got != tt.want {
+				t.Errorf("Judge(%.3f) = %v, want %v", tt.offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJudgementLateWindowMatchesMissGrace(t *testing.T) {
+	notes := []MIDINote{laneNote(1, 0.0, 0.1)}
+	
+	// 190ms late is inside Casual's late window but past Standard's
+	for _, tt := range []struct {
+		name string
+		want HitAccuracy
+	}{
+		{"Casual", OK},
+		{"Standard", Miss},
+	} {
+		profile, _ := JudgementProfileByName(tt.name)
+		clock := NewManualClock()
+		game := NewHeadlessGame(NewScriptedInput(Press(1, firstNoteTime+0.19, 0.05)), clock)
+		if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes(notes)); err != nil {
+			t.Fatalf("LoadMIDITrack failed: %v", err)
+		}
+		game.SetJudgement(profile)
+		Simulate(game, clock, HEADLESS_FPS)
+		
+		if got := game.gameNotes[0].HitAccuracy; got != tt.want {
+			t.Errorf("%s: accuracy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJudgementReleaseWindow(t *testing.T) {
+	notes := []MIDINote{laneNote(1, 0.0, 1.0)}
+	
+	// Releasing 100ms before the end is fine on Standard but early on Strict
+	for _, tt := range []struct {
+		name string
+		want int32 // Perfect hits
+	}{
+		{"Standard", 1},
+		{"Strict", 0},
+	} {
+		profile, _ := JudgementProfileByName(tt.name)
+		clock := NewManualClock()
+		game := NewHeadlessGame(NewScriptedInput(Press(1, firstNoteTime, 0.9)), clock)
+		if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes(notes)); err != nil {
+			t.Fatalf("LoadMIDITrack failed: %v", err)
+		}
+		game.SetJudgement(profile)
+		Simulate(game, clock, HEADLESS_FPS)
+		
+		if game.perfectHits != tt.want {
+			t.Errorf("%s: perfectHits = %d, want %d", tt.name, game.perfectHits, tt.want)
+		}
+	}
+}
+
+func TestLoadJudgementProfile(t *testing.T) {
+	dir := t.TempDir()
+	
+	path := filepath.Join(dir, "custom.json")
+	custom := `{"perfect_early": 0.02, "perfect_late": 0.04, "good_early": 0.05, "good_late": 0.08}`
+	if err := os.WriteFile(path, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := LoadJudgementProfile(path)
+	if err != nil {
+		t.Fatalf("LoadJudgementProfile failed: %v", err)
+	}
+	if profile.Name != "Custom" || profile.PerfectLate != 0.04 {
+		t.Errorf("profile = %+v, want custom windows", profile)
+	}
+	// Windows missing from the file keep the Standard values
+	if profile.OKLate != StandardJudgement().OKLate {
+		t.Errorf("OKLate = %.3f, want %.3f", profile.OKLate, StandardJudgement().OKLate)
+	}
+	
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"good_early": 0.5}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadJudgementProfile(invalid); err == nil {
+		t.Errorf("good window wider than the OK window should be rejected")
+	}
+	
+	// Notes retired before the late window ends would never be missed
+	lingering := filepath.Join(dir, "lingering.json")
+	if err := os.WriteFile(lingering, []byte(`{"ok_late": 1.0}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadJudgementProfile(lingering); err == nil {
+		t.Errorf("late OK window past NOTE_LINGER_TIME should be rejected")
+	}
+}
+
+func TestJudgementStoredWithScoreAndReplay(t *testing.T) {
+	strict, _ := JudgementProfileByName("Strict")
+	notes := []MIDINote{laneNote(0, 0.0, 0.1), laneNote(1, 0.5, 0.1)}
+	
+	clock := NewManualClock()
+	events := append(Press(0, firstNoteTime+0.05, 0.05), Press(1, firstNoteTime+0.5, 0.05)...)
+	game := NewHeadlessGame(NewScriptedInput(events), clock)
+	if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes(notes)); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	game.SetJudgement(strict)
+	db, err := LoadScoreDatabase(filepath.Join(t.TempDir(), SCORES_FILE))
+	if err != nil {
+		t.Fatalf("LoadScoreDatabase failed: %v", err)
+	}
+	game.scores = db
+	
+	Simulate(game, clock, HEADLESS_FPS)
+	game.saveScore()
+	
+	entries := db.TopScores(game.songHash, game.difficulty, 1)
+	if len(entries) != 1 || entries[0].Judgement != strict {
+		t.Fatalf("score entries = %+v, want one judged with Strict", entries)
+	}
+	
+	// The replay is judged with Strict too, where 50ms late is only Good
+	replay := game.BuildReplay()
+	if replay.Settings.Judgement != strict {
+		t.Errorf("replay judgement = %s, want Strict", replay.Settings.Judgement.Name)
+	}
+	result, err := VerifyReplay(replay, game.midiProcessor)
+	if err != nil {
+		t.Fatalf("VerifyReplay failed: %v", err)
+	}
+	if result.GoodHits != 1 {
+		t.Errorf("GoodHits = %d, want 1", result.GoodHits)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,116 @@
+package main
+
+// Layout constants, in design pixels
+const (
+	DESIGN_WIDTH      = 800 // Window size the screens were laid out for, also the default window size
+	DESIGN_HEIGHT     = 600
+	MIN_WINDOW_WIDTH  = 640
+	MIN_WINDOW_HEIGHT = 480
+	LANE_WIDTH        = 200
+	HIT_LINE_MARGIN   = 100 // Distance from the hit line to the bottom of the window
+	MIN_FONT_SIZE     = 10  // Smallest font size that stays readable when scaled down
+)
+
+// LaneRect is a lane's horizontal extent on screen
+type LaneRect struct {
+	X     float32
+	Width float32
+}
+
+// Layout places the playfield and HUD for the current window size. Everything is
+// laid out at the design size and scaled uniformly, so the screens keep their
+// proportions at any resolution and window shape. Sizes are in screen coordinates,
+// which raylib maps to physical pixels on high-DPI displays.
+type Layout struct {
+	Width   int32
+	Height  int32
+	Scale   float32 // Screen pixels per design pixel
+	HitLine float32 // Y position of the hit line
+	Lanes   [3]LaneRect
+}
+
+// NewLayout creates the layout for a window of the given size
+func NewLayout(width, height int32) Layout {
+	scale := min(float32(width)/DESIGN_WIDTH, float32(height)/DESIGN_HEIGHT)
+	if scale <= 0 {
+		scale = 1
+	}
+	
+	layout := Layout{
+		Width:   width,
+		Height:  height,
+		Scale:   scale,
+		HitLine: float32(height) - HIT_LINE_MARGIN*scale,
+	}
+	
+	// Lanes are centered however wide the window is
+	laneWidth := LANE_WIDTH * scale
+	left := float32(width)/2 - laneWidth*float32(len(layout.Lanes))/2
+	for i := range layout.Lanes {
+		layout.Lanes[i] = LaneRect{X: left + float32(i)*laneWidth, Width: laneWidth}
+	}
+	
+	return layout
+}
+
+// Scaled converts a length in design pixels to screen pixels
+func (l Layout) Scaled(v float32) float32 {
+	return v * l.Scale
+}
+
+// Px converts a length in design pixels to whole screen pixels
+func (l Layout) Px(v float32) int32 {
+	return int32(v * l.Scale)
+}
+
+// Font returns the scaled size for a font designed at the given size
+func (l Layout) Font(size int32) int32 {
+	return max(l.Px(float32(size)), MIN_FONT_SIZE)
+}
+
+// CenterX returns the horizontal center of the window
+func (l Layout) CenterX() int32 {
+	return l.Width / 2
+}
+
+// CenterY returns the vertical center of the window
+func (l Layout) CenterY() int32 {
+	return l.Height / 2
+}
+
+// Right returns the X position offset design pixels from the right edge
+func (l Layout) Right(offset float32) int32 {
+	return l.Width - l.Px(offset)
+}
+
+// Bottom returns the Y position offset design pixels from the bottom edge
+func (l Layout) Bottom(offset float32) int32 {
+	return l.Height - l.Px(offset)
+}
+
+// LaneCenter returns the X position of a lane's center
+func (l Layout) LaneCenter(lane int) float32 {
+	if lane < 0 || lane >= len(l.Lanes) {
+		return float32(l.CenterX())
+	}
+	return l.Lanes[lane].X + l.Lanes[lane].Width/2
+}
+
+// PlayfieldLeft returns the X position of the left edge of the lanes
+func (l Layout) PlayfieldLeft() float32 {
+	return l.Lanes[0].X
+}
+
+// PlayfieldRight returns the X position of the right edge of the lanes
+func (l Layout) PlayfieldRight() float32 {
+	last := l.Lanes[len(l.Lanes)-1]
+	return last.X + last.Width
+}
+
+// NoteSpeed returns how many screen pixels a note scrolls per second on the flat playfield
+func (l Layout) NoteSpeed(modifiers Modifiers) float32 {
+	if modifiers.ConstantTime {
+		return l.HitLine / float32(modifiers.VisibleTime())
+	}
+	return l.Scaled(NOTE_SPEED) * float32(modifiers.Speed())
+}
\ No newline at end of file
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLayoutAtDesignSize(t *testing.T) {
+	l := NewLayout(DESIGN_WIDTH, DESIGN_HEIGHT)
+	
+	if l.Scale != 1 {
+		t.Errorf("Scale = %v, want 1", l.Scale)
+	}
+	if l.HitLine != 500 {
+		t.Errorf("HitLine = %v, want 500", l.HitLine)
+	}
+	// The original fixed lane positions
+	for i, want := range []float32{100, 300, 500} {
+		if l.Lanes[i].X != want || l.Lanes[i].Width != LANE_WIDTH {
+			t.Errorf("lane %d = %+v, want X %v width %d", i, l.Lanes[i], want, LANE_WIDTH)
+		}
+	}
+}
+
+func TestLayoutScalesWithWindow(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int32
+		scale         float32
+	}{
+		{"double size", 1600, 1200, 2},
+		{"widescreen", 1920, 1080, 1.8},
+		{"tall window", 800, 1000, 1},
+		{"minimum size", MIN_WINDOW_WIDTH, MIN_WINDOW_HEIGHT, 0.8},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLayout(tt.width, tt.height)
+			if math.Abs(float64(l.Scale-tt.scale)) > 1e-5 {
+				t.Errorf("Scale = %v, want %v", l.Scale, tt.scale)
+			}
+			
+			// Lanes are centered and keep their proportions
+			left, right := l.PlayfieldLeft(), l.PlayfieldRight()
+			if math.Abs(float64(left+right)/2-float64(tt.width)/2) > 0.01 {
+				t.Errorf("lanes span %v to %v, not centered in width %d", left, right, tt.width)
+			}
+			if math.Abs(float64(right-left-3*LANE_WIDTH*tt.scale)) > 0.01 {
+				t.Errorf("lanes are %v wide, want %v", right-left, 3*LANE_WIDTH*tt.scale)
+			}
+			
+			// The hit line keeps its distance from the bottom in design pixels
+			if got := float32(tt.height) - l.HitLine; math.Abs(float64(got-HIT_LINE_MARGIN*tt.scale)) > 0.01 {
+				t.Errorf("hit line is %v above the bottom, want %v", got, HIT_LINE_MARGIN*tt.scale)
+			}
+			if l.HitLine <= 0 || l.HitLine >= float32(tt.height) {
+				t.Errorf("HitLine = %v, outside the window", l.HitLine)
+			}
+		})
+	}
+}
+
+func TestLayoutFontsStayReadable(t *testing.T) {
+	l := NewLayout(DESIGN_WIDTH/4, DESIGN_HEIGHT/4)
+	if size := l.Font(12); size != MIN_FONT_SIZE {
+		t.Errorf("Font(12) = %d in a tiny window, want the minimum %d", size, MIN_FONT_SIZE)
+	}
+	
+	l = NewLayout(DESIGN_WIDTH*2, DESIGN_HEIGHT*2)
+	if size := l.Font(20); size != 40 {
+		t.Errorf("Font(20) = %d at double size, want 40", size)
+	}
+	if x := l.Right(230); x != DESIGN_WIDTH*2-460 {
+		t.Errorf("Right(230) = %d, want %d", x, DESIGN_WIDTH*2-460)
+	}
+}
+
+func TestFlatHitPointFollowsLayout(t *testing.T) {
+	l := NewLayout(1920, 1080)
+	for lane := range l.Lanes {
+		x, y, width := flatHitPoint(l, lane)
+		if x != l.LaneCenter(lane) || y != l.HitLine || width != l.Lanes[lane].Width {
+			t.Errorf("flatHitPoint(%d) = (%v, %v, %v), want lane %d's center on the hit line", lane, x, y, width, lane)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Lint constants
+const (
+	LINT_MIN_GAP          = 0.06  // Seconds between notes in one lane that can still be played apart
+	LINT_SHORT_SUSTAIN    = 0.5   // Notes longer than this share of the sustain threshold look like sustains
+	LINT_MIN_BPM          = 40.0  // Slowest tempo that is not flagged
+	LINT_MAX_BPM          = 300.0 // Fastest tempo that is not flagged
+	LINT_MAX_TEMPO_JUMP   = 2.0   // Largest factor between neighbouring tempos that is not flagged
+	LINT_TIME_TOLERANCE   = 1e-6  // Seconds within which two notes start together
+	LINT_EXIT_PROBLEMS    = 1     // Exit code when the chart has errors
+	LINT_EXIT_LOAD_FAILED = 2     // Exit code when the chart could not be read
+)
+
+// LintSeverity is how bad a chart problem is
+type LintSeverity string
+
+// Lint severities
+const (
+	LintError   LintSeverity = "error"   // The chart cannot be played as written
+	LintWarning LintSeverity = "warning" // The chart plays, but probably not as intended
+)
+
+// LintIssue is a playability problem found in a chart
+type LintIssue struct {
+	Severity LintSeverity `json:"severity"`
+	Check    string       `json:"check"`
+	Time     float64      `json:"time"` // Song time in seconds
+	Lane     int          `json:"lane"` // Lane of the note, -1 when the problem has none
+	Message  string       `json:"message"`
+}
+
+// LintReport is every problem found in a chart
+type LintReport struct {
+	File     string      `json:"file"`
+	Notes    int         `json:"notes"`
+	Errors   int         `json:"errors"`
+	Warnings int         `json:"warnings"`
+	Issues   []LintIssue `json:"issues"`
+}
+
+// LintChart checks the guitar track of a loaded chart for playability problems,
+// judging sustains with a judgement profile
+func LintChart(midiProcessor *MIDIProcessor, judgement JudgementProfile) (LintReport, error) {
+	track, err := midiProcessor.FindGuitarTrack()
+	if err != nil {
+		return LintReport{}, err
+	}
+	report := LintReport{File: midiProcessor.FilePath(), Notes: len(track.Notes), Issues: make([]LintIssue, 0)}
+	add := func(severity LintSeverity, check string, time float64, lane int, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, LintIssue{
+			Severity: severity,
+			Check:    check,
+			Time:     time,
+			Lane:     lane,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	
+	notes := make([]MIDINote, len(track.Notes))
+	copy(notes, track.Notes)
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].StartTime < notes[j].StartTime
+	})
+	
+	// Compare each note with the next one in its lane
+	previous := [3]int{-1, -1, -1}
+	for i, note := range notes {
+		if note.Lane < 0 || note.Lane >= len(previous) {
+			continue
+		}
+		if p := previous[note.Lane]; p >= 0 {
+			last := notes[p]
+			gap := note.StartTime - last.StartTime
+			switch {
+			case gap < LINT_TIME_TOLERANCE:
+				add(LintError, "chord-collapse", note.StartTime, note.Lane,
+					"pitches %d and %d start together in the same lane and play as one note", last.Pitch, note.Pitch)
+			case last.StartTime+last.Duration > note.StartTime+LINT_TIME_TOLERANCE:
+				add(LintError, "overlap", note.StartTime, note.Lane,
+					"note overlaps the %.3fs note at %.3fs in the same lane", last.Duration, last.StartTime)
+			case gap < LINT_MIN_GAP:
+				add(LintError, "gap", note.StartTime, note.Lane,
+					"note is %.0fms after the last one in its lane, under the %.0fms that can be played",
+					gap*1000, LINT_MIN_GAP*1000)
+			}
+		}
+		previous[note.Lane] = i
+		
+		if note.Duration > judgement.SustainThreshold*LINT_SHORT_SUSTAIN && !judgement.IsSustain(note.Duration) {
+			add(LintWarning, "short-sustain", note.StartTime, note.Lane,
+				"%.3fs note is under the %.2fs sustain threshold and plays as a tap", note.Duration, judgement.SustainThreshold)
+		}
+	}
+	
+	for _, note := range midiProcessor.DroppedNotes() {
+		add(LintWarning, "dropped-pitch", note.StartTime, -1,
+			"pitch %d is outside the guitar range %d-%d and was left out", note.Pitch, GUITAR_MIN_PITCH, GUITAR_MAX_PITCH)
+	}
+	for _, dangling := range midiProcessor.dangling {
+		if dangling.Off {
+			add(LintWarning, "dangling-note-off", dangling.Time, -1, "note off for pitch %d with no note on", dangling.Pitch)
+		} else {
+			add(LintWarning, "dangling-note-on", dangling.Time, -1, "note on for pitch %d is never turned off", dangling.Pitch)
+		}
+	}
+	
+	lintTempoMap(midiProcessor.TempoMap(), add)
+	
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Time < report.Issues[j].Time
+	})
+	for _, issue := range report.Issues {
+		if issue.Severity == LintError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	return report, nil
+}
+
+// lintTempoMap flags tempos that are invalid, extreme or jump sharply
+func lintTempoMap(tempoMap TempoMap, add func(LintSeverity, string, float64, int, string, ...interface{})) {
+	if tempoMap.TicksPerBeat <= 0 {
+		add(LintError, "tempo", 0, -1, "file has no tick resolution, SMPTE timing is not supported")
+		return
+	}
+	
+	lastBPM := 0.0
+	for _, change := range tempoMap.Tempos {
+		time := tempoMap.TicksToSeconds(change.Tick)
+		if change.MicrosPerBeat <= 0 {
+			add(LintError, "tempo", time, -1, "tempo of %d microseconds per beat is invalid", change.MicrosPerBeat)
+			continue
+		}
+		bpm := 60000000.0 / float64(change.MicrosPerBeat)
+		if bpm < LINT_MIN_BPM || bpm > LINT_MAX_BPM {
+			add(LintWarning, "tempo", time, -1, "tempo of %.1f BPM is outside %.0f-%.0f BPM", bpm, LINT_MIN_BPM, LINT_MAX_BPM)
+		}
+		if lastBPM > 0 && math.Max(bpm/lastBPM, lastBPM/bpm) > LINT_MAX_TEMPO_JUMP {
+			add(LintWarning, "tempo", time, -1, "tempo jumps from %.1f to %.1f BPM", lastBPM, bpm)
+		}
+		lastBPM = bpm
+	}
+}
+
+// PrintLint writes a lint report as text, one line per problem
+func PrintLint(w io.Writer, report LintReport) {
+	laneNames := []string{"A", "W", "D"}
+	
+	for _, issue := range report.Issues {
+		where := ""
+		if issue.Lane >= 0 && issue.Lane < len(laneNames) {
+			where = fmt.Sprintf(" lane %s", laneNames[issue.Lane])
+		}
+		fmt.Fprintf(w, "%s: %.3fs%s: %s: %s [%s]\n", report.File, issue.Time, where, issue.Severity, issue.Message, issue.Check)
+	}
+	fmt.Fprintf(w, "%s: %d notes, %d errors, %d warnings\n", report.File, report.Notes, report.Errors, report.Warnings)
+}
+
+// RunLint runs the lint command on its arguments and returns the exit code
+func RunLint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	judgementName := flags.String("judgement", "Standard", "timing profile whose sustain threshold is checked")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: ghero lint [-json] [-judgement name] <file.mid>\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return LINT_EXIT_LOAD_FAILED
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return LINT_EXIT_LOAD_FAILED
+	}
+	
+	judgement, err := ResolveJudgementProfile(*judgementName)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load judgement profile: %v\n", err)
+		return LINT_EXIT_LOAD_FAILED
+	}
+	
+	midiProcessor, err := loadChartQuietly(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load MIDI file: %v\n", err)
+		return LINT_EXIT_LOAD_FAILED
+	}
+	report, err := LintChart(midiProcessor, judgement)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to lint chart: %v\n", err)
+		return LINT_EXIT_LOAD_FAILED
+	}
+	
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "Failed to write report: %v\n", err)
+			return LINT_EXIT_LOAD_FAILED
+		}
+	} else {
+		PrintLint(stdout, report)
+	}
+	
+	if report.Errors > 0 {
+		return LINT_EXIT_PROBLEMS
+	}
+	return 0
+}
+
+// loadChartQuietly loads a MIDI file without printing its progress, so command
+// output can be piped. Unlike the game it does not fall back to test data.
+func loadChartQuietly(path string) (*MIDIProcessor, error) {
+	midiProcessor := NewMIDIProcessor()
+	midiProcessor.SetOutput(io.Discard)
+	if err := midiProcessor.LoadMIDI(path); err != nil {
+		return nil, err
+	}
+	if !midiProcessor.Parsed() {
+		return nil, fmt.Errorf("%s is not a readable MIDI file", path)
+	}
+	return midiProcessor, nil
+}
\ No newline at end of file
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// writeLintChart writes a chart at 120 BPM with 480 ticks per beat, so 240 ticks are a quarter second
+func writeLintChart(t *testing.T, noteTrack []byte) string {
+	t.Helper()
+	tempoTrack := midiTrackChunk(
+		midiEvent(0, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20),
+		midiEvent(3840, 0xFF, 0x51, 0x03, 0x12, 0x4F, 0x80), // Drops to 50 BPM at bar 3
+	)
+	return writeMIDIFile(t, 480, tempoTrack, noteTrack)
+}
+
+func TestLintChartFindsProblems(t *testing.T) {
+	path := writeLintChart(t, midiTrackChunk(
+		// Two pitches that both map to lane A start together
+		midiEvent(0, 0x90, 52, 100), midiEvent(0, 0x90, 55, 100),
+		midiEvent(120, 0x80, 52, 0), midiEvent(0, 0x80, 55, 0),
+		// A lane W sustain overlapped by the next lane W note
+		midiEvent(360, 0x90, 64, 100), midiEvent(240, 0x90, 65, 100),
+		midiEvent(60, 0x80, 65, 0), midiEvent(180, 0x80, 64, 0),
+		// Lane D notes 30ms apart, then one a little short to be a sustain
+		midiEvent(480, 0x90, 76, 100), midiEvent(10, 0x80, 76, 0),
+		midiEvent(19, 0x90, 77, 100), midiEvent(100, 0x80, 77, 0),
+		midiEvent(0, 0x90, 80, 100), midiEvent(200, 0x80, 80, 0),
+		// A bass note outside the guitar range and a note off with no note on
+		midiEvent(480, 0x90, 30, 100), midiEvent(240, 0x80, 30, 0),
+		midiEvent(0, 0x80, 60, 0),
+	))
+	midiProcessor := NewMIDIProcessor()
+	if err := midiProcessor.LoadMIDI(path); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	report, err := LintChart(midiProcessor, StandardJudgement())
+	if err != nil {
+		t.Fatalf("LintChart failed: %v", err)
+	}
+	
+	found := make(map[string]LintIssue)
+	for _, issue := range report.Issues {
+		found[issue.Check] = issue
+	}
+	want := map[string]LintSeverity{
+		"chord-collapse":    LintError,
+		"overlap":           LintError,
+		"gap":               LintError,
+		"short-sustain":     LintWarning,
+		"dropped-pitch":     LintWarning,
+		"dangling-note-off": LintWarning,
+		"tempo":             LintWarning,
+	}
+	for check, severity := range want {
+		issue, ok := found[check]
+		if !ok {
+			t.Errorf("no %s issue in %+v", check, report.Issues)
+			continue
+		}
+		if issue.Severity != severity {
+			t.Errorf("%s issue is a %s, want a %s", check, issue.Severity, severity)
+		}
+	}
+	if len(report.Issues) != len(want) {
+		t.Errorf("found %d issues, want one of each check: %+v", len(report.Issues), report.Issues)
+	}
+	if report.Errors != 3 || report.Warnings != 4 {
+		t.Errorf("report has %d errors and %d warnings, want 3 and 4", report.Errors, report.Warnings)
+	}
+	if issue := found["overlap"]; issue.Lane != 1 || issue.Time != 0.75 {
+		t.Errorf("overlap = %+v, want lane W at 0.75s", issue)
+	}
+}
+
+func TestRunLintExitCodes(t *testing.T) {
+	clean := writeLintChart(t, midiTrackChunk(
+		midiEvent(0, 0x90, 52, 100), midiEvent(240, 0x80, 52, 0),
+		midiEvent(0, 0x90, 64, 100), midiEvent(960, 0x80, 64, 0),
+	))
+	broken := writeLintChart(t, midiTrackChunk(
+		midiEvent(0, 0x90, 52, 100), midiEvent(0, 0x90, 55, 100),
+		midiEvent(240, 0x80, 52, 0), midiEvent(0, 0x80, 55, 0),
+	))
+	
+	var stdout, stderr bytes.Buffer
+	if code := RunLint([]string{"-json", clean}, &stdout, &stderr); code != 0 {
+		t.Errorf("clean chart exited with %d: %s", code, stderr.String())
+	}
+	var report LintReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("output is not a JSON report: %v\n%s", err, stdout.String())
+	}
+	if report.Notes != 2 || report.Errors != 0 {
+		t.Errorf("clean report = %+v, want 2 notes without errors", report)
+	}
+	
+	stdout.Reset()
+	if code := RunLint([]string{broken}, &stdout, &stderr); code != LINT_EXIT_PROBLEMS {
+		t.Errorf("broken chart exited with %d, want %d", code, LINT_EXIT_PROBLEMS)
+	}
+	if !bytes.Contains(stdout.Bytes(), []byte("[chord-collapse]")) {
+		t.Errorf("text output does not name the problem:\n%s", stdout.String())
+	}
+	
+	if code := RunLint([]string{clean + ".missing"}, &stdout, &stderr); code != LINT_EXIT_LOAD_FAILED {
+		t.Errorf("missing file exited with %d, want %d", code, LINT_EXIT_LOAD_FAILED)
+	}
+}
+
+func TestLoadProgressOutput(t *testing.T) {
+	path := writeLintChart(t, midiTrackChunk(
+		midiEvent(0, 0x90, 52, 100), midiEvent(240, 0x80, 52, 0),
+	))
+	
+	var output bytes.Buffer
+	midiProcessor := NewMIDIProcessor()
+	midiProcessor.SetOutput(&output)
+	if err := midiProcessor.LoadMIDI(path); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	if !bytes.Contains(output.Bytes(), []byte("Tempo change")) {
+		t.Errorf("parser progress was not written to the processor's output:\n%s", output.String())
+	}
+	
+	if _, err := loadChartQuietly(path + ".missing"); err == nil {
+		t.Errorf("loadChartQuietly should fail for a missing file")
+	}
+}
\ No newline at end of file
@@ -1,24 +1,93 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
 	
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 func main() {
+	// Chart tools run without opening a window
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lint":
+			os.Exit(RunLint(os.Args[2:], os.Stdout, os.Stderr))
+		case "stats":
+			os.Exit(RunStats(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+	
+	songPath := flag.String("song", "assets/test.mid", "MIDI file to play")
+	songDir := flag.String("songs", "assets", "directory listed on the song select screen")
+	replayPath := flag.String("replay", "", "replay file to watch")
+	verify := flag.Bool("verify", false, "verify the replay headlessly instead of watching it")
+	autoplay := flag.Bool("bot", false, "let the bot play the song")
+	botSkill := flag.Float64("bot-skill", 1.0, "bot skill from 0 (sloppy) to 1 (perfect)")
+	botCheck := flag.Bool("bot-check", false, "check headlessly that a perfect bot can hit every note")
+	noFail := flag.Bool("no-fail", false, "keep playing when the rock meter runs out")
+	strumMode := flag.Bool("strum", false, "hold lane keys as frets and strum with the up/down arrows")
+	view3D := flag.Bool("3d", false, "draw the playfield as a 3D highway")
+	judgementName := flag.String("judgement", "Standard", "timing profile: Casual, Standard, Strict or a JSON file")
+	flag.Parse()
+	
 	fmt.Println("Guitar Hero Game - Starting...")
 	
+	judgement, err := ResolveJudgementProfile(*judgementName)
+	if err != nil {
+		log.Fatalf("Failed to load judgement profile: %v", err)
+	}
+	
+	// Load the replay first so it can pick the song it was recorded on
+	var replay *Replay
+	if *replayPath != "" {
+		replay, err = LoadReplay(*replayPath)
+		if err != nil {
+			log.Fatalf("Failed to load replay: %v", err)
+		}
+		if replay.SongPath != "" && !isFlagSet("song") {
+			*songPath = replay.SongPath
+		}
+	}
+	
 	// Initialize MIDI processor
 	midiProcessor := NewMIDIProcessor()
 	
-	// Load and analyze the test MIDI file
-	err := midiProcessor.LoadMIDI("assets/test.mid")
+	// Load and analyze the MIDI file
+	err = midiProcessor.LoadMIDI(*songPath)
 	if err != nil {
 		log.Fatalf("Failed to load MIDI file: %v", err)
 	}
 	
+	// Verify replays without opening a window
+	if replay != nil && *verify {
+		result, err := VerifyReplay(replay, midiProcessor)
+		if err != nil {
+			fmt.Printf("Replay verification FAILED: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Replay verified: score %d, max combo %d\n", result.Score, result.MaxCombo)
+		return
+	}
+	
+	// Check the chart is playable without opening a window
+	if *botCheck {
+		problems, result, err := CheckChartWithBot(midiProcessor)
+		if err != nil {
+			log.Fatalf("Failed to check chart: %v", err)
+		}
+		PrintBotCheck(problems, result)
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	
 	// Analyze tracks to find guitar track
 	guitarTrack, err := midiProcessor.FindGuitarTrack()
 	if err != nil {
@@ -27,10 +96,12 @@ func main() {
 	
 	fmt.Printf("Found guitar track with %d notes\n", len(guitarTrack.Notes))
 	
-	// Initialize Raylib
-	rl.InitWindow(SCREEN_WIDTH, SCREEN_HEIGHT, "Guitar Hero Game")
+	// Initialize Raylib with a window that can be resized and scales on high-DPI displays
+	rl.SetConfigFlags(rl.FlagWindowResizable | rl.FlagWindowHighdpi)
+	rl.InitWindow(DESIGN_WIDTH, DESIGN_HEIGHT, "Guitar Hero Game")
 	defer rl.CloseWindow()
 	
+	rl.SetWindowMinSize(MIN_WINDOW_WIDTH, MIN_WINDOW_HEIGHT)
 	rl.SetTargetFPS(60)
 	
 	// Initialize game
@@ -39,6 +110,31 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load MIDI track: %v", err)
 	}
+	game.SetNoFail(*noFail)
+	game.SetStrumMode(*strumMode)
+	game.SetJudgement(judgement)
+	if replay != nil {
+		game.SetReplay(replay)
+	}
+	if *autoplay {
+		game.SetAutoplay(NewBot(*botSkill, time.Now().UnixNano()))
+	}
+	
+	// Find songs for the song select screen
+	songs, err := ScanSongs(*songDir)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if len(songs) == 0 {
+		// Fall back to the song loaded above
+		songs = []SongEntry{{
+			Name: filepath.Base(*songPath),
+			Path: *songPath,
+			Hash: midiProcessor.SongHash(),
+		}}
+		songs[0].Rating = RateSong(*songPath)
+	}
+	game.SetSongs(songs, *songPath)
 	
 	// Ensure audio cleanup on exit
 	defer func() {
@@ -49,24 +145,169 @@ func main() {
 	
 	// Initialize renderer
 	renderer := NewRenderer(game)
+	defer renderer.Unload()
+	if *view3D {
+		renderer.SetView(View3D)
+	}
 	
 	fmt.Println("Guitar Hero Game - Ready to start!")
 	
 	// Main game loop
 	for !rl.WindowShouldClose() {
+		// Read the controller once per frame, for menus and play
+		if game.gamepad != nil {
+			game.gamepad.Update()
+		}
+		
 		// Handle input based on game state
-		if rl.IsKeyPressed(rl.KeySpace) {
+		if game.MenuPressed(ControlMenuConfirm) {
 			switch game.state {
 			case StateMenu:
-				game.StartGame()
+				if game.IsReplay() {
+					// Replays always play the song they were recorded on
+					game.StartGame()
+				} else {
+					game.state = StateSongSelect
+				}
+			case StateSongSelect:
+				if err := game.LoadSelectedSong(); err != nil {
+					fmt.Printf("Failed to load song: %v\n", err)
+				} else {
+					game.StartGame()
+				}
 			case StatePlaying:
 				// Pause functionality removed for simplicity
 				// You can add pause state if needed
-			case StateGameOver:
+			case StateGameOver, StateFailed:
 				game.state = StateMenu // Return to menu for restart
+			case StateModifiers:
+				game.ChangeSelectedModifier(1)
+			case StatePracticeMenu:
+				game.StartPractice()
+			case StateEditor:
+				game.Editor().TogglePlayback()
 			}
 		}
 		
+		// Song select navigation
+		if game.state == StateSongSelect {
+			if game.MenuPressed(ControlMenuUp) {
+				game.MoveSongSelection(-1)
+			}
+			if game.MenuPressed(ControlMenuDown) {
+				game.MoveSongSelection(1)
+			}
+			if game.MenuPressed(ControlMenuLeft) {
+				game.CycleDifficulty(-1)
+			}
+			if game.MenuPressed(ControlMenuRight) {
+				game.CycleDifficulty(1)
+			}
+			if rl.IsKeyPressed(rl.KeyN) {
+				game.SetNoFail(!game.NoFail())
+			}
+			if rl.IsKeyPressed(rl.KeyS) {
+				game.SetStrumMode(!game.StrumMode())
+			}
+			if rl.IsKeyPressed(rl.KeyJ) {
+				game.CycleJudgement()
+			}
+			if rl.IsKeyPressed(rl.KeyV) {
+				if renderer.View() == View3D {
+					renderer.SetView(ViewFlat)
+				} else {
+					renderer.SetView(View3D)
+				}
+			}
+			if rl.IsKeyPressed(rl.KeyM) {
+				game.state = StateModifiers
+			} else if rl.IsKeyPressed(rl.KeyP) {
+				// Practice needs the song loaded to list its sections
+				if err := game.LoadSelectedSong(); err != nil {
+					fmt.Printf("Failed to load song: %v\n", err)
+				} else {
+					game.state = StatePracticeMenu
+				}
+			} else if rl.IsKeyPressed(rl.KeyE) {
+				if err := game.OpenEditor(); err != nil {
+					fmt.Printf("Failed to open editor: %v\n", err)
+				}
+			} else if game.MenuPressed(ControlMenuBack) {
+				game.state = StateMenu
+			}
+		} else if game.state == StateModifiers {
+			// Modifiers menu, opened from song select
+			if game.MenuPressed(ControlMenuUp) {
+				game.MoveModifierSelection(-1)
+			}
+			if game.MenuPressed(ControlMenuDown) {
+				game.MoveModifierSelection(1)
+			}
+			if game.MenuPressed(ControlMenuLeft) {
+				game.ChangeSelectedModifier(-1)
+			}
+			if game.MenuPressed(ControlMenuRight) {
+				game.ChangeSelectedModifier(1)
+			}
+			if game.MenuPressed(ControlMenuBack) {
+				game.state = StateSongSelect
+			}
+		} else if game.state == StatePracticeMenu {
+			if game.MenuPressed(ControlMenuUp) {
+				game.MovePracticeSelection(-1)
+			}
+			if game.MenuPressed(ControlMenuDown) {
+				game.MovePracticeSelection(1)
+			}
+			if game.MenuPressed(ControlMenuLeft) {
+				game.ChangePracticeOption(-1)
+			}
+			if game.MenuPressed(ControlMenuRight) {
+				game.ChangePracticeOption(1)
+			}
+			if game.MenuPressed(ControlMenuBack) {
+				game.state = StateSongSelect
+			}
+		} else if game.state == StateEditor {
+			updateEditorInput(game)
+		}
+		
+		// Scroll speed can be changed while playing, practice can be left for its menu
+		if game.state == StatePlaying {
+			if game.IsPracticing() && game.MenuPressed(ControlMenuBack) {
+				game.StopPractice()
+			}
+			if rl.IsKeyPressed(rl.KeyMinus) {
+				game.AdjustScrollSpeed(-1)
+			}
+			if rl.IsKeyPressed(rl.KeyEqual) {
+				game.AdjustScrollSpeed(1)
+			}
+		}
+		
+		// Controller mapping screen, opened from the menu
+		if game.state == StateControllerMap {
+			if game.mapper.Update(game.MenuPressed) {
+				game.state = StateMenu
+			}
+		} else if rl.IsKeyPressed(rl.KeyC) && game.state == StateMenu && game.mapper != nil {
+			game.state = StateControllerMap
+		}
+		
+		// Toggle autoplay from the menu
+		if rl.IsKeyPressed(rl.KeyB) && game.state == StateMenu {
+			if game.bot != nil {
+				game.SetAutoplay(nil)
+			} else {
+				game.SetAutoplay(NewBot(*botSkill, time.Now().UnixNano()))
+			}
+		}
+		
+		// Fullscreen in any state
+		if rl.IsKeyPressed(rl.KeyF11) {
+			rl.ToggleBorderlessWindowed()
+		}
+		
 		if rl.IsKeyPressed(rl.KeyEscape) {
 			break
 		}
@@ -80,4 +321,97 @@ func main() {
 	}
 	
 	fmt.Println("Guitar Hero Game - Goodbye!")
+}
+
+// isFlagSet returns whether a command line flag was passed explicitly
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// updateEditorInput edits the chart from the mouse and keyboard
+func updateEditorInput(game *Game) {
+	editor := game.Editor()
+	layout := NewLayout(int32(rl.GetScreenWidth()), int32(rl.GetScreenHeight()))
+	mouse := rl.GetMousePosition()
+	lane, t, onLane := editor.PointAt(layout, mouse.X, mouse.Y)
+	ctrl := rl.IsKeyDown(rl.KeyLeftControl) || rl.IsKeyDown(rl.KeyRightControl)
+	shift := rl.IsKeyDown(rl.KeyLeftShift) || rl.IsKeyDown(rl.KeyRightShift)
+	
+	// Click to place or select, drag to stretch the sustain
+	if rl.IsMouseButtonPressed(rl.MouseButtonLeft) && onLane {
+		editor.Click(lane, t)
+	} else if rl.IsMouseButtonDown(rl.MouseButtonLeft) {
+		editor.Drag(editor.TimeAt(layout, mouse.Y))
+	} else if rl.IsMouseButtonReleased(rl.MouseButtonLeft) {
+		editor.Release()
+	}
+	if rl.IsMouseButtonPressed(rl.MouseButtonRight) && onLane {
+		editor.DeleteAt(lane, t)
+	}
+	
+	// Scrub with the wheel or arrows, shift stretches the selected sustain instead
+	if wheel := rl.GetMouseWheelMove(); wheel != 0 {
+		editor.Scrub(int(math.Copysign(1, float64(wheel))))
+	}
+	step := 0
+	if game.MenuPressed(ControlMenuUp) {
+		step = 1
+	}
+	if game.MenuPressed(ControlMenuDown) {
+		step = -1
+	}
+	if step != 0 && shift {
+		editor.AdjustSustain(step)
+	} else if step != 0 {
+		editor.Scrub(step)
+	}
+	if game.MenuPressed(ControlMenuLeft) {
+		editor.ChangeSnap(-1)
+	}
+	if game.MenuPressed(ControlMenuRight) {
+		editor.ChangeSnap(1)
+	}
+	if rl.IsKeyPressed(rl.KeyMinus) {
+		editor.Zoom(-1)
+	}
+	if rl.IsKeyPressed(rl.KeyEqual) {
+		editor.Zoom(1)
+	}
+	
+	for i, key := range []int32{rl.KeyOne, rl.KeyTwo, rl.KeyThree} {
+		if rl.IsKeyPressed(key) {
+			editor.ToggleNoteAtCursor(i)
+		}
+	}
+	if rl.IsKeyPressed(rl.KeyDelete) {
+		editor.DeleteSelected()
+	}
+	if rl.IsKeyPressed(rl.KeyH) {
+		editor.ToggleSelectedType(NoteHOPO)
+	}
+	if rl.IsKeyPressed(rl.KeyT) {
+		editor.ToggleSelectedType(NoteTap)
+	}
+	
+	if ctrl && rl.IsKeyPressed(rl.KeyZ) {
+		editor.Undo()
+	}
+	if ctrl && rl.IsKeyPressed(rl.KeyY) {
+		editor.Redo()
+	}
+	if ctrl && rl.IsKeyPressed(rl.KeyS) {
+		if err := editor.Save(); err != nil {
+			fmt.Printf("Failed to save chart: %v\n", err)
+		}
+	}
+	
+	if game.MenuPressed(ControlMenuBack) && editor.RequestClose() {
+		game.CloseEditor()
+	}
 }
\ No newline at end of file
@@ -1,16 +1,32 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 )
 
+// MIDI processor constants
+const (
+	GUITAR_MIN_PITCH = 40 // Lowest chart pitch kept as a guitar note
+	GUITAR_MAX_PITCH = 84 // Highest chart pitch kept as a guitar note
+)
+
 // MIDIProcessor handles MIDI file parsing and guitar track extraction
 type MIDIProcessor struct {
 	filePath    string
 	tracks      []MIDITrack
 	guitarTrack *MIDITrack
+	beats       []Beat    // Tempo map from the file, nil when the file has none
+	tempoMap    *TempoMap // Tick resolution and tempo changes, nil without a file
+	output      io.Writer // Where loading progress is printed
+	
+	// Problems found while reading the file
+	dropped  []MIDINote     // Notes outside the guitar pitch range
+	dangling []danglingNote // Note ons and offs without their pair
 }
 
 // MIDITrack represents a single track from a MIDI file
@@ -20,6 +36,31 @@ type MIDITrack struct {
 	Instrument  int
 	Notes       []MIDINote
 	IsGuitar    bool
+	
+	// Star Power phrases read from the chart's marker notes
+	StarPowerPhrases []Phrase
+	
+	// Note type sections read from the chart's marker notes
+	ForcedHOPOs  []Phrase
+	ForcedStrums []Phrase
+	TapPhrases   []Phrase
+	
+	// Named parts of the song read from text and marker events, or split on silence
+	Sections     []Section
+	AutoSections bool // Sections were split on silence rather than read from the chart
+}
+
+// Phrase is a span of song time marked in the chart
+type Phrase struct {
+	StartTime float64
+	EndTime   float64
+}
+
+// Section is a named part of the song, like a verse or a solo
+type Section struct {
+	Name      string
+	StartTime float64
+	EndTime   float64
 }
 
 // MIDINote represents a single note event
@@ -35,9 +76,30 @@ type MIDINote struct {
 func NewMIDIProcessor() *MIDIProcessor {
 	return &MIDIProcessor{
 		tracks: make([]MIDITrack, 0),
+		output: os.Stdout,
 	}
 }
 
+// SetOutput changes where loading progress is printed, io.Discard to load quietly
+func (mp *MIDIProcessor) SetOutput(output io.Writer) {
+	mp.output = output
+}
+
+// NewMIDIProcessorFromNotes creates a processor holding a single guitar track
+// with the given notes, without reading a file
+func NewMIDIProcessorFromNotes(notes []MIDINote) *MIDIProcessor {
+	mp := NewMIDIProcessor()
+	mp.tracks = []MIDITrack{{
+		Name:       "Guitar",
+		Instrument: 25, // Clean Guitar
+		IsGuitar:   true,
+		Notes:        notes,
+		Sections:     SilenceSections(notes),
+		AutoSections: true,
+	}}
+	return mp
+}
+
 // LoadMIDI loads and parses a MIDI file
 func (mp *MIDIProcessor) LoadMIDI(filePath string) error {
 	mp.filePath = filePath
@@ -54,12 +116,12 @@ func (mp *MIDIProcessor) LoadMIDI(filePath string) error {
 	}
 	
 	mp.filePath = absPath
-	fmt.Printf("Loading MIDI file: %s\n", mp.filePath)
+	fmt.Fprintf(mp.output, "Loading MIDI file: %s\n", mp.filePath)
 	
 	// Parse the actual MIDI file
 	err = mp.parseMIDIFile()
 	if err != nil {
-		fmt.Printf("Failed to parse MIDI file, using test data: %v\n", err)
+		fmt.Fprintf(mp.output, "Failed to parse MIDI file, using test data: %v\n", err)
 		mp.createTestData()
 	}
 	
@@ -68,16 +130,22 @@ func (mp *MIDIProcessor) LoadMIDI(filePath string) error {
 
 // parseMIDIFile parses the actual MIDI file using our simple parser
 func (mp *MIDIProcessor) parseMIDIFile() error {
-	fmt.Printf("Parsing MIDI file: %s\n", mp.filePath)
+	fmt.Fprintf(mp.output, "Parsing MIDI file: %s\n", mp.filePath)
 	
 	// Use our simple MIDI parser
 	parser := NewSimpleMIDIParser()
+	parser.output = mp.output
 	allNotes, err := parser.ParseFile(mp.filePath)
 	if err != nil {
 		return fmt.Errorf("failed to parse MIDI file: %v", err)
 	}
 	
-	fmt.Printf("Total notes extracted: %d\n", len(allNotes))
+	fmt.Fprintf(mp.output, "Total notes extracted: %d\n", len(allNotes))
+	mp.beats = parser.BeatMap()
+	tempoMap := parser.TempoMap()
+	mp.tempoMap = &tempoMap
+	mp.dangling = parser.Dangling()
+	mp.dropped = nil
 	
 	// Filter notes to create guitar track
 	// For now, we'll use all notes and assume they're guitar notes
@@ -87,27 +155,62 @@ func (mp *MIDIProcessor) parseMIDIFile() error {
 	// - Note patterns
 	
 	guitarNotes := make([]MIDINote, 0)
+	track := MIDITrack{
+		Name:       "Guitar",
+		Channel:    0,
+		Instrument: 25, // Clean Guitar
+		IsGuitar:   true,
+		Sections:   parser.Sections(),
+	}
+	
+	// Keep the file's own track name and instrument so the chart saves as it was read
+	if info, ok := parser.NoteTrack(); ok {
+		if info.Name != "" {
+			track.Name = info.Name
+		}
+		track.Channel = info.Channel
+		if info.Program >= 0 {
+			track.Instrument = info.Program
+		}
+	}
+	markers := map[int]*[]Phrase{
+		STAR_POWER_MARKER_PITCH: &track.StarPowerPhrases,
+		HOPO_MARKER_PITCH:       &track.ForcedHOPOs,
+		STRUM_MARKER_PITCH:      &track.ForcedStrums,
+		TAP_MARKER_PITCH:        &track.TapPhrases,
+	}
 	for _, note := range allNotes {
+		// Marker notes span a phrase rather than being played
+		if phrases, ok := markers[note.Pitch]; ok {
+			*phrases = append(*phrases, Phrase{
+				StartTime: note.StartTime,
+				EndTime:   note.StartTime + note.Duration,
+			})
+			continue
+		}
+		
 		// Filter out very low or very high notes that don't make sense for guitar
-		if note.Pitch >= 40 && note.Pitch <= 84 { // Roughly guitar range
+		if note.Pitch >= GUITAR_MIN_PITCH && note.Pitch <= GUITAR_MAX_PITCH { // Roughly guitar range
 			guitarNotes = append(guitarNotes, note)
+		} else {
+			mp.dropped = append(mp.dropped, note)
 		}
 	}
 	
-	fmt.Printf("Guitar notes after filtering: %d\n", len(guitarNotes))
+	fmt.Fprintf(mp.output, "Guitar notes after filtering: %d\n", len(guitarNotes))
 	
 	// Create a single guitar track with all the notes
-	track := MIDITrack{
-		Name:       "Guitar",
-		Channel:    0,
-		Instrument: 25, // Clean Guitar
-		IsGuitar:   true,
-		Notes:      guitarNotes,
+	track.Notes = guitarNotes
+	
+	// Songs without section markers are split where the guitar rests
+	if len(track.Sections) == 0 {
+		track.Sections = SilenceSections(guitarNotes)
+		track.AutoSections = true
 	}
 	
 	mp.tracks = []MIDITrack{track}
 	
-	fmt.Printf("Created guitar track with %d notes\n", len(track.Notes))
+	fmt.Fprintf(mp.output, "Created guitar track with %d notes\n", len(track.Notes))
 	return nil
 }
 
@@ -132,6 +235,63 @@ func (mp *MIDIProcessor) createTestData() {
 	mp.guitarTrack = &mp.tracks[0]
 }
 
+// SongHash returns a hash identifying the loaded song's content
+func (mp *MIDIProcessor) SongHash() string {
+	if mp.filePath != "" {
+		if fileHash, err := hashFile(mp.filePath); err == nil {
+			return fileHash
+		}
+	}
+	
+	// No file on disk, hash the notes themselves
+	hash := sha256.New()
+	for _, track := range mp.tracks {
+		for _, note := range track.Notes {
+			fmt.Fprintf(hash, "%d %d %.6f %.6f\n", note.Pitch, note.Velocity, note.StartTime, note.Duration)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// BeatMap returns the beats of the loaded file in song time, nil if it has none
+func (mp *MIDIProcessor) BeatMap() []Beat {
+	return mp.beats
+}
+
+// TempoMap returns the tick resolution and tempo changes of the loaded file,
+// or the default tempo map when there is no file
+func (mp *MIDIProcessor) TempoMap() TempoMap {
+	if mp.tempoMap == nil {
+		return DefaultTempoMap()
+	}
+	return *mp.tempoMap
+}
+
+// SaveMIDI writes the loaded tracks to a MIDI file with the song's tempo map
+func (mp *MIDIProcessor) SaveMIDI(path string) error {
+	if len(mp.tracks) == 0 {
+		return fmt.Errorf("no tracks loaded")
+	}
+	return NewMIDIWriter(mp.TempoMap()).WriteFile(path, mp.tracks)
+}
+
+// Parsed returns whether the tracks were read from the file, rather than being
+// the test data loaded when it could not be parsed
+func (mp *MIDIProcessor) Parsed() bool {
+	return mp.tempoMap != nil
+}
+
+// DroppedNotes returns the notes of the file left out of the guitar track for
+// being outside the guitar pitch range
+func (mp *MIDIProcessor) DroppedNotes() []MIDINote {
+	return mp.dropped
+}
+
+// FilePath returns the path of the loaded MIDI file
+func (mp *MIDIProcessor) FilePath() string {
+	return mp.filePath
+}
+
 // FindGuitarTrack identifies and returns the guitar track from the MIDI file
 func (mp *MIDIProcessor) FindGuitarTrack() (*MIDITrack, error) {
 	if len(mp.tracks) == 0 {
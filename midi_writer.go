@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// MIDI writer constants
+const (
+	MARKER_VELOCITY = 100 // Velocity of the marker notes written over phrases
+)
+
+// MIDIWriter serializes tracks to a format 1 Standard MIDI File at the tick
+// resolution and tempo map they were read with
+type MIDIWriter struct {
+	tempoMap TempoMap
+}
+
+// writerEvent is an event at a tick, ready to be written
+type writerEvent struct {
+	Tick int
+	Rank int // Order among events at the same tick
+	Data []byte
+}
+
+// NewMIDIWriter creates a writer that places notes with a tempo map
+func NewMIDIWriter(tempoMap TempoMap) *MIDIWriter {
+	if tempoMap.TicksPerBeat <= 0 {
+		tempoMap.TicksPerBeat = DEFAULT_TICKS_PER_BEAT
+	}
+	if len(tempoMap.Tempos) == 0 {
+		tempoMap.Tempos = DefaultTempoMap().Tempos
+	}
+	return &MIDIWriter{tempoMap: tempoMap}
+}
+
+// WriteFile writes the tracks to a MIDI file
+func (w *MIDIWriter) WriteFile(path string, tracks []MIDITrack) error {
+	if err := os.WriteFile(path, w.Encode(tracks), 0644); err != nil {
+		return fmt.Errorf("failed to write MIDI file: %v", err)
+	}
+	return nil
+}
+
+// Encode returns the tracks as a MIDI file. The first track holds the tempo map
+// and section markers and lasts until the tempo map's end, each track after it
+// holds one MIDITrack's notes with a marker note over each of its phrases.
+func (w *MIDIWriter) Encode(tracks []MIDITrack) []byte {
+	data := []byte("MThd")
+	data = binary.BigEndian.AppendUint32(data, 6)
+	data = binary.BigEndian.AppendUint16(data, 1)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(tracks)+1))
+	data = binary.BigEndian.AppendUint16(data, uint16(w.tempoMap.TicksPerBeat))
+	
+	data = appendTrackChunk(data, w.conductorEvents(tracks))
+	for _, track := range tracks {
+		data = appendTrackChunk(data, w.trackEvents(track))
+	}
+	return data
+}
+
+// conductorEvents returns the time signatures, tempo changes and section markers
+func (w *MIDIWriter) conductorEvents(tracks []MIDITrack) []writerEvent {
+	events := make([]writerEvent, 0)
+	for _, signature := range w.tempoMap.TimeSignatures {
+		power := byte(math.Round(math.Log2(float64(signature.Denominator))))
+		events = append(events, writerEvent{Tick: signature.Tick,
+			Data: []byte{0xFF, 0x58, 0x04, byte(signature.Numerator), power, 24, 8}})
+	}
+	for _, change := range w.tempoMap.Tempos {
+		tempo := change.MicrosPerBeat
+		events = append(events, writerEvent{Tick: change.Tick,
+			Data: []byte{0xFF, 0x51, 0x03, byte(tempo >> 16), byte(tempo >> 8), byte(tempo)}})
+	}
+	
+	// Sections are shared by the song, the first track read with markers has them
+	for _, track := range tracks {
+		if len(track.Sections) == 0 || track.AutoSections {
+			continue
+		}
+		for _, section := range track.Sections {
+			events = append(events, writerEvent{Tick: w.tempoMap.SecondsToTicks(section.StartTime),
+				Data: metaEvent(0x06, section.Name)})
+		}
+		break
+	}
+	
+	// An empty text event keeps the song as long as the file it was read from
+	if w.tempoMap.EndTick > 0 {
+		events = append(events, writerEvent{Tick: w.tempoMap.EndTick, Rank: 1, Data: metaEvent(0x01, "")})
+	}
+	
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Tick != events[j].Tick {
+			return events[i].Tick < events[j].Tick
+		}
+		return events[i].Rank < events[j].Rank
+	})
+	return events
+}
+
+// trackEvents returns a track's name, program change, notes and marker notes
+func (w *MIDIWriter) trackEvents(track MIDITrack) []writerEvent {
+	channel := byte(track.Channel & 0x0F)
+	events := make([]writerEvent, 0)
+	
+	// Note offs are written as note ons without velocity so every note event
+	// shares the running status. They sort before note ons at the same tick so
+	// back to back notes stay apart, except the offs of notes without a length.
+	addNote := func(pitch, velocity int, start, end float64) {
+		startTick := w.tempoMap.SecondsToTicks(start)
+		endTick := max(w.tempoMap.SecondsToTicks(end), startTick)
+		offRank := 0
+		if endTick == startTick {
+			offRank = 2
+		}
+		events = append(events,
+			writerEvent{Tick: startTick, Rank: 1, Data: []byte{0x90 | channel, byte(pitch), byte(max(velocity, 1))}},
+			writerEvent{Tick: endTick, Rank: offRank, Data: []byte{0x90 | channel, byte(pitch), 0}})
+	}
+	for _, note := range track.Notes {
+		addNote(note.Pitch, note.Velocity, note.StartTime, note.StartTime+note.Duration)
+	}
+	markers := []struct {
+		pitch   int
+		phrases []Phrase
+	}{
+		{STAR_POWER_MARKER_PITCH, track.StarPowerPhrases},
+		{HOPO_MARKER_PITCH, track.ForcedHOPOs},
+		{STRUM_MARKER_PITCH, track.ForcedStrums},
+		{TAP_MARKER_PITCH, track.TapPhrases},
+	}
+	for _, marker := range markers {
+		for _, phrase := range marker.phrases {
+			addNote(marker.pitch, MARKER_VELOCITY, phrase.StartTime, phrase.EndTime)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Tick != events[j].Tick {
+			return events[i].Tick < events[j].Tick
+		}
+		return events[i].Rank < events[j].Rank
+	})
+	
+	header := make([]writerEvent, 0, 2)
+	if track.Name != "" {
+		header = append(header, writerEvent{Data: metaEvent(0x03, track.Name)})
+	}
+	if track.Instrument >= 0 && track.Instrument <= 127 {
+		header = append(header, writerEvent{Data: []byte{0xC0 | channel, byte(track.Instrument)}})
+	}
+	return append(header, events...)
+}
+
+// metaEvent encodes a text-like meta event
+func metaEvent(metaType byte, text string) []byte {
+	data := append([]byte{0xFF, metaType}, encodeVariableLength(len(text))...)
+	return append(data, text...)
+}
+
+// appendTrackChunk appends an MTrk chunk holding events sorted by tick. A channel
+// event repeating the last channel status leaves its status byte out.
+func appendTrackChunk(data []byte, events []writerEvent) []byte {
+	body := make([]byte, 0)
+	tick := 0
+	runningStatus := byte(0)
+	for _, event := range events {
+		body = append(body, encodeVariableLength(event.Tick-tick)...)
+		tick = event.Tick
+		
+		status := event.Data[0]
+		switch {
+		case status >= 0xF0:
+			// Meta and system events cancel the running status
+			runningStatus = 0
+			body = append(body, event.Data...)
+		case status == runningStatus:
+			body = append(body, event.Data[1:]...)
+		default:
+			runningStatus = status
+			body = append(body, event.Data...)
+		}
+	}
+	body = append(body, 0x00, 0xFF, 0x2F, 0x00)
+	
+	data = append(data, "MTrk"...)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(body)))
+	return append(data, body...)
+}
+
+// encodeVariableLength encodes a MIDI variable-length quantity
+func encodeVariableLength(value int) []byte {
+	encoded := []byte{byte(value & 0x7F)}
+	for value >>= 7; value > 0; value >>= 7 {
+		encoded = append([]byte{byte(value&0x7F) | 0x80}, encoded...)
+	}
+	return encoded
+}
\ No newline at end of file
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEncodeVariableLength(t *testing.T) {
+	tests := []struct {
+		value   int
+		encoded []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0x81, 0x00}},
+		{0x2000, []byte{0xC0, 0x00}},
+		{0x3FFF, []byte{0xFF, 0x7F}},
+		{0x4000, []byte{0x81, 0x80, 0x00}},
+		{0x0FFFFFFF, []byte{0xFF, 0xFF, 0xFF, 0x7F}},
+	}
+	
+	for _, tt := range tests {
+		encoded := encodeVariableLength(tt.value)
+		if !bytes.Equal(encoded, tt.encoded) {
+			t.Errorf("encodeVariableLength(%#x) = % x, want % x", tt.value, encoded, tt.encoded)
+		}
+		parser := &SimpleMIDIParser{data: encoded}
+		if value, err := parser.readVariableLength(); err != nil || value != tt.value {
+			t.Errorf("reading % x back = %#x, %v, want %#x", encoded, value, err, tt.value)
+		}
+	}
+}
+
+func TestMIDIWriterUsesRunningStatus(t *testing.T) {
+	track := MIDITrack{
+		Name:       "Guitar",
+		Channel:    2,
+		Instrument: 29,
+		Notes: []MIDINote{
+			{Pitch: 52, Velocity: 100, StartTime: 0, Duration: 0.25},
+			{Pitch: 64, Velocity: 100, StartTime: 0.5, Duration: 0.25},
+			{Pitch: 76, Velocity: 100, StartTime: 1, Duration: 0.25},
+		},
+	}
+	
+	data := NewMIDIWriter(DefaultTempoMap()).Encode([]MIDITrack{track})
+	noteTrack := data[bytes.LastIndex(data, []byte("MTrk")):]
+	if count := bytes.Count(noteTrack, []byte{0x92}); count != 1 {
+		t.Errorf("note track has %d note on status bytes, want one shared by all six note events", count)
+	}
+	if !bytes.Contains(noteTrack, []byte{0xC2, 29}) {
+		t.Errorf("note track has no program change to 29 on channel 2")
+	}
+	if !bytes.Contains(noteTrack, []byte{0xFF, 0x03, 6, 'G', 'u', 'i', 't', 'a', 'r'}) {
+		t.Errorf("note track has no track name")
+	}
+}
+
+func TestMIDIWriterRoundTrip(t *testing.T) {
+	text := func(delta int, metaType byte, text string) []byte {
+		return midiEvent(delta, append([]byte{0xFF, metaType, byte(len(text))}, text...)...)
+	}
+	// 96 ticks per beat, 120 BPM in 4/4 then 90 BPM in 3/4 from bar 2
+	tempoTrack := midiTrackChunk(
+		midiEvent(0, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20),
+		text(0, 0x01, "[section intro]"),
+		midiEvent(384, 0xFF, 0x51, 0x03, 0x0A, 0x2C, 0x2A),
+		midiEvent(0, 0xFF, 0x58, 0x04, 0x03, 0x02, 24, 8),
+		text(0, 0x06, "Verse 1"),
+		text(576, 0x01, ""),
+	)
+	noteTrack := midiTrackChunk(
+		text(0, 0x03, "PART GUITAR"),
+		midiEvent(0, 0xC1, 30),
+		midiEvent(0, 0x91, STAR_POWER_MARKER_PITCH, 100),
+		midiEvent(0, 64, 90), // Running status
+		midiEvent(0, 76, 80), // Chord
+		midiEvent(48, 0x81, 64, 0),
+		midiEvent(0, 76, 0),
+		midiEvent(48, 0x91, HOPO_MARKER_PITCH, 100),
+		midiEvent(0, 52, 110),
+		midiEvent(1, HOPO_MARKER_PITCH, 0),
+		midiEvent(95, 52, 0),
+		midiEvent(200, 0x81, STAR_POWER_MARKER_PITCH, 0),
+		midiEvent(100, 0x91, 70, 100),
+		midiEvent(0, 70, 0), // No length
+		midiEvent(50, 0x91, 60, 100),
+		midiEvent(250, 0x81, 60, 0),
+	)
+	
+	original := NewMIDIProcessor()
+	if err := original.LoadMIDI(writeMIDIFile(t, 96, tempoTrack, noteTrack)); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	if _, err := original.FindGuitarTrack(); err != nil {
+		t.Fatalf("FindGuitarTrack failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "saved.mid")
+	if err := original.SaveMIDI(path); err != nil {
+		t.Fatalf("SaveMIDI failed: %v", err)
+	}
+	saved := NewMIDIProcessor()
+	if err := saved.LoadMIDI(path); err != nil {
+		t.Fatalf("LoadMIDI of the saved file failed: %v", err)
+	}
+	if _, err := saved.FindGuitarTrack(); err != nil {
+		t.Fatalf("FindGuitarTrack of the saved file failed: %v", err)
+	}
+	
+	if !reflect.DeepEqual(saved.TempoMap(), original.TempoMap()) {
+		t.Errorf("TempoMap() = %+v, want %+v", saved.TempoMap(), original.TempoMap())
+	}
+	if len(saved.BeatMap()) != len(original.BeatMap()) {
+		t.Errorf("beat map has %d beats, want %d", len(saved.BeatMap()), len(original.BeatMap()))
+	}
+	
+	want, got := original.tracks[0], saved.tracks[0]
+	if got.Name != "PART GUITAR" || got.Channel != 1 || got.Instrument != 30 {
+		t.Errorf("track = %q on channel %d playing %d, want PART GUITAR on channel 1 playing 30",
+			got.Name, got.Channel, got.Instrument)
+	}
+	if len(want.Notes) != 5 {
+		t.Fatalf("original has %d notes, want 5", len(want.Notes))
+	}
+	if len(got.Notes) != len(want.Notes) {
+		t.Fatalf("saved file has %d notes, want %d", len(got.Notes), len(want.Notes))
+	}
+	// Notes are read in the order they end, which may differ between the files
+	sortByStart := func(notes []MIDINote) []MIDINote {
+		sorted := append([]MIDINote(nil), notes...)
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].StartTime != sorted[j].StartTime {
+				return sorted[i].StartTime < sorted[j].StartTime
+			}
+			return sorted[i].Pitch < sorted[j].Pitch
+		})
+		return sorted
+	}
+	wantNotes, gotNotes := sortByStart(want.Notes), sortByStart(got.Notes)
+	for i := range wantNotes {
+		w, g := wantNotes[i], gotNotes[i]
+		if g.Pitch != w.Pitch || g.Velocity != w.Velocity || g.Lane != w.Lane ||
+			math.Abs(g.StartTime-w.StartTime) > 1e-9 || math.Abs(g.Duration-w.Duration) > 1e-9 {
+			t.Errorf("note %d = %+v, want %+v", i, g, w)
+		}
+	}
+	
+	phrases := []struct {
+		name      string
+		got, want []Phrase
+	}{
+		{"StarPowerPhrases", got.StarPowerPhrases, want.StarPowerPhrases},
+		{"ForcedHOPOs", got.ForcedHOPOs, want.ForcedHOPOs},
+	}
+	for _, p := range phrases {
+		if len(p.want) != 1 || !reflect.DeepEqual(p.got, p.want) {
+			t.Errorf("%s = %+v, want %+v", p.name, p.got, p.want)
+		}
+	}
+	if !reflect.DeepEqual(got.Sections, want.Sections) || len(want.Sections) != 2 || got.AutoSections {
+		t.Errorf("Sections = %+v, want %+v", got.Sections, want.Sections)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Modifier constants
+const (
+	SCROLL_SPEED_MIN  = 0.5
+	SCROLL_SPEED_MAX  = 4.0
+	SCROLL_SPEED_STEP = 0.25
+	
+	// Seconds a note is visible above the hit line at 1x in constant time mode,
+	// the same as the flat playfield at its design size
+	BASE_VISIBLE_TIME = (DESIGN_HEIGHT - HIT_LINE_MARGIN) / NOTE_SPEED
+	
+	// Where hidden and sudden fade notes, as fractions of the visible highway above the hit line
+	HIDDEN_FADE_START = 0.5  // Hidden notes start fading here
+	HIDDEN_FADE_END   = 0.25 // and are gone from here to the hit line
+	SUDDEN_FADE_START = 0.75 // Sudden notes appear here
+	SUDDEN_FADE_END   = 0.6  // and are fully visible from here
+)
+
+// Modifiers change how notes scroll and which lanes they arrive in
+type Modifiers struct {
+	ScrollSpeed  float64 `json:"scroll_speed,omitempty"`  // Scroll speed multiplier, 0 means 1x
+	ConstantTime bool    `json:"constant_time,omitempty"` // Notes are visible for the same time at any window size
+	Hidden       bool    `json:"hidden,omitempty"`        // Notes fade out before the hit line
+	Sudden       bool    `json:"sudden,omitempty"`        // Notes appear late
+	Mirror       bool    `json:"mirror,omitempty"`        // Lanes are flipped left to right
+	Shuffle      bool    `json:"shuffle,omitempty"`       // Lanes are swapped around every run
+	ShuffleSeed  int64   `json:"shuffle_seed,omitempty"`  // Picks the shuffled lane order
+}
+
+// ModifierOption is a row on the modifiers menu
+type ModifierOption int
+
+const (
+	OptionScrollSpeed ModifierOption = iota
+	OptionConstantTime
+	OptionHidden
+	OptionSudden
+	OptionMirror
+	OptionShuffle
+	modifierOptionCount
+)
+
+// String returns the option's display name
+func (o ModifierOption) String() string {
+	switch o {
+	case OptionScrollSpeed:
+		return "Scroll speed"
+	case OptionConstantTime:
+		return "Constant time visible"
+	case OptionHidden:
+		return "Hidden"
+	case OptionSudden:
+		return "Sudden"
+	case OptionMirror:
+		return "Mirror lanes"
+	case OptionShuffle:
+		return "Shuffle lanes"
+	default:
+		return "Unknown"
+	}
+}
+
+// Speed returns the scroll speed multiplier
+func (m Modifiers) Speed() float64 {
+	if m.ScrollSpeed <= 0 {
+		return 1
+	}
+	return m.ScrollSpeed
+}
+
+// VisibleTime returns how many seconds ahead notes are shown in constant time mode
+func (m Modifiers) VisibleTime() float64 {
+	return BASE_VISIBLE_TIME / m.Speed()
+}
+
+// AdjustSpeed changes the scroll speed by steps, keeping it in range
+func (m *Modifiers) AdjustSpeed(steps int) {
+	speed := m.Speed() + float64(steps)*SCROLL_SPEED_STEP
+	m.ScrollSpeed = min(max(speed, SCROLL_SPEED_MIN), SCROLL_SPEED_MAX)
+}
+
+// Change steps a numeric option or flips a toggle
+func (m *Modifiers) Change(option ModifierOption, step int) {
+	switch option {
+	case OptionScrollSpeed:
+		m.AdjustSpeed(step)
+	case OptionConstantTime:
+		m.ConstantTime = !m.ConstantTime
+	case OptionHidden:
+		m.Hidden = !m.Hidden
+	case OptionSudden:
+		m.Sudden = !m.Sudden
+	case OptionMirror:
+		m.Mirror = !m.Mirror
+	case OptionShuffle:
+		m.Shuffle = !m.Shuffle
+	}
+}
+
+// Value returns an option's setting for display
+func (m Modifiers) Value(option ModifierOption) string {
+	onOff := func(on bool) string {
+		if on {
+			return "ON"
+		}
+		return "OFF"
+	}
+	
+	switch option {
+	case OptionScrollSpeed:
+		if m.ConstantTime {
+			return fmt.Sprintf("%.2fx (%.2fs)", m.Speed(), m.VisibleTime())
+		}
+		return fmt.Sprintf("%.2fx", m.Speed())
+	case OptionConstantTime:
+		return onOff(m.ConstantTime)
+	case OptionHidden:
+		return onOff(m.Hidden)
+	case OptionSudden:
+		return onOff(m.Sudden)
+	case OptionMirror:
+		return onOff(m.Mirror)
+	case OptionShuffle:
+		return onOff(m.Shuffle)
+	default:
+		return ""
+	}
+}
+
+// String returns short tags for the modifiers in use, like "2.00x HD MR"
+func (m Modifiers) String() string {
+	tags := make([]string, 0)
+	if m.Speed() != 1 {
+		tags = append(tags, fmt.Sprintf("%.2fx", m.Speed()))
+	}
+	flags := []struct {
+		on  bool
+		tag string
+	}{
+		{m.ConstantTime, "CT"},
+		{m.Hidden, "HD"},
+		{m.Sudden, "SD"},
+		{m.Mirror, "MR"},
+		{m.Shuffle, "SH"},
+	}
+	for _, flag := range flags {
+		if flag.on {
+			tags = append(tags, flag.tag)
+		}
+	}
+	
+	if len(tags) == 0 {
+		return "None"
+	}
+	return strings.Join(tags, " ")
+}
+
+// LaneMap returns the lane each chart lane is played in
+func (m Modifiers) LaneMap() [3]int {
+	laneMap := [3]int{0, 1, 2}
+	if m.Shuffle {
+		order := rand.New(rand.NewSource(m.ShuffleSeed)).Perm(len(laneMap))
+		copy(laneMap[:], order)
+	}
+	if m.Mirror {
+		for i := range laneMap {
+			laneMap[i] = len(laneMap) - 1 - laneMap[i]
+		}
+	}
+	return laneMap
+}
+
+// NoteVisibility returns how visible a note is from 0 to 1, from how far up the
+// visible highway it is, 0 at the hit line and 1 where notes come into view
+func (m Modifiers) NoteVisibility(position float64) float32 {
+	fade := func(from, to float64) float32 {
+		return float32(min(max((position-from)/(to-from), 0), 1))
+	}
+	
+	visibility := float32(1)
+	if m.Hidden {
+		visibility = min(visibility, fade(HIDDEN_FADE_END, HIDDEN_FADE_START))
+	}
+	if m.Sudden {
+		visibility = min(visibility, fade(SUDDEN_FADE_START, SUDDEN_FADE_END))
+	}
+	return visibility
+}
+
+// SetModifiers changes the modifiers for the next runs
+func (g *Game) SetModifiers(modifiers Modifiers) {
+	g.modifiers = modifiers
+}
+
+// Modifiers returns the modifiers in use
+func (g *Game) Modifiers() Modifiers {
+	return g.modifiers
+}
+
+// AdjustScrollSpeed changes the scroll speed by steps, also while playing
+func (g *Game) AdjustScrollSpeed(steps int) {
+	g.modifiers.AdjustSpeed(steps)
+}
+
+// SelectedModifier returns the highlighted row on the modifiers menu
+func (g *Game) SelectedModifier() ModifierOption {
+	return g.selectedModifier
+}
+
+// MoveModifierSelection moves the modifiers menu highlight, wrapping around
+func (g *Game) MoveModifierSelection(step int) {
+	count := int(modifierOptionCount)
+	g.selectedModifier = ModifierOption((int(g.selectedModifier) + step + count) % count)
+}
+
+// ChangeSelectedModifier changes the highlighted modifier
+func (g *Game) ChangeSelectedModifier(step int) {
+	g.modifiers.Change(g.selectedModifier, step)
+}
+
+// applyLaneMap moves every note to the lane the modifiers put its chart lane in
+func (g *Game) applyLaneMap() {
+	laneMap := g.modifiers.LaneMap()
+	
+	// Notes are still in the lanes of the previous run
+	var chartLane [3]int
+	for lane, mapped := range g.laneMap {
+		chartLane[mapped] = lane
+	}
+	for i := range g.gameNotes {
+		note := &g.gameNotes[i]
+		if note.Lane >= 0 && note.Lane < len(chartLane) {
+			note.Lane = laneMap[chartLane[note.Lane]]
+		}
+	}
+	g.laneMap = laneMap
+}
\ No newline at end of file
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestModifierLaneMap(t *testing.T) {
+	if got := (Modifiers{}).LaneMap(); got != [3]int{0, 1, 2} {
+		t.Errorf("LaneMap() = %v without modifiers, want the chart lanes", got)
+	}
+	if got := (Modifiers{Mirror: true}).LaneMap(); got != [3]int{2, 1, 0} {
+		t.Errorf("LaneMap() = %v with mirror, want [2 1 0]", got)
+	}
+	
+	// The same seed always shuffles the same way, and every lane is still used once
+	shuffled := Modifiers{Shuffle: true, ShuffleSeed: 42}.LaneMap()
+	if again := (Modifiers{Shuffle: true, ShuffleSeed: 42}).LaneMap(); again != shuffled {
+		t.Errorf("LaneMap() = %v then %v for the same seed", shuffled, again)
+	}
+	used := [3]bool{}
+	for _, lane := range shuffled {
+		used[lane] = true
+	}
+	if used != [3]bool{true, true, true} {
+		t.Errorf("LaneMap() = %v with shuffle, not a permutation", shuffled)
+	}
+}
+
+func TestLaneModifiersSurviveRestarts(t *testing.T) {
+	notes := []MIDINote{laneNote(0, 0.0, 0.1), laneNote(1, 0.5, 0.1), laneNote(2, 1.0, 0.1)}
+	clock := NewManualClock()
+	game := NewHeadlessGame(NewScriptedInput(nil), clock)
+	if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes(notes)); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	
+	for run, modifiers := range []Modifiers{{Mirror: true}, {Shuffle: true}, {Shuffle: true, Mirror: true}, {}} {
+		clock.Advance(1234567) // New shuffle seed every run
+		game.SetModifiers(modifiers)
+		game.StartGame()
+		
+		laneMap := game.Modifiers().LaneMap()
+		for i, note := range game.gameNotes {
+			if note.Lane != laneMap[i] {
+				t.Errorf("run %d: note %d in lane %d, want %d", run, i, note.Lane, laneMap[i])
+			}
+		}
+	}
+}
+
+func TestShuffledReplayVerifies(t *testing.T) {
+	notes := []MIDINote{laneNote(0, 0.0, 0.1), laneNote(1, 0.5, 0.1), laneNote(2, 1.0, 0.1)}
+	modifiers := Modifiers{Shuffle: true, Mirror: true, Hidden: true, ScrollSpeed: 2}
+	
+	// The manual clock starts at the zero seed
+	laneMap := Modifiers{Shuffle: true, Mirror: true}.LaneMap()
+	events := make([]InputEvent, 0)
+	for i := range notes {
+		events = append(events, Press(laneMap[i], firstNoteTime+0.5*float64(i), 0.05)...)
+	}
+	
+	clock := NewManualClock()
+	game := NewHeadlessGame(NewScriptedInput(events), clock)
+	if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes(notes)); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	game.SetModifiers(modifiers)
+	Simulate(game, clock, HEADLESS_FPS)
+	
+	if game.perfectHits != 3 {
+		t.Fatalf("perfectHits = %d, want 3 pressing the shuffled lanes", game.perfectHits)
+	}
+	
+	replay := game.BuildReplay()
+	if replay.Settings.Modifiers != game.Modifiers() {
+		t.Errorf("replay modifiers = %+v, want %+v", replay.Settings.Modifiers, game.Modifiers())
+	}
+	if _, err := VerifyReplay(replay, NewMIDIProcessorFromNotes(notes)); err != nil {
+		t.Errorf("VerifyReplay failed: %v", err)
+	}
+}
+
+func TestScrollSpeedRange(t *testing.T) {
+	modifiers := Modifiers{}
+	modifiers.AdjustSpeed(2)
+	if modifiers.Speed() != 1.5 {
+		t.Errorf("Speed() = %v after two steps up, want 1.5", modifiers.Speed())
+	}
+	modifiers.AdjustSpeed(-100)
+	if modifiers.Speed() != SCROLL_SPEED_MIN {
+		t.Errorf("Speed() = %v, want the minimum %v", modifiers.Speed(), SCROLL_SPEED_MIN)
+	}
+	modifiers.AdjustSpeed(100)
+	if modifiers.Speed() != SCROLL_SPEED_MAX {
+		t.Errorf("Speed() = %v, want the maximum %v", modifiers.Speed(), SCROLL_SPEED_MAX)
+	}
+}
+
+func TestConstantTimeIgnoresWindowSize(t *testing.T) {
+	modifiers := Modifiers{ConstantTime: true, ScrollSpeed: 2}
+	for _, size := range [][2]int32{{800, 600}, {800, 1200}, {1920, 1080}} {
+		l := NewLayout(size[0], size[1])
+		visible := float64(l.HitLine / l.NoteSpeed(modifiers))
+		if math.Abs(visible-modifiers.VisibleTime()) > 1e-4 {
+			t.Errorf("%dx%d: notes visible for %.3fs, want %.3fs", size[0], size[1], visible, modifiers.VisibleTime())
+		}
+	}
+	
+	// Without it, a taller window shows notes for longer
+	short := NewLayout(800, 600)
+	tall := NewLayout(800, 1200)
+	if short.HitLine/short.NoteSpeed(Modifiers{}) >= tall.HitLine/tall.NoteSpeed(Modifiers{}) {
+		t.Errorf("expected a taller window to show notes for longer at a fixed speed")
+	}
+}
+
+func TestNoteVisibility(t *testing.T) {
+	tests := []struct {
+		name      string
+		modifiers Modifiers
+		position  float64
+		want      float32
+	}{
+		{"no modifiers near", Modifiers{}, 0.1, 1},
+		{"hidden far", Modifiers{Hidden: true}, 0.9, 1},
+		{"hidden fading", Modifiers{Hidden: true}, (HIDDEN_FADE_START + HIDDEN_FADE_END) / 2, 0.5},
+		{"hidden near", Modifiers{Hidden: true}, 0.1, 0},
+		{"sudden far", Modifiers{Sudden: true}, 0.9, 0},
+		{"sudden near", Modifiers{Sudden: true}, 0.1, 1},
+		{"both in the gap", Modifiers{Hidden: true, Sudden: true}, 0.55, 1},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.modifiers.NoteVisibility(tt.position)
+			if math.Abs(float64(got-tt.want)) > 1e-5 {
+				t.Errorf("NoteVisibility(%v) = %v, want %v", tt.position, got, tt.want)
+			}
+		})
+	}
+}
\ No newline at end of file
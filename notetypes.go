@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sort"
+)
+
+// NoteType is how a note has to be played in strum mode. Lane keys strum as
+// they fret in the default input mode, so there every type is hit alike.
+type NoteType int
+
+const (
+	NoteStrum NoteType = iota // Fret and strum
+	NoteHOPO                  // Hammer-on or pull-off, fret alone while the combo is unbroken
+	NoteTap                   // Fret alone at any time
+)
+
+// Note type constants
+const (
+	HOPO_THRESHOLD     = 0.17 // Max seconds after a note in another lane for an automatic HOPO
+	HOPO_MARKER_PITCH  = 117  // MIDI note forcing the notes it spans to be HOPOs
+	STRUM_MARKER_PITCH = 118  // MIDI note forcing the notes it spans to be strummed
+	TAP_MARKER_PITCH   = 104  // MIDI note marking tap sections
+)
+
+// String returns the display name of a note type
+func (t NoteType) String() string {
+	switch t {
+	case NoteStrum:
+		return "Strum"
+	case NoteHOPO:
+		return "HOPO"
+	case NoteTap:
+		return "Tap"
+	default:
+		return "Unknown"
+	}
+}
+
+// canHitWithoutStrum returns whether a note can be hit by fretting alone in strum mode
+func (g *Game) canHitWithoutStrum(note *GameNote) bool {
+	switch note.Type {
+	case NoteTap:
+		return true
+	case NoteHOPO:
+		return g.combo > 0
+	default:
+		return false
+	}
+}
+
+// assignNoteTypes detects HOPOs from note spacing, then applies the chart's forced
+// HOPO, forced strum and tap markers on top
+func assignNoteTypes(notes []GameNote, forcedHOPOs, forcedStrums, taps []Phrase) {
+	// Notes in time order
+	order := make([]int, len(notes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return notes[order[a]].StartTime < notes[order[b]].StartTime
+	})
+	
+	isChord := func(pos int) bool {
+		start := notes[order[pos]].StartTime
+		return (pos > 0 && notes[order[pos-1]].StartTime == start) ||
+			(pos+1 < len(order) && notes[order[pos+1]].StartTime == start)
+	}
+	
+	for pos, i := range order {
+		note := &notes[i]
+		note.Type = NoteStrum
+		if pos == 0 || isChord(pos) || isChord(pos-1) {
+			continue
+		}
+		
+		previous := &notes[order[pos-1]]
+		if note.Lane != previous.Lane && note.StartTime-previous.StartTime <= HOPO_THRESHOLD {
+			note.Type = NoteHOPO
+		}
+	}
+	
+	// Chart markers win over detection, with taps taking priority
+	inPhrase := func(note *GameNote, phrases []Phrase) bool {
+		for _, phrase := range phrases {
+			if note.StartTime >= phrase.StartTime && note.StartTime < phrase.EndTime {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range notes {
+		note := &notes[i]
+		switch {
+		case inPhrase(note, taps):
+			note.Type = NoteTap
+		case inPhrase(note, forcedStrums):
+			note.Type = NoteStrum
+		case inPhrase(note, forcedHOPOs):
+			note.Type = NoteHOPO
+		}
+	}
+}
\ No newline at end of file
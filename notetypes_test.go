@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+)
+
+func loadNoteTypes(t *testing.T, track MIDITrack) []GameNote {
+	t.Helper()
+	
+	mp := NewMIDIProcessorFromNotes(track.Notes)
+	mp.tracks[0].ForcedHOPOs = track.ForcedHOPOs
+	mp.tracks[0].ForcedStrums = track.ForcedStrums
+	mp.tracks[0].TapPhrases = track.TapPhrases
+	
+	game := NewHeadlessGame(nil, NewManualClock())
+	if err := game.LoadMIDITrack(mp); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	return game.gameNotes
+}
+
+func TestHOPODetection(t *testing.T) {
+	notes := loadNoteTypes(t, MIDITrack{Notes: []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.1, 0.1), // Close after another lane: HOPO
+		laneNote(1, 0.2, 0.1), // Same lane: strum
+		laneNote(2, 1.0, 0.1), // Too far: strum
+		laneNote(0, 1.1, 0.1), // Chord right after a note: strum
+		laneNote(1, 1.1, 0.1),
+		laneNote(2, 1.2, 0.1), // Right after a chord: strum
+	}})
+	
+	want := []NoteType{NoteStrum, NoteHOPO, NoteStrum, NoteStrum, NoteStrum, NoteStrum, NoteStrum}
+	for i, note := range notes {
+		if note.Type != want[i] {
+			t.Errorf("note %d type = %s, want %s", i, note.Type, want[i])
+		}
+	}
+}
+
+func TestNoteTypeMarkers(t *testing.T) {
+	notes := loadNoteTypes(t, MIDITrack{
+		Notes: []MIDINote{
+			laneNote(0, 0.0, 0.1),
+			laneNote(1, 0.1, 0.1), // Would be a HOPO, forced to strum
+			laneNote(0, 1.0, 0.1), // Forced HOPO
+			laneNote(1, 2.0, 0.1), // Tap
+		},
+		ForcedStrums: []Phrase{{StartTime: 0.05, EndTime: 0.15}},
+		ForcedHOPOs:  []Phrase{{StartTime: 0.9, EndTime: 1.1}},
+		TapPhrases:   []Phrase{{StartTime: 1.5, EndTime: 2.5}},
+	})
+	
+	want := []NoteType{NoteStrum, NoteStrum, NoteHOPO, NoteTap}
+	for i, note := range notes {
+		if note.Type != want[i] {
+			t.Errorf("note %d type = %s, want %s", i, note.Type, want[i])
+		}
+	}
+}
+
+func TestFretWithoutStrum(t *testing.T) {
+	// Fretting alone only counts in strum mode, the default mode strums every press
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1), // Missed
+		laneNote(1, 0.1, 0.1), // HOPO after a broken combo
+		laneNote(2, 1.0, 0.1), // Strum
+		laneNote(0, 2.0, 0.1), // Tap
+	}
+	events := make([]InputEvent, 0)
+	events = append(events, Press(1, firstNoteTime+0.1, 0.05)...)
+	events = append(events, Press(2, firstNoteTime+1.0, 0.05)...)
+	events = append(events, Press(0, firstNoteTime+2.0, 0.05)...)
+	
+	game := runHeadless(t, notes, events, inStrumMode, func(midiProcessor *MIDIProcessor, game *Game) {
+		midiProcessor.tracks[0].TapPhrases = []Phrase{{StartTime: 1.9, EndTime: 2.1}}
+	})
+	
+	want := []bool{false, false, false, true}
+	for i, note := range game.gameNotes {
+		if hit := note.HitAccuracy != Miss; hit != want[i] {
+			t.Errorf("%s note %d hit = %v by fretting alone, want %v", note.Type, i, hit, want[i])
+		}
+	}
+	// Fretting alone is never penalized
+	if game.ghostPresses != 0 || game.wrongLanePresses != 0 {
+		t.Errorf("ghostPresses = %d, wrongLanePresses = %d, want 0", game.ghostPresses, game.wrongLanePresses)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Particle and animation constants, in pixels and seconds
+const (
+	PARTICLE_POOL_SIZE = 512 // Particles alive at once, the oldest are recycled first
+	SPARK_COUNT        = 14  // Sparks in a burst on a hit
+	SPARK_SPEED        = 260
+	SPARK_LIFETIME     = 0.4
+	SPARK_GRAVITY      = 700
+	FLAME_RATE         = 45 // Flame particles per second on a held sustain
+	FLAME_SPEED        = 120
+	FLAME_LIFETIME     = 0.35
+	COMBO_FLASH_TIME   = 0.6
+	FRET_BOUNCE_TIME   = 0.15
+	FRET_BOUNCE_DEPTH  = 6 // How far a fret is pushed down by a press
+)
+
+// Particle is a single short-lived spark or flame
+type Particle struct {
+	X, Y     float32
+	VX, VY   float32
+	Gravity  float32 // Downward acceleration, negative floats upward
+	Size     float32
+	Life     float32 // Seconds left, dead at 0
+	Lifetime float32
+	Color    rl.Color
+}
+
+// ParticleSystem keeps particles in a fixed pool so effects never allocate while playing
+type ParticleSystem struct {
+	particles [PARTICLE_POOL_SIZE]Particle
+	next      int // Slot the next particle goes into
+	rng       *rand.Rand
+}
+
+// NewParticleSystem creates an empty particle pool
+func NewParticleSystem() *ParticleSystem {
+	return &ParticleSystem{
+		rng: rand.New(rand.NewSource(1)),
+	}
+}
+
+// Spawn adds a particle, recycling the oldest slot when the pool is full
+func (ps *ParticleSystem) Spawn(particle Particle) {
+	particle.Lifetime = particle.Life
+	ps.particles[ps.next] = particle
+	ps.next = (ps.next + 1) % PARTICLE_POOL_SIZE
+}
+
+// Burst spawns sparks flying out from a point
+func (ps *ParticleSystem) Burst(x, y float32, count int, color rl.Color) {
+	for i := 0; i < count; i++ {
+		// Mostly upward, fanned out to the sides
+		angle := -math.Pi/2 + (ps.rng.Float64()-0.5)*math.Pi*0.9
+		speed := SPARK_SPEED * (0.5 + ps.rng.Float64()*0.5)
+		ps.Spawn(Particle{
+			X:       x,
+			Y:       y,
+			VX:      float32(math.Cos(angle) * speed),
+			VY:      float32(math.Sin(angle) * speed),
+			Gravity: SPARK_GRAVITY,
+			Size:    2 + ps.rng.Float32()*2,
+			Life:    SPARK_LIFETIME * (0.6 + ps.rng.Float32()*0.4),
+			Color:   color,
+		})
+	}
+}
+
+// Flame spawns one rising flame particle across a width centered on x
+func (ps *ParticleSystem) Flame(x, y, width float32) {
+	colors := []rl.Color{rl.Orange, rl.Gold, rl.Yellow}
+	ps.Spawn(Particle{
+		X:       x + (ps.rng.Float32()-0.5)*width,
+		Y:       y,
+		VX:      (ps.rng.Float32() - 0.5) * 30,
+		VY:      -FLAME_SPEED * (0.6 + ps.rng.Float32()*0.4),
+		Gravity: -80,
+		Size:    3 + ps.rng.Float32()*3,
+		Life:    FLAME_LIFETIME * (0.6 + ps.rng.Float32()*0.4),
+		Color:   colors[ps.rng.Intn(len(colors))],
+	})
+}
+
+// Update moves every live particle forward by dt seconds
+func (ps *ParticleSystem) Update(dt float32) {
+	for i := range ps.particles {
+		p := &ps.particles[i]
+		if p.Life <= 0 {
+			continue
+		}
+		p.Life -= dt
+		
+		// Integrate with the average velocity over the step so motion does not depend on frame rate
+		vy := p.VY + p.Gravity*dt
+		p.X += p.VX * dt
+		p.Y += (p.VY + vy) / 2 * dt
+		p.VY = vy
+	}
+}
+
+// Alive returns how many particles are alive
+func (ps *ParticleSystem) Alive() int {
+	alive := 0
+	for i := range ps.particles {
+		if ps.particles[i].Life > 0 {
+			alive++
+		}
+	}
+	return alive
+}
+
+// Clear kills every particle
+func (ps *ParticleSystem) Clear() {
+	for i := range ps.particles {
+		ps.particles[i].Life = 0
+	}
+}
+
+// Draw draws the live particles, fading and shrinking as they age
+func (ps *ParticleSystem) Draw() {
+	for i := range ps.particles {
+		p := &ps.particles[i]
+		if p.Life <= 0 {
+			continue
+		}
+		fade := p.Life / p.Lifetime
+		rl.DrawCircleV(rl.Vector2{X: p.X, Y: p.Y}, p.Size*(0.5+fade/2), rl.ColorAlpha(p.Color, fade))
+	}
+}
+
+// Effects drives hit sparks, sustain flames, combo flashes and fret bounces from game events
+type Effects struct {
+	game      *Game
+	particles *ParticleSystem
+	hitPoint  func(lane int) (x, y, width float32) // Where and how wide a lane crosses the hit line on screen
+	
+	flameDebt   [3]float32 // Fractional flame particles owed per lane
+	fretBounce  [3]float32 // Seconds left in each fret's press animation
+	fretWasDown [3]bool
+	
+	comboFlash      float32 // Seconds left in the combo milestone flash
+	comboFlashCombo int32
+}
+
+// NewEffects creates the effects for a game and subscribes them to its events
+func NewEffects(game *Game) *Effects {
+	effects := &Effects{
+		game:      game,
+		particles: NewParticleSystem(),
+		hitPoint: func(lane int) (float32, float32, float32) {
+			return flatHitPoint(NewLayout(DESIGN_WIDTH, DESIGN_HEIGHT), lane)
+		},
+	}
+	game.events.Subscribe(effects.handle)
+	return effects
+}
+
+// handle starts effects for one game event
+func (e *Effects) handle(event GameEvent) {
+	switch event.Type {
+	case EventRunStarted:
+		e.particles.Clear()
+		e.flameDebt = [3]float32{}
+		e.fretBounce = [3]float32{}
+		e.comboFlash = 0
+	case EventNoteHit:
+		x, y, _ := e.hitPoint(event.Lane)
+		count := SPARK_COUNT
+		if event.Accuracy == Perfect {
+			count += SPARK_COUNT / 2
+		}
+		e.particles.Burst(x, y, count, accuracyColor(event.Accuracy))
+	case EventComboMilestone:
+		e.comboFlash = COMBO_FLASH_TIME
+		e.comboFlashCombo = event.Combo
+	}
+}
+
+// Update advances every effect by dt seconds
+func (e *Effects) Update(dt float32) {
+	// Flames on lanes holding a sustain, at a fixed rate however long the frame was
+	held := [3]bool{}
+	for i := range e.game.gameNotes {
+		note := &e.game.gameNotes[i]
+		if note.IsActive && note.IsBeingHeld && !note.IsHit && note.Lane >= 0 && note.Lane < len(held) {
+			held[note.Lane] = true
+		}
+	}
+	for lane := range held {
+		if !held[lane] {
+			e.flameDebt[lane] = 0
+			continue
+		}
+		e.flameDebt[lane] += FLAME_RATE * dt
+		x, y, width := e.hitPoint(lane)
+		for ; e.flameDebt[lane] >= 1; e.flameDebt[lane]-- {
+			e.particles.Flame(x, y, width/2)
+		}
+	}
+	
+	// Frets bounce when pressed
+	for lane := range e.fretBounce {
+		down := e.game.lanes[lane].IsPressed
+		if down && !e.fretWasDown[lane] {
+			e.fretBounce[lane] = FRET_BOUNCE_TIME
+		}
+		e.fretWasDown[lane] = down
+		e.fretBounce[lane] = max(e.fretBounce[lane]-dt, 0)
+	}
+	
+	e.comboFlash = max(e.comboFlash-dt, 0)
+	e.particles.Update(dt)
+}
+
+// FretOffset returns how far a lane's fret is pushed down by its press animation
+func (e *Effects) FretOffset(lane int) float32 {
+	if lane < 0 || lane >= len(e.fretBounce) || e.fretBounce[lane] <= 0 {
+		return 0
+	}
+	// Down quickly and spring back up over the animation
+	progress := 1 - e.fretBounce[lane]/FRET_BOUNCE_TIME
+	return FRET_BOUNCE_DEPTH * float32(math.Sin(float64(progress)*math.Pi))
+}
+
+// drawComboFlash flashes the screen and announces a combo milestone
+func (r *Renderer) drawComboFlash() {
+	flash := r.effects.comboFlash
+	if flash <= 0 {
+		return
+	}
+	fade := flash / COMBO_FLASH_TIME
+	
+	l := r.layout
+	
+	rl.DrawRectangle(0, 0, l.Width, l.Height, rl.ColorAlpha(rl.White, 0.15*fade))
+	text := fmt.Sprintf("%d NOTE STREAK!", r.effects.comboFlashCombo)
+	size := l.Font(int32(30 + 10*(1-fade))) // Grows as it fades
+	textWidth := rl.MeasureText(text, size)
+	rl.DrawText(text, l.CenterX()-textWidth/2, l.Height/3, size, rl.ColorAlpha(rl.Gold, fade))
+}
\ No newline at end of file
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"testing"
+	
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestParticlePoolRecyclesOldest(t *testing.T) {
+	ps := NewParticleSystem()
+	for i := 0; i < PARTICLE_POOL_SIZE/SPARK_COUNT+10; i++ {
+		ps.Burst(100, 100, SPARK_COUNT, rl.Gold)
+	}
+	
+	if alive := ps.Alive(); alive != PARTICLE_POOL_SIZE {
+		t.Errorf("Alive() = %d, want the pool size %d", alive, PARTICLE_POOL_SIZE)
+	}
+	
+	ps.Update(SPARK_LIFETIME + 0.01)
+	if alive := ps.Alive(); alive != 0 {
+		t.Errorf("Alive() = %d after the spark lifetime, want 0", alive)
+	}
+}
+
+func TestParticlesIndependentOfFrameRate(t *testing.T) {
+	spark := Particle{X: 0, Y: 0, VX: 50, VY: -200, Gravity: SPARK_GRAVITY, Life: 1}
+	
+	positions := make([]rl.Vector2, 0)
+	for _, fps := range []int{40, 60, 144} {
+		ps := NewParticleSystem()
+		ps.Spawn(spark)
+		for i := 0; i < fps/4; i++ {
+			ps.Update(1 / float32(fps))
+		}
+		p := ps.particles[0]
+		positions = append(positions, rl.Vector2{X: p.X, Y: p.Y})
+	}
+	
+	for _, position := range positions[1:] {
+		if math.Abs(float64(position.X-positions[0].X)) > 0.01 || math.Abs(float64(position.Y-positions[0].Y)) > 0.01 {
+			t.Errorf("position %+v differs from %+v at another frame rate", position, positions[0])
+		}
+	}
+}
+
+func TestEffectsFromGameEvents(t *testing.T) {
+	notes := make([]MIDINote, 0)
+	events := make([]InputEvent, 0)
+	for i := 0; i < COMBO_MILESTONE; i++ {
+		notes = append(notes, laneNote(i%3, float64(i)*0.2, 0.1))
+		events = append(events, Press(i%3, firstNoteTime+float64(i)*0.2, 0.05)...)
+	}
+	
+	clock := NewManualClock()
+	game := NewHeadlessGame(NewScriptedInput(events), clock)
+	if err := game.LoadMIDITrack(NewMIDIProcessorFromNotes(notes)); err != nil {
+		t.Fatalf("LoadMIDITrack failed: %v", err)
+	}
+	effects := NewEffects(game)
+	
+	Simulate(game, clock, HEADLESS_FPS)
+	
+	if effects.comboFlash <= 0 || effects.comboFlashCombo != COMBO_MILESTONE {
+		t.Errorf("combo flash = %.2fs for combo %d, want a flash for %d",
+			effects.comboFlash, effects.comboFlashCombo, COMBO_MILESTONE)
+	}
+	if effects.particles.Alive() == 0 {
+		t.Errorf("hits should leave sparks")
+	}
+	
+	game.StartGame()
+	if effects.particles.Alive() != 0 || effects.comboFlash != 0 {
+		t.Errorf("a new run should clear the effects")
+	}
+}
+
+func TestSustainFlameRate(t *testing.T) {
+	game := newGame(nil, NewManualClock())
+	game.gameNotes = []GameNote{{Lane: 1, Duration: 1, IsActive: true, IsPressed: true, IsBeingHeld: true}}
+	
+	// The same hold time emits the same number of flames at any frame rate
+	for _, fps := range []int{30, 60, 120} {
+		effects := NewEffects(game)
+		for i := 0; i < fps; i++ {
+			effects.Update(1 / float32(fps))
+		}
+		spawned := effects.particles.next
+		if spawned < FLAME_RATE-1 || spawned > FLAME_RATE {
+			t.Errorf("%d FPS spawned %d flames in a second, want %d", fps, spawned, FLAME_RATE)
+		}
+	}
+}
\ No newline at end of file
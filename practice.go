@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Practice constants
+const (
+	PRACTICE_LEAD_IN     = 2.0 // Seconds played before the looped part
+	PRACTICE_LEAD_OUT    = 1.0 // Seconds played after it before looping
+	PRACTICE_SPEED_MIN   = 0.5
+	PRACTICE_SPEED_MAX   = 1.0
+	PRACTICE_SPEED_STEP  = 0.1
+	PRACTICE_TIME_STEP   = 1.0 // Seconds a custom loop boundary moves per step
+	PRACTICE_MIN_LENGTH  = 1.0 // Shortest custom loop in seconds
+	PRACTICE_CUSTOM_LOOP = -1  // Section index of a loop set by time
+)
+
+// PracticeSettings selects the part of the song to loop and how fast to play it
+type PracticeSettings struct {
+	Section int     // Index into the song's sections, PRACTICE_CUSTOM_LOOP for a time range
+	Start   float64 // Song time the loop starts
+	End     float64 // Song time the loop ends
+	Speed   float64 // Playback speed from PRACTICE_SPEED_MIN to PRACTICE_SPEED_MAX
+}
+
+// PracticeOption is a row on the practice menu
+type PracticeOption int
+
+const (
+	PracticeSection PracticeOption = iota
+	PracticeStart
+	PracticeEnd
+	PracticeSpeed
+	practiceOptionCount
+)
+
+// String returns the option's display name
+func (o PracticeOption) String() string {
+	switch o {
+	case PracticeSection:
+		return "Section"
+	case PracticeStart:
+		return "Loop start"
+	case PracticeEnd:
+		return "Loop end"
+	case PracticeSpeed:
+		return "Speed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Sections returns the named parts of the loaded song in game time
+func (g *Game) Sections() []Section {
+	return g.sections
+}
+
+// offsetSections moves sections by the chart's time offset and trims them to the song
+func offsetSections(sections []Section, offset float64, songDuration float64) []Section {
+	offsetSections := make([]Section, 0, len(sections))
+	for _, section := range sections {
+		start := max(section.StartTime+offset, 0)
+		end := min(section.EndTime+offset, songDuration)
+		if end <= start {
+			continue
+		}
+		offsetSections = append(offsetSections, Section{Name: section.Name, StartTime: start, EndTime: end})
+	}
+	return offsetSections
+}
+
+// Practice returns the practice loop settings
+func (g *Game) Practice() PracticeSettings {
+	return g.practice
+}
+
+// resetPractice loops the first section of a newly loaded song, or the whole song without sections
+func (g *Game) resetPractice() {
+	speed := g.practice.Speed
+	if speed <= 0 {
+		speed = PRACTICE_SPEED_MAX
+	}
+	g.practice = PracticeSettings{Section: PRACTICE_CUSTOM_LOOP, End: g.songDuration, Speed: speed}
+	if len(g.sections) > 0 {
+		g.selectPracticeSection(0)
+	}
+}
+
+// selectPracticeSection loops a section, or keeps the current times for a custom loop
+func (g *Game) selectPracticeSection(section int) {
+	g.practice.Section = section
+	if section >= 0 && section < len(g.sections) {
+		g.practice.Start = g.sections[section].StartTime
+		g.practice.End = g.sections[section].EndTime
+	}
+}
+
+// SelectedPracticeOption returns the highlighted row on the practice menu
+func (g *Game) SelectedPracticeOption() PracticeOption {
+	return g.selectedPracticeOption
+}
+
+// MovePracticeSelection moves the practice menu highlight, wrapping around
+func (g *Game) MovePracticeSelection(step int) {
+	count := int(practiceOptionCount)
+	g.selectedPracticeOption = PracticeOption((int(g.selectedPracticeOption) + step + count) % count)
+}
+
+// ChangePracticeOption steps the highlighted practice setting
+func (g *Game) ChangePracticeOption(step int) {
+	switch g.selectedPracticeOption {
+	case PracticeSection:
+		// Cycle through every section and the custom loop
+		count := len(g.sections) + 1
+		index := (g.practice.Section + 1 + step + count*count) % count
+		g.selectPracticeSection(index - 1)
+	case PracticeStart:
+		g.practice.Section = PRACTICE_CUSTOM_LOOP
+		start := g.practice.Start + float64(step)*PRACTICE_TIME_STEP
+		g.practice.Start = min(max(start, 0), g.practice.End-PRACTICE_MIN_LENGTH)
+	case PracticeEnd:
+		g.practice.Section = PRACTICE_CUSTOM_LOOP
+		end := g.practice.End + float64(step)*PRACTICE_TIME_STEP
+		g.practice.End = min(max(end, g.practice.Start+PRACTICE_MIN_LENGTH), g.songDuration)
+	case PracticeSpeed:
+		speed := g.practice.Speed + float64(step)*PRACTICE_SPEED_STEP
+		g.practice.Speed = min(max(speed, PRACTICE_SPEED_MIN), PRACTICE_SPEED_MAX)
+	}
+}
+
+// PracticeValue returns a practice setting for display
+func (g *Game) PracticeValue(option PracticeOption) string {
+	switch option {
+	case PracticeSection:
+		if g.practice.Section >= 0 && g.practice.Section < len(g.sections) {
+			return g.sections[g.practice.Section].Name
+		}
+		return "Custom"
+	case PracticeStart:
+		return fmt.Sprintf("%.1fs", g.practice.Start)
+	case PracticeEnd:
+		return fmt.Sprintf("%.1fs", g.practice.End)
+	case PracticeSpeed:
+		return fmt.Sprintf("%.0f%%", g.practice.Speed*100)
+	default:
+		return ""
+	}
+}
+
+// StartPractice starts looping the selected part of the song
+func (g *Game) StartPractice() {
+	g.practicing = true
+	g.practiceLoops = 0
+	g.lastLoopAccuracy = -1
+	g.StartGame()
+}
+
+// StopPractice leaves practice and returns to the practice menu
+func (g *Game) StopPractice() {
+	g.practicing = false
+	g.state = StatePracticeMenu
+	if g.audioManager != nil {
+		g.audioManager.StopPlayback()
+	}
+}
+
+// IsPracticing returns whether the current run loops part of the song
+func (g *Game) IsPracticing() bool {
+	return g.practicing
+}
+
+// PracticeLoops returns how many times the loop has been completed
+func (g *Game) PracticeLoops() int {
+	return g.practiceLoops
+}
+
+// LastLoopAccuracy returns the hit percentage of the last completed loop, -1 before the first
+func (g *Game) LastLoopAccuracy() float64 {
+	return g.lastLoopAccuracy
+}
+
+// windowPractice limits a run to the practice loop: the clock starts a little
+// before the loop at the practice speed and only notes inside it are played
+func (g *Game) windowPractice() {
+	g.timeOffset = max(g.practice.Start-PRACTICE_LEAD_IN, 0)
+	g.playbackSpeed = g.practice.Speed
+	g.currentTime = g.timeOffset
+	
+	g.totalNotes = 0
+	for i := range g.gameNotes {
+		note := &g.gameNotes[i]
+		if note.StartTime < g.practice.Start || note.StartTime >= g.practice.End {
+			note.IsActive = false
+			continue
+		}
+		g.totalNotes++
+	}
+}
+
+// restartPracticeLoop records how the loop went and plays it again
+func (g *Game) restartPracticeLoop() {
+	g.lastLoopAccuracy = g.Result().Accuracy()
+	g.practiceLoops++
+	fmt.Printf("Practice loop %d: %.1f%%\n", g.practiceLoops, g.lastLoopAccuracy)
+	
+	if g.audioManager != nil {
+		g.audioManager.StopPlayback()
+	}
+	g.StartGame()
+}
\ No newline at end of file
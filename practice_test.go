@@ -0,0 +1,159 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// practiceSections splits unplayedNotes into an intro and a verse, played from
+// 2s and 4s of game time
+func practiceSections(midiProcessor *MIDIProcessor, game *Game) {
+	midiProcessor.tracks[0].Sections = []Section{{"Intro", 0, 2}, {"Verse", 2, GAME_DURATION}}
+	midiProcessor.tracks[0].AutoSections = false
+}
+
+// stepFrames advances the game by whole frames
+func stepFrames(game *Game, clock *ManualClock, frames int) {
+	frameTime := time.Second / HEADLESS_FPS
+	for i := 0; i < frames; i++ {
+		clock.Advance(frameTime)
+		game.Update(float32(frameTime.Seconds()))
+	}
+}
+
+func TestPracticeLoopsSection(t *testing.T) {
+	game, clock := newHeadless(t, unplayedNotes(), nil, practiceSections)
+	if game.PracticeValue(PracticeSection) != "Intro" {
+		t.Fatalf("practice starts on %q, want the first section", game.PracticeValue(PracticeSection))
+	}
+	
+	game.StartPractice()
+	if game.totalNotes != 4 {
+		t.Errorf("totalNotes = %d, want the 4 notes in the section", game.totalNotes)
+	}
+	if game.currentTime != 0 {
+		t.Errorf("practice starts at %.2fs, want the lead-in clamped to 0", game.currentTime)
+	}
+	
+	// Missing every note neither fails nor ends the run, it loops
+	stepFrames(game, clock, (4+PRACTICE_LEAD_OUT+0.5)*HEADLESS_FPS)
+	if !game.IsPlaying() {
+		t.Fatalf("state = %v, practice should keep playing", game.state)
+	}
+	if game.PracticeLoops() != 1 {
+		t.Errorf("PracticeLoops() = %d, want 1", game.PracticeLoops())
+	}
+	if game.LastLoopAccuracy() != 0 {
+		t.Errorf("LastLoopAccuracy() = %.1f, want 0 with every note missed", game.LastLoopAccuracy())
+	}
+	if game.missedHits != 0 {
+		t.Errorf("missedHits = %d, want the new loop to start from zero", game.missedHits)
+	}
+	
+	game.StopPractice()
+	if game.state != StatePracticeMenu || game.IsPracticing() {
+		t.Errorf("StopPractice left state %v, practicing %v", game.state, game.IsPracticing())
+	}
+}
+
+func TestPracticeAtHalfSpeed(t *testing.T) {
+	game, clock := newHeadless(t, unplayedNotes(), nil, practiceSections)
+	game.selectedPracticeOption = PracticeSection
+	game.ChangePracticeOption(1)
+	game.selectedPracticeOption = PracticeSpeed
+	game.ChangePracticeOption(-5)
+	
+	practice := game.Practice()
+	if practice.Section != 1 || practice.Start != 4 || practice.Speed != PRACTICE_SPEED_MIN {
+		t.Fatalf("Practice() = %+v, want the verse at half speed", practice)
+	}
+	
+	game.StartPractice()
+	stepFrames(game, clock, HEADLESS_FPS)
+	want := practice.Start - PRACTICE_LEAD_IN + 0.5
+	if math.Abs(game.currentTime-want) > 1e-6 {
+		t.Errorf("currentTime = %.3f after a second at half speed, want %.3f", game.currentTime, want)
+	}
+}
+
+func TestPracticeBotHitsOnlyTheLoop(t *testing.T) {
+	game, clock := newHeadless(t, unplayedNotes(), nil, practiceSections)
+	game.SetAutoplay(NewBot(1, 1))
+	
+	game.StartPractice()
+	stepFrames(game, clock, (4+PRACTICE_LEAD_OUT+0.5)*HEADLESS_FPS)
+	if game.LastLoopAccuracy() != 100 {
+		t.Errorf("LastLoopAccuracy() = %.1f, want 100 for a perfect bot", game.LastLoopAccuracy())
+	}
+	
+	// The bot keeps playing the restarted loop
+	stepFrames(game, clock, 4*HEADLESS_FPS)
+	if game.perfectHits != 4 {
+		t.Errorf("perfectHits = %d in the second loop, want 4", game.perfectHits)
+	}
+}
+
+func TestPracticeCustomLoopClamps(t *testing.T) {
+	game, _ := newHeadless(t, unplayedNotes(), nil, practiceSections)
+	
+	game.selectedPracticeOption = PracticeStart
+	game.ChangePracticeOption(10)
+	practice := game.Practice()
+	if practice.Section != PRACTICE_CUSTOM_LOOP {
+		t.Errorf("Section = %d, moving the start should make a custom loop", practice.Section)
+	}
+	if practice.Start != practice.End-PRACTICE_MIN_LENGTH {
+		t.Errorf("Start = %.1f, want it kept %.1fs before End %.1f", practice.Start, PRACTICE_MIN_LENGTH, practice.End)
+	}
+	
+	game.selectedPracticeOption = PracticeEnd
+	game.ChangePracticeOption(100)
+	if end := game.Practice().End; end != game.songDuration {
+		t.Errorf("End = %.1f, want it clamped to the song length %.1f", end, game.songDuration)
+	}
+	
+	// The section option cycles through the sections and back to custom
+	game.selectedPracticeOption = PracticeSection
+	for _, want := range []string{"Intro", "Verse", "Custom"} {
+		game.ChangePracticeOption(1)
+		if got := game.PracticeValue(PracticeSection); got != want {
+			t.Errorf("section = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestMarkerSections(t *testing.T) {
+	marker := func(delta int, text string) []byte {
+		return midiEvent(delta, append([]byte{0xFF, 0x06, byte(len(text))}, text...)...)
+	}
+	tempoTrack := midiTrackChunk(
+		marker(0, "Intro"),
+		marker(960, " "),
+		marker(960, "Chorus "),
+	)
+	noteTrack := midiTrackChunk(
+		midiEvent(0, 0x90, 64, 100),
+		midiEvent(3840, 0x80, 64, 0),
+	)
+	
+	mp := NewMIDIProcessor()
+	if err := mp.LoadMIDI(writeMIDIFile(t, 480, tempoTrack, noteTrack)); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	track, err := mp.FindGuitarTrack()
+	if err != nil {
+		t.Fatalf("FindGuitarTrack failed: %v", err)
+	}
+	
+	// Blank markers are skipped and the last section lasts to the end of the song
+	want := []Section{{"Intro", 0, 2}, {"Chorus", 2, 4}}
+	if len(track.Sections) != len(want) {
+		t.Fatalf("Sections = %+v, want %+v", track.Sections, want)
+	}
+	for i, section := range track.Sections {
+		if section != want[i] {
+			t.Errorf("section %d = %+v, want %+v", i, section, want[i])
+		}
+	}
+}
\ No newline at end of file
@@ -2,34 +2,101 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"path/filepath"
 	
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
+// Flat playfield constants
+const (
+	NOTE_SPEED  = 200 // design pixels per second
+	NOTE_HEIGHT = 40
+)
+
+// Lane colors, left to right
+var laneColors = []rl.Color{rl.SkyBlue, rl.Pink, rl.Orange}
+
 // Renderer handles all drawing operations
 type Renderer struct {
-	game *Game
+	game     *Game
+	feedback *HitFeedback
+	effects  *Effects
+	view     HighwayView
+	highway  *Highway3D
+	layout   Layout // Placement for the current window size, updated every frame
 }
 
 // NewRenderer creates a new renderer
 func NewRenderer(game *Game) *Renderer {
-	return &Renderer{
-		game: game,
+	r := &Renderer{
+		game:     game,
+		feedback: NewHitFeedback(game),
+		effects:  NewEffects(game),
+		highway:  NewHighway3D(),
+		layout:   NewLayout(DESIGN_WIDTH, DESIGN_HEIGHT),
+	}
+	r.effects.hitPoint = r.hitPoint
+	return r
+}
+
+// SetView switches between the flat and 3D playfields
+func (r *Renderer) SetView(view HighwayView) {
+	r.view = view
+}
+
+// View returns the playfield view in use
+func (r *Renderer) View() HighwayView {
+	return r.view
+}
+
+// Unload frees the renderer's GPU resources
+func (r *Renderer) Unload() {
+	r.highway.Unload()
+}
+
+// hitPoint returns where a lane crosses the hit line on screen in the current view, and the lane's width there
+func (r *Renderer) hitPoint(lane int) (float32, float32, float32) {
+	if r.view == View3D {
+		return r.highway.HitPoint(lane)
 	}
+	return flatHitPoint(r.layout, lane)
+}
+
+// flatHitPoint returns where a lane crosses the hit line in the flat view, and the lane's width
+func flatHitPoint(layout Layout, lane int) (float32, float32, float32) {
+	if lane < 0 || lane >= len(layout.Lanes) {
+		return layout.LaneCenter(lane), layout.HitLine, 0
+	}
+	return layout.LaneCenter(lane), layout.HitLine, layout.Lanes[lane].Width
 }
 
 // Draw renders the entire game
 func (r *Renderer) Draw() {
+	r.layout = NewLayout(int32(rl.GetScreenWidth()), int32(rl.GetScreenHeight()))
+	
 	rl.BeginDrawing()
 	rl.ClearBackground(rl.Black)
 	
 	switch r.game.state {
 	case StateMenu:
 		r.drawMenu()
+	case StateSongSelect:
+		r.drawSongSelect()
 	case StatePlaying:
 		r.drawGameplay()
 	case StateGameOver:
 		r.drawGameOver()
+	case StateFailed:
+		r.drawFailed()
+	case StateControllerMap:
+		r.drawControllerMap()
+	case StateModifiers:
+		r.drawModifiers()
+	case StatePracticeMenu:
+		r.drawPracticeMenu()
+	case StateEditor:
+		r.drawEditor()
 	}
 	
 	rl.EndDrawing()
@@ -37,65 +104,91 @@ func (r *Renderer) Draw() {
 
 // drawGameplay draws the main gameplay screen
 func (r *Renderer) drawGameplay() {
-	// Draw lanes
-	r.drawLanes()
+	r.effects.Update(rl.GetFrameTime())
 	
-	// Draw hit line
-	r.drawHitLine()
+	if r.view == View3D {
+		r.drawHighway()
+	} else {
+		// Draw lanes
+		r.drawLanes()
+		r.drawBeatLines()
+	
+		// Draw hit line
+		r.drawHitLine()
+	
+		// Draw notes
+		r.drawNotes()
+	}
+	r.effects.particles.Draw()
 	
-	// Draw notes
-	r.drawNotes()
+	// Draw timing feedback for recent hits
+	r.drawJudgementPopups()
+	r.drawHitTickBar()
 	
 	// Draw UI
 	r.drawUI()
 	
 	// Draw progress bar
 	r.drawProgressBar()
+	
+	r.drawComboFlash()
 }
 
 // drawMenu draws the main menu
 func (r *Renderer) drawMenu() {
-	centerX := r.game.screenWidth / 2
-	centerY := r.game.screenHeight / 2
+	l := r.layout
+	centerX := l.CenterX()
+	centerY := l.CenterY()
 	
 	// Title
 	title := "Guitar Hero Game"
-	titleWidth := rl.MeasureText(title, 40)
-	rl.DrawText(title, centerX-titleWidth/2, centerY-100, 40, rl.White)
+	titleWidth := rl.MeasureText(title, l.Font(40))
+	rl.DrawText(title, centerX-titleWidth/2, centerY-l.Px(100), l.Font(40), rl.White)
 	
 	// Instructions
 	instructions := []string{
 		"Press SPACE to Start",
 		"Use A, W, D keys to hit notes",
 		"Hit notes when they reach the red line",
+		"Press SPACE while playing for Star Power",
+		"Hold SHIFT on a sustain to whammy",
+		"Press B to toggle autoplay",
+		"Press C to map a controller",
+		"Press F11 to toggle fullscreen",
 		"Press ESC to quit",
 	}
+	if r.game.IsReplay() {
+		instructions[0] = "Press SPACE to watch the replay"
+	} else if r.game.IsAutoplay() {
+		instructions[0] = fmt.Sprintf("Press SPACE to watch autoplay (skill %.0f%%)", r.game.bot.Skill*100)
+	}
 	
 	for i, instruction := range instructions {
-		textWidth := rl.MeasureText(instruction, 20)
-		rl.DrawText(instruction, centerX-textWidth/2, centerY-20+int32(i*30), 20, rl.LightGray)
+		textWidth := rl.MeasureText(instruction, l.Font(20))
+		rl.DrawText(instruction, centerX-textWidth/2, centerY+l.Px(float32(i*30-20)), l.Font(20), rl.LightGray)
 	}
 }
 
 // drawGameOver draws the game over screen
 func (r *Renderer) drawGameOver() {
-	centerX := r.game.screenWidth / 2
-	centerY := r.game.screenHeight / 2
+	l := r.layout
+	centerX := l.CenterX()
+	centerY := l.CenterY()
 	
 	// Game Over title
 	title := "Game Over!"
-	titleWidth := rl.MeasureText(title, 40)
-	rl.DrawText(title, centerX-titleWidth/2, centerY-150, 40, rl.Red)
+	titleWidth := rl.MeasureText(title, l.Font(40))
+	rl.DrawText(title, centerX-titleWidth/2, centerY-l.Px(150), l.Font(40), rl.Red)
 	
 	// Final score
 	scoreText := fmt.Sprintf("Final Score: %d", r.game.score)
-	scoreWidth := rl.MeasureText(scoreText, 30)
-	rl.DrawText(scoreText, centerX-scoreWidth/2, centerY-100, 30, rl.White)
+	scoreWidth := rl.MeasureText(scoreText, l.Font(30))
+	rl.DrawText(scoreText, centerX-scoreWidth/2, centerY-l.Px(100), l.Font(30), rl.White)
 	
 	// Max combo
 	comboText := fmt.Sprintf("Max Combo: %d", r.game.maxCombo)
-	comboWidth := rl.MeasureText(comboText, 25)
-	rl.DrawText(comboText, centerX-comboWidth/2, centerY-60, 25, rl.Yellow)
+	comboWidth := rl.MeasureText(comboText, l.Font(25))
+	rl.DrawText(comboText, centerX-comboWidth/2, centerY-l.Px(60), l.Font(25), rl.Yellow)
 	
 	// Statistics
 	stats := []string{
@@ -106,7 +199,7 @@ func (r *Renderer) drawGameOver() {
 	}
 	
 	for i, stat := range stats {
-		statWidth := rl.MeasureText(stat, 20)
+		statWidth := rl.MeasureText(stat, l.Font(20))
 		color := rl.White
 		switch i {
 		case 0:
@@ -118,186 +211,839 @@ func (r *Renderer) drawGameOver() {
 		case 3:
 			color = rl.Red
 		}
-		rl.DrawText(stat, centerX-statWidth/2, centerY+int32(i*25), 20, color)
+		rl.DrawText(stat, centerX-statWidth/2, centerY+l.Px(float32(i*25)), l.Font(20), color)
 	}
 	
 	// Accuracy
-	accuracy := float32(0)
-	if r.game.totalNotes > 0 {
-		accuracy = float32(r.game.perfectHits+r.game.goodHits+r.game.okHits) / float32(r.game.totalNotes) * 100
-	}
+	accuracy := r.game.Result().Accuracy()
 	accuracyText := fmt.Sprintf("Accuracy: %.1f%%", accuracy)
-	accuracyWidth := rl.MeasureText(accuracyText, 25)
-	rl.DrawText(accuracyText, centerX-accuracyWidth/2, centerY+120, 25, rl.White)
+	accuracyWidth := rl.MeasureText(accuracyText, l.Font(25))
+	rl.DrawText(accuracyText, centerX-accuracyWidth/2, centerY+l.Px(120), l.Font(25), rl.White)
 	
 	// Restart instruction
 	restartText := "Press SPACE to play again or ESC to quit"
-	restartWidth := rl.MeasureText(restartText, 20)
-	rl.DrawText(restartText, centerX-restartWidth/2, centerY+170, 20, rl.LightGray)
+	restartWidth := rl.MeasureText(restartText, l.Font(20))
+	rl.DrawText(restartText, centerX-restartWidth/2, centerY+l.Px(170), l.Font(20), rl.LightGray)
+	
+	// Replay location
+	if r.game.lastReplayPath != "" {
+		replayText := fmt.Sprintf("Replay saved: %s", filepath.Base(r.game.lastReplayPath))
+		replayWidth := rl.MeasureText(replayText, l.Font(16))
+		rl.DrawText(replayText, centerX-replayWidth/2, centerY+l.Px(200), l.Font(16), rl.Gray)
+	}
+	
+	// New high score banner
+	if r.game.lastScoreRank == 1 {
+		bannerText := "NEW HIGH SCORE!"
+		bannerWidth := rl.MeasureText(bannerText, l.Font(25))
+		rl.DrawText(bannerText, centerX-bannerWidth/2, centerY-l.Px(190), l.Font(25), rl.Gold)
+	}
+	
+	// Leaderboard for the song that was just played
+	if r.game.scores != nil && !r.game.IsReplay() {
+		r.drawScoreTable(l.Right(230), l.Px(60), r.game.songHash, r.game.lastScoreRank)
+	}
+	
+	// Grade, stars and timing in the left column
+	r.drawResults(l.Px(30), l.Px(60))
+}
+
+// drawFailed draws the song failed screen
+func (r *Renderer) drawFailed() {
+	l := r.layout
+	centerX := l.CenterX()
+	centerY := l.CenterY()
+	
+	title := "Song Failed"
+	titleWidth := rl.MeasureText(title, l.Font(40))
+	rl.DrawText(title, centerX-titleWidth/2, centerY-l.Px(120), l.Font(40), rl.Red)
+	
+	// How far the player got
+	progress := 0.0
+	if r.game.songDuration > 0 {
+		progress = math.Min(r.game.FailTime()/r.game.songDuration, 1.0) * 100
+	}
+	progressText := fmt.Sprintf("Made it %.0f%% through the song", progress)
+	progressWidth := rl.MeasureText(progressText, l.Font(25))
+	rl.DrawText(progressText, centerX-progressWidth/2, centerY-l.Px(60), l.Font(25), rl.White)
+	
+	scoreText := fmt.Sprintf("Score: %d   Max Combo: %d", r.game.score, r.game.maxCombo)
+	scoreWidth := rl.MeasureText(scoreText, l.Font(20))
+	rl.DrawText(scoreText, centerX-scoreWidth/2, centerY-l.Px(20), l.Font(20), rl.Yellow)
+	
+	hintText := "Turn on No Fail on the song select screen to play through"
+	hintWidth := rl.MeasureText(hintText, l.Font(16))
+	rl.DrawText(hintText, centerX-hintWidth/2, centerY+l.Px(30), l.Font(16), rl.Gray)
+	
+	restartText := "Press SPACE to return to the menu or ESC to quit"
+	restartWidth := rl.MeasureText(restartText, l.Font(20))
+	rl.DrawText(restartText, centerX-restartWidth/2, centerY+l.Px(70), l.Font(20), rl.LightGray)
+	
+	if r.game.lastReplayPath != "" {
+		replayText := fmt.Sprintf("Replay saved: %s", filepath.Base(r.game.lastReplayPath))
+		replayWidth := rl.MeasureText(replayText, l.Font(16))
+		rl.DrawText(replayText, centerX-replayWidth/2, centerY+l.Px(100), l.Font(16), rl.Gray)
+	}
+}
+
+// drawResults draws the grade, star rating, combo badges and timing histogram
+func (r *Renderer) drawResults(x, y int32) {
+	l := r.layout
+	result := r.game.Result()
+	
+	// Grade
+	gradeColors := map[string]rl.Color{
+		"S": rl.Gold,
+		"A": rl.Green,
+		"B": rl.SkyBlue,
+		"C": rl.Orange,
+		"D": rl.Red,
+	}
+	grade := result.Grade()
+	rl.DrawText(grade, x, y, l.Font(80), gradeColors[grade])
+	rl.DrawText(fmt.Sprintf("%.1f%% weighted", result.WeightedAccuracy()), x, y+l.Px(85), l.Font(16), rl.LightGray)
+	
+	// Stars
+	stars := result.Stars()
+	for i := 0; i < 5; i++ {
+		color := rl.DarkGray
+		if i < stars {
+			color = rl.Gold
+		}
+		drawStar(float32(x)+l.Scaled(15+float32(i)*34), float32(y)+l.Scaled(125), l.Scaled(14), color)
+	}
+	
+	// Full combo badges
+	badgeY := y + l.Px(155)
+	if result.IsAllPerfect() {
+		rl.DrawText("ALL PERFECT", x, badgeY, l.Font(20), rl.Gold)
+	} else if result.IsFullCombo() {
+		rl.DrawText("FULL COMBO", x, badgeY, l.Font(20), rl.Green)
+	}
+	
+	// Presses that hit no note
+	extraText := fmt.Sprintf("Ghost: %d  Wrong lane: %d", result.GhostPresses, result.WrongLanePresses)
+	extraColor := rl.Gray
+	if result.GhostPresses+result.WrongLanePresses > 0 {
+		extraColor = rl.Orange
+	}
+	rl.DrawText(extraText, x, badgeY+l.Px(24), l.Font(14), extraColor)
+	
+	// Timing histogram of hit offsets
+	r.drawHitHistogram(x, y+l.Px(200), l.Px(180), l.Px(80))
+	
+	r.drawWeakSections(x, y+l.Px(350))
+}
+
+// drawWeakSections lists the sections of the song the player hit the fewest notes in
+func (r *Renderer) drawWeakSections(x, y int32) {
+	l := r.layout
+	results := r.game.SectionResults()
+	if len(results) == 0 {
+		return
+	}
+	
+	weak := WeakSections(results, MAX_WEAK_SECTIONS)
+	if len(weak) == 0 {
+		rl.DrawText(fmt.Sprintf("Every section over %.0f%%", WEAK_SECTION_ACCURACY), x, y, l.Font(16), rl.Green)
+		return
+	}
+	
+	rl.DrawText("Needs practice", x, y, l.Font(16), rl.White)
+	for i, result := range weak {
+		line := fmt.Sprintf("%-12.12s %3.0f%% (%d missed)", result.Name, result.Accuracy(), result.Misses)
+		rl.DrawText(line, x, y+l.Px(float32(20+i*18)), l.Font(14), rl.Orange)
+	}
+}
+
+// drawHitHistogram draws how early or late notes were hit
+func (r *Renderer) drawHitHistogram(x, y, width, height int32) {
+	l := r.layout
+	bins := HitHistogram(r.game.hitOffsets)
+	
+	maxCount := 1
+	for _, count := range bins {
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+	
+	rl.DrawText("Hit timing", x, y, l.Font(16), rl.White)
+	graphY := y + l.Px(20)
+	rl.DrawRectangle(x, graphY, width, height, rl.ColorAlpha(rl.DarkGray, 0.5))
+	
+	barWidth := width / int32(len(bins))
+	for i, count := range bins {
+		barHeight := int32(float32(height) * float32(count) / float32(maxCount))
+		barX := x + int32(i)*barWidth
+		
+		// Color bars by the judgement their offsets would get
+		center := (float64(i)+0.5)*HISTOGRAM_BIN_WIDTH - HISTOGRAM_WINDOW
+		color := accuracyColor(r.game.calculateAccuracy(center))
+		rl.DrawRectangle(barX+1, graphY+height-barHeight, barWidth-2, barHeight, color)
+	}
+	
+	// Center line marks a perfectly timed hit
+	centerX := x + width/2
+	rl.DrawLine(centerX, graphY, centerX, graphY+height, rl.White)
+	
+	rl.DrawText("early", x, graphY+height+l.Px(4), l.Font(14), rl.Gray)
+	lateWidth := rl.MeasureText("late", l.Font(14))
+	rl.DrawText("late", x+width-lateWidth, graphY+height+l.Px(4), l.Font(14), rl.Gray)
+	
+	// Average timing and how consistent it was
+	mean, stdDev := HitOffsetStats(r.game.hitOffsets)
+	direction := "late"
+	if mean < 0 {
+		direction = "early"
+	}
+	statsText := fmt.Sprintf("Mean %+.1fms %s, SD %.1fms", mean*1000, direction, stdDev*1000)
+	rl.DrawText(statsText, x, graphY+height+l.Px(22), l.Font(14), rl.LightGray)
+}
+
+// drawStar draws a filled five-pointed star
+func drawStar(centerX, centerY, radius float32, color rl.Color) {
+	innerRadius := radius * 0.4
+	point := func(angle float64, length float32) rl.Vector2 {
+		return rl.Vector2{
+			X: centerX + length*float32(math.Cos(angle)),
+			Y: centerY + length*float32(math.Sin(angle)),
+		}
+	}
+	
+	for i := 0; i < 5; i++ {
+		angle := -math.Pi/2 + float64(i)*2*math.Pi/5
+		tip := point(angle, radius)
+		left := point(angle-math.Pi/5, innerRadius)
+		right := point(angle+math.Pi/5, innerRadius)
+		
+		// Raylib only draws triangles with counter-clockwise vertices
+		rl.DrawTriangle(tip, left, rl.Vector2{X: centerX, Y: centerY}, color)
+		rl.DrawTriangle(tip, rl.Vector2{X: centerX, Y: centerY}, right, color)
+	}
+}
+
+// drawControllerMap draws the controller mapping screen with the current bindings
+func (r *Renderer) drawControllerMap() {
+	l := r.layout
+	gamepad := r.game.gamepad
+	mapper := r.game.mapper
+	
+	rl.DrawText("Controller Mapping", l.Px(20), l.Px(20), l.Font(30), rl.White)
+	nameText := "No controller connected"
+	nameColor := rl.Red
+	if gamepad.IsConnected() {
+		nameText = gamepad.Name()
+		nameColor = rl.Green
+	}
+	rl.DrawText(nameText, l.Px(20), l.Px(60), l.Font(20), nameColor)
+	
+	profile := gamepad.Profile()
+	for control := Control(0); control < controlCount; control++ {
+		y := l.Px(float32(95 + int(control)*24))
+		labelColor := rl.LightGray
+		if control == mapper.Selected() {
+			rl.DrawRectangle(l.Px(15), y-l.Px(3), l.Px(500), l.Px(23), rl.DarkGray)
+			labelColor = rl.White
+		}
+		rl.DrawText(control.String(), l.Px(20), y, l.Font(18), labelColor)
+		
+		// Bindings light up while held so they can be checked
+		bindingText := profile.Get(control).String()
+		bindingColor := rl.Gray
+		if control == mapper.Selected() && mapper.IsListening() {
+			bindingText = "..."
+			bindingColor = rl.Yellow
+		} else if gamepad.IsConnected() && profile.Get(control).IsDown(gamepad.gamepad) {
+			bindingColor = rl.Green
+		}
+		rl.DrawText(bindingText, l.Px(300), y, l.Font(18), bindingColor)
+	}
+	
+	// Live whammy position
+	whammy := gamepad.Value(ControlWhammy)
+	barX, barY, barWidth, barHeight := l.Px(560), l.Px(120), l.Px(200), l.Px(12)
+	rl.DrawText("Whammy", barX, l.Px(95), l.Font(18), rl.LightGray)
+	rl.DrawRectangle(barX, barY, barWidth, barHeight, rl.DarkGray)
+	rl.DrawRectangle(barX, barY, int32(float32(barWidth)*whammy), barHeight, rl.Purple)
+	rl.DrawRectangleLines(barX, barY, barWidth, barHeight, rl.White)
+	
+	rl.DrawText(mapper.Message(), l.Px(20), l.Bottom(60), l.Font(18), rl.Yellow)
+	controls := "UP/DOWN: select  ENTER/START: rebind  DELETE: unbind  R: defaults  BACKSPACE/X: back"
+	rl.DrawText(controls, l.Px(20), l.Bottom(30), l.Font(16), rl.Gray)
+}
+
+// drawSongSelect draws the song select screen with the leaderboard for the highlighted song
+func (r *Renderer) drawSongSelect() {
+	l := r.layout
+	
+	// Title and difficulty
+	rl.DrawText("Select a Song", l.Px(20), l.Px(20), l.Font(30), rl.White)
+	judgementText := fmt.Sprintf("Timing: %s", r.game.Judgement().Name)
+	rl.DrawText(judgementText, l.Px(250), l.Px(28), l.Font(20), rl.Orange)
+	rl.DrawText(fmt.Sprintf("View: %s", r.view), l.Px(450), l.Px(28), l.Font(20), rl.Violet)
+	difficultyText := fmt.Sprintf("< %s >", r.game.difficulty)
+	rl.DrawText(difficultyText, l.Px(20), l.Px(60), l.Font(20), rl.Yellow)
+	noFailText := "No Fail: OFF"
+	noFailColor := rl.Gray
+	if r.game.NoFail() {
+		noFailText = "No Fail: ON"
+		noFailColor = rl.Green
+	}
+	rl.DrawText(noFailText, l.Px(160), l.Px(60), l.Font(20), noFailColor)
+	inputText := "Input: Lane keys"
+	if r.game.StrumMode() {
+		inputText = "Input: Frets + strum (UP/DOWN)"
+	}
+	rl.DrawText(inputText, l.Px(320), l.Px(60), l.Font(20), rl.SkyBlue)
+	modifiersText := fmt.Sprintf("Mods: %s", r.game.Modifiers())
+	rl.DrawText(modifiersText, l.Px(20), l.Bottom(95), l.Font(16), rl.Lime)
+	
+	// Song list
+	for i, song := range r.game.songs {
+		y := l.Px(float32(100 + i*25))
+		color := rl.LightGray
+		if i == r.game.selectedSong {
+			rl.DrawRectangle(l.Px(15), y-l.Px(3), l.Px(500), l.Px(24), rl.DarkGray)
+			color = rl.White
+		}
+		rl.DrawText(song.Name, l.Px(20), y, l.Font(20), color)
+		if song.Rating > 0 {
+			rating := fmt.Sprintf("%.1f", song.Rating)
+			rl.DrawText(rating, l.Px(505)-rl.MeasureText(rating, l.Font(20)), y, l.Font(20), ratingColor(song.Rating))
+		}
+	}
+	
+	// Leaderboard for the highlighted song
+	song, ok := r.game.SelectedSong()
+	if ok && r.game.scores != nil {
+		r.drawScoreTable(l.Right(230), l.Px(20), song.Hash, 0)
+	}
+	
+	// Controls
+	controls := "UP/DOWN: song  LEFT/RIGHT: difficulty  SPACE: play  BACKSPACE: back"
+	rl.DrawText(controls, l.Px(20), l.Bottom(70), l.Font(16), rl.Gray)
+	options := "N: no fail  S: strum  J: timing  V: view"
+	rl.DrawText(options, l.Px(20), l.Bottom(50), l.Font(16), rl.Gray)
+	screens := "M: modifiers  P: practice  E: edit chart  F11: fullscreen"
+	rl.DrawText(screens, l.Px(20), l.Bottom(30), l.Font(16), rl.Gray)
+}
+
+// ratingColor returns the color of a difficulty rating, green for easy charts to red for the hardest
+func ratingColor(rating float64) rl.Color {
+	switch {
+	case rating < 3:
+		return rl.Green
+	case rating < 6:
+		return rl.Yellow
+	case rating < 8:
+		return rl.Orange
+	default:
+		return rl.Red
+	}
+}
+
+// drawModifiers draws the modifiers menu
+func (r *Renderer) drawModifiers() {
+	l := r.layout
+	modifiers := r.game.Modifiers()
+	
+	rl.DrawText("Modifiers", l.Px(20), l.Px(20), l.Font(30), rl.White)
+	rl.DrawText(fmt.Sprintf("Active: %s", modifiers), l.Px(20), l.Px(60), l.Font(20), rl.Lime)
+	
+	for option := ModifierOption(0); option < modifierOptionCount; option++ {
+		y := l.Px(float32(100 + int(option)*30))
+		color := rl.LightGray
+		if option == r.game.SelectedModifier() {
+			rl.DrawRectangle(l.Px(15), y-l.Px(4), l.Px(500), l.Px(28), rl.DarkGray)
+			color = rl.White
+		}
+		rl.DrawText(option.String(), l.Px(20), y, l.Font(20), color)
+		rl.DrawText(modifiers.Value(option), l.Px(320), y, l.Font(20), rl.Yellow)
+	}
+	
+	hintText := "Scroll speed can also be changed with - and + while playing"
+	rl.DrawText(hintText, l.Px(20), l.Bottom(50), l.Font(16), rl.Gray)
+	controls := "UP/DOWN: select  LEFT/RIGHT/SPACE: change  BACKSPACE: back"
+	rl.DrawText(controls, l.Px(20), l.Bottom(30), l.Font(16), rl.Gray)
+}
+
+// drawPracticeMenu draws the practice menu with the sections of the loaded song
+func (r *Renderer) drawPracticeMenu() {
+	l := r.layout
+	
+	rl.DrawText("Practice", l.Px(20), l.Px(20), l.Font(30), rl.White)
+	if song, ok := r.game.SelectedSong(); ok {
+		rl.DrawText(song.Name, l.Px(20), l.Px(60), l.Font(20), rl.Yellow)
+	}
+	
+	for option := PracticeOption(0); option < practiceOptionCount; option++ {
+		y := l.Px(float32(100 + int(option)*30))
+		color := rl.LightGray
+		if option == r.game.SelectedPracticeOption() {
+			rl.DrawRectangle(l.Px(15), y-l.Px(4), l.Px(500), l.Px(28), rl.DarkGray)
+			color = rl.White
+		}
+		rl.DrawText(option.String(), l.Px(20), y, l.Font(20), color)
+		rl.DrawText(fmt.Sprintf("< %s >", r.game.PracticeValue(option)), l.Px(220), y, l.Font(20), rl.Yellow)
+	}
+	
+	// Every section on a timeline of the song, the loop highlighted
+	practice := r.game.Practice()
+	barX, barY, barWidth, barHeight := l.Px(20), l.Px(240), l.Width-l.Px(40), l.Px(30)
+	if r.game.songDuration > 0 {
+		toX := func(t float64) int32 {
+			return barX + int32(float64(barWidth)*t/r.game.songDuration)
+		}
+		rl.DrawRectangle(barX, barY, barWidth, barHeight, rl.DarkGray)
+		rl.DrawRectangle(toX(practice.Start), barY, toX(practice.End)-toX(practice.Start), barHeight,
+			rl.ColorAlpha(rl.Orange, 0.6))
+		for _, section := range r.game.Sections() {
+			x := toX(section.StartTime)
+			rl.DrawLine(x, barY, x, barY+barHeight, rl.White)
+			rl.DrawText(section.Name, x+l.Px(3), barY+barHeight+l.Px(4), l.Font(12), rl.LightGray)
+		}
+		rl.DrawRectangleLines(barX, barY, barWidth, barHeight, rl.White)
+	}
+	if len(r.game.Sections()) == 0 {
+		rl.DrawText("This song has no section markers, set the loop by time", l.Px(20), barY+barHeight+l.Px(24),
+			l.Font(16), rl.Gray)
+	}
+	
+	controls := "UP/DOWN: select  LEFT/RIGHT: change  SPACE: start  BACKSPACE: back"
+	rl.DrawText(controls, l.Px(20), l.Bottom(50), l.Font(16), rl.Gray)
+	rl.DrawText("BACKSPACE while practicing returns here", l.Px(20), l.Bottom(30), l.Font(16), rl.Gray)
+}
+
+// drawEditor draws the chart editor's timeline with the cursor on the hit line
+func (r *Renderer) drawEditor() {
+	l := r.layout
+	editor := r.game.Editor()
+	if editor == nil {
+		return
+	}
+	cursor := editor.Cursor()
+	speed := float64(l.NoteSpeed(editor.View()))
+	timeY := func(t float64) float32 {
+		return l.HitLine - float32((t-cursor)*speed)
+	}
+	
+	for i := range l.Lanes {
+		rect := l.Lanes[i]
+		rl.DrawRectangle(int32(rect.X), 0, int32(rect.Width), l.Height, rl.ColorAlpha(laneColors[i], 0.1))
+		rl.DrawRectangleLines(int32(rect.X), 0, int32(rect.Width), l.Height, rl.Gray)
+	}
+	
+	// Grid lines between the beats, beats and numbered downbeats over them
+	left, right := int32(l.PlayfieldLeft()), int32(l.PlayfieldRight())
+	from := cursor - float64(float32(l.Height)-l.HitLine)/speed
+	to := cursor + float64(l.HitLine)/speed
+	for t, lines := editor.Snap(from), 0; t <= to && lines < 1000; t, lines = editor.Snap(t+editor.GridStep(t)), lines+1 {
+		y := int32(timeY(t))
+		rl.DrawLine(left, y, right, y, rl.ColorAlpha(rl.DarkGray, 0.8))
+	}
+	for _, beat := range editor.Beats() {
+		if beat.Time < from || beat.Time > to {
+			continue
+		}
+		y := int32(timeY(beat.Time))
+		if beat.IsDownbeat() {
+			rl.DrawRectangle(left, y-1, right-left, 3, rl.ColorAlpha(rl.LightGray, 0.7))
+			rl.DrawText(fmt.Sprintf("%d", beat.Measure), left-l.Px(30), y-l.Px(8), l.Font(16), rl.Gray)
+		} else {
+			rl.DrawLine(left, y, right, y, rl.ColorAlpha(rl.Gray, 0.7))
+		}
+	}
+	
+	// Notes with their sustains, shorter sustains than the judgement holds are dimmed
+	noteHeight := l.Scaled(NOTE_HEIGHT)
+	for i, note := range editor.Notes() {
+		lane := l.Lanes[note.Lane]
+		noteY := timeY(note.StartTime)
+		tailHeight := float32(note.Duration * speed)
+		if noteY+noteHeight < -tailHeight || noteY > float32(l.Height)+noteHeight {
+			continue
+		}
+		rect := rl.NewRectangle(lane.X+l.Scaled(10), noteY-noteHeight/2, lane.Width-l.Scaled(20), noteHeight)
+		
+		if note.Duration > 0 {
+			alpha := float32(0.2)
+			if r.game.isSustainedNote(&note.GameNote) {
+				alpha = 0.5
+			}
+			tailWidth := rect.Width / 2
+			rl.DrawRectangle(int32(rect.X+tailWidth/2), int32(noteY-tailHeight), int32(tailWidth), int32(tailHeight),
+				rl.ColorAlpha(laneColors[note.Lane], alpha))
+		}
+		r.drawFlatGem(note.Type, rect, laneColors[note.Lane], 1)
+		if i == editor.Selected() {
+			rl.DrawRectangleLinesEx(rl.NewRectangle(rect.X-2, rect.Y-2, rect.Width+4, rect.Height+4), l.Scaled(3), rl.Yellow)
+		}
+	}
+	
+	r.drawHitLine()
+	
+	// Position and tools on the left
+	title := "Chart Editor"
+	if editor.IsDirty() {
+		title += " *"
+	}
+	rl.DrawText(title, l.Px(10), l.Px(10), l.Font(24), rl.White)
+	rl.DrawText(fmt.Sprintf("%s (saving keeps only the guitar chart, the original is copied to %s)",
+		filepath.Base(editor.Path()), filepath.Base(editor.BackupPath())), l.Px(10), l.Px(40), l.Font(14), rl.Gray)
+	position := fmt.Sprintf("%.3fs", cursor)
+	if beat, ok := editor.BeatAt(cursor); ok {
+		position = fmt.Sprintf("%d.%d  %s  %.0f BPM", beat.Measure, beat.Index+1, position, beat.BPM)
+	}
+	info := []string{
+		position,
+		fmt.Sprintf("Snap: %s", editor.SnapName()),
+		fmt.Sprintf("Zoom: %.2fx", editor.View().Speed()),
+		fmt.Sprintf("Notes: %d", len(editor.Notes())),
+	}
+	if selected := editor.Selected(); selected >= 0 {
+		note := editor.Notes()[selected]
+		info = append(info, fmt.Sprintf("Selected: lane %d %s", note.Lane+1, note.Type))
+		info = append(info, fmt.Sprintf("  at %.3fs, %.3fs long", note.StartTime, note.Duration))
+	}
+	if editor.IsPlaying() {
+		info = append(info, "PLAYING")
+	}
+	for i, line := range info {
+		rl.DrawText(line, l.Px(10), l.Px(float32(70+i*20)), l.Font(16), rl.LightGray)
+	}
+	
+	if editor.IsConfirmingDiscard() {
+		warning := "Unsaved changes, press BACKSPACE again to discard them"
+		warningWidth := rl.MeasureText(warning, l.Font(20))
+		rl.DrawText(warning, l.CenterX()-warningWidth/2, l.Px(20), l.Font(20), rl.Orange)
+	}
+	
+	// Controls along the bottom
+	rl.DrawRectangle(0, l.Bottom(70), l.Width, l.Px(70), rl.ColorAlpha(rl.Black, 0.8))
+	controls := []string{
+		"CLICK: place/select, drag for sustain  RIGHT CLICK: delete  1/2/3: note at cursor  DEL: delete",
+		"WHEEL/UP/DOWN: scrub  LEFT/RIGHT: snap  -/=: zoom  SHIFT+UP/DOWN: sustain  H: HOPO  T: tap",
+		"SPACE: play/stop  CTRL+Z/CTRL+Y: undo/redo  CTRL+S: save  BACKSPACE: exit",
+	}
+	for i, line := range controls {
+		rl.DrawText(line, l.Px(10), l.Bottom(float32(64-i*20)), l.Font(14), rl.Gray)
+	}
+}
+
+// drawScoreTable draws the top scores and personal best for a song at the selected difficulty
+func (r *Renderer) drawScoreTable(x, y int32, songHash string, highlightRank int) {
+	l := r.layout
+	rl.DrawText(fmt.Sprintf("Top %d - %s", LEADERBOARD_SIZE, r.game.difficulty), x, y, l.Font(20), rl.White)
+	y += l.Px(28)
+	
+	entries := r.game.scores.TopScores(songHash, r.game.difficulty, LEADERBOARD_SIZE)
+	if len(entries) == 0 {
+		rl.DrawText("No scores yet", x, y, l.Font(16), rl.Gray)
+		y += l.Px(20)
+	}
+	
+	for i, entry := range entries {
+		color := rl.LightGray
+		if i+1 == highlightRank {
+			color = rl.Gold
+		}
+		line := fmt.Sprintf("%2d. %-10.10s %7d %s %5.1f%%",
+			i+1, entry.Player, entry.Result.Score, entry.Result.Grade(), entry.Result.Accuracy())
+		rl.DrawText(line, x, y, l.Font(16), color)
+		y += l.Px(20)
+	}
+	
+	// Personal best
+	y += l.Px(10)
+	best, ok := r.game.scores.PersonalBest(songHash, r.game.difficulty, r.game.playerName)
+	if ok {
+		bestText := fmt.Sprintf("Personal best: %d", best.Result.Score)
+		rl.DrawText(bestText, x, y, l.Font(16), rl.SkyBlue)
+		detailText := fmt.Sprintf("%.1f%%, max combo %d, %s, %s",
+			best.Result.Accuracy(), best.Result.MaxCombo, best.Judgement.Name, best.Date.Format("2006-01-02"))
+		if mods := best.Modifiers.String(); mods != "None" {
+			detailText += ", " + mods
+		}
+		rl.DrawText(detailText, x, y+l.Px(20), l.Font(14), rl.Gray)
+	} else {
+		rl.DrawText("Personal best: none", x, y, l.Font(16), rl.Gray)
+	}
 }
 
 // drawLanes draws the three game lanes
 func (r *Renderer) drawLanes() {
+	l := r.layout
 	for i, lane := range r.game.lanes {
-		// Lane background
+		rect := l.Lanes[i]
+		
+		// Lane background, tinted blue while Star Power is active
 		color := rl.DarkGray
+		if r.game.IsStarPowerActive() {
+			color = rl.DarkBlue
+		}
 		if lane.IsPressed {
 			color = rl.Gray
 		}
 		
 		rl.DrawRectangle(
-			int32(lane.X),
+			int32(rect.X),
 			0,
-			int32(lane.Width),
-			r.game.screenHeight,
+			int32(rect.Width),
+			l.Height,
 			color,
 		)
 		
 		// Lane borders
 		rl.DrawRectangleLines(
-			int32(lane.X),
+			int32(rect.X),
 			0,
-			int32(lane.Width),
-			r.game.screenHeight,
+			int32(rect.Width),
+			l.Height,
 			rl.White,
 		)
 		
+		// Fret at the hit line, bouncing when pressed
+		fretColor := laneColors[i]
+		if !lane.IsPressed {
+			fretColor = rl.ColorAlpha(fretColor, 0.4)
+		}
+		fretY := l.HitLine + l.Scaled(r.effects.FretOffset(i)-8)
+		fret := rl.NewRectangle(rect.X+l.Scaled(20), fretY, rect.Width-l.Scaled(40), l.Scaled(16))
+		rl.DrawRectangleRounded(fret, 0.5, 6, fretColor)
+		
 		// Lane labels
 		keyText := []string{"A", "W", "D"}[i]
-		textX := int32(lane.X + lane.Width/2 - 10)
-		textY := int32(r.game.hitLine + 50)
-		rl.DrawText(keyText, textX, textY, 30, rl.White)
+		textX := int32(l.LaneCenter(i) - l.Scaled(10))
+		textY := int32(l.HitLine + l.Scaled(50))
+		rl.DrawText(keyText, textX, textY, l.Font(30), rl.White)
+	}
+}
+
+// drawBeatLines draws a line across the lanes for every beat, thicker and numbered on downbeats
+func (r *Renderer) drawBeatLines() {
+	l := r.layout
+	now := r.game.currentTime
+	speed := float64(l.NoteSpeed(r.game.Modifiers()))
+	from := now - float64(float32(l.Height)-l.HitLine)/speed
+	to := now + float64(l.HitLine)/speed
+	
+	left := int32(l.PlayfieldLeft())
+	right := int32(l.PlayfieldRight())
+	for _, beat := range r.game.BeatsBetween(from, to) {
+		y := int32(l.HitLine - float32((beat.Time-now)*speed))
+		if beat.IsDownbeat() {
+			rl.DrawRectangle(left, y-1, right-left, 3, rl.ColorAlpha(rl.LightGray, 0.6))
+			if beat.Measure > 0 {
+				rl.DrawText(fmt.Sprintf("%d", beat.Measure), left-l.Px(30), y-l.Px(8), l.Font(16), rl.Gray)
+			}
+		} else {
+			rl.DrawLine(left, y, right, y, rl.ColorAlpha(rl.Gray, 0.5))
+		}
 	}
 }
 
 // drawHitLine draws the horizontal hit line
 func (r *Renderer) drawHitLine() {
+	l := r.layout
 	rl.DrawLine(
 		0,
-		int32(r.game.hitLine),
-		r.game.screenWidth,
-		int32(r.game.hitLine),
+		int32(l.HitLine),
+		l.Width,
+		int32(l.HitLine),
 		rl.Red,
 	)
 	
 	// Make hit line more visible
 	rl.DrawLine(
 		0,
-		int32(r.game.hitLine-1),
-		r.game.screenWidth,
-		int32(r.game.hitLine-1),
+		int32(l.HitLine-1),
+		l.Width,
+		int32(l.HitLine-1),
 		rl.Red,
 	)
 	rl.DrawLine(
 		0,
-		int32(r.game.hitLine+1),
-		r.game.screenWidth,
-		int32(r.game.hitLine+1),
+		int32(l.HitLine+1),
+		l.Width,
+		int32(l.HitLine+1),
 		rl.Red,
 	)
 }
 
 // drawNotes draws all active game notes
 func (r *Renderer) drawNotes() {
+	l := r.layout
+	modifiers := r.game.Modifiers()
+	speed := float64(l.NoteSpeed(modifiers))
+	lookahead := float64(l.HitLine) / speed
 	for _, note := range r.game.gameNotes {
-		if !note.IsActive {
+		// Hit notes disappear, missed ones scroll on
+		if !note.IsActive || (note.IsHit && note.HitAccuracy != Miss) {
 			continue
 		}
 		
-		// Calculate note position
-		lane := r.game.lanes[note.Lane]
-		noteX := lane.X + 10 // Small margin from lane edge
-		noteY := note.Y
+		// Calculate note position from the time until it reaches the hit line
+		lane := l.Lanes[note.Lane]
+		noteX := lane.X + l.Scaled(10) // Small margin from lane edge
+		noteY := l.HitLine - float32((note.StartTime-r.game.currentTime)*speed)
+		noteWidth := lane.Width - l.Scaled(20)
+		noteHeight := l.Scaled(NOTE_HEIGHT)
 		
 		// Skip notes that are off screen
-		if noteY < -note.Height || noteY > float32(r.game.screenHeight)+note.Height {
+		if noteY < -noteHeight || noteY > float32(l.Height)+noteHeight {
 			continue
 		}
 		
-		// Choose note color based on state
-		var color rl.Color
-		if note.IsHit {
-			switch note.HitAccuracy {
-			case Perfect:
-				color = rl.Gold
-			case Good:
-				color = rl.Green
-			case OK:
-				color = rl.Blue
-			case Miss:
-				color = rl.Red
-			}
-		} else if note.IsBeingHeld {
-			// Green for sustained notes being held correctly
-			color = rl.Green
-		} else if note.IsPressed {
-			// Light green for sustained notes just started
-			color = rl.Lime
-		} else {
-			// Different colors for different lanes
-			colors := []rl.Color{rl.SkyBlue, rl.Pink, rl.Orange}
-			color = colors[note.Lane]
+		// Hidden and sudden fade notes out on the way down, held sustains stay visible
+		visibility := float32(1)
+		if !note.IsPressed {
+			visibility = modifiers.NoteVisibility((note.StartTime - r.game.currentTime) / lookahead)
+		}
+		if visibility <= 0 {
+			continue
 		}
 		
-		// Draw note
-		rl.DrawRectangle(
-			int32(noteX),
-			int32(noteY),
-			int32(note.Width),
-			int32(note.Height),
-			color,
-		)
-		
-		// Draw note border
-		rl.DrawRectangleLines(
-			int32(noteX),
-			int32(noteY),
-			int32(note.Width),
-			int32(note.Height),
-			rl.White,
-		)
+		color := fadeColor(r.noteColor(&note), visibility)
+		r.drawFlatGem(note.Type, rl.NewRectangle(noteX, noteY, noteWidth, noteHeight), color, visibility)
 		
 		// For sustained notes, draw length indicator
-		if note.Duration > 0.3 { // Only for sustained notes
-			sustainHeight := int32(note.Duration * NOTE_SPEED)
-			sustainX := int32(noteX + note.Width/4)
-			sustainWidth := int32(note.Width/2)
+		if r.game.isSustainedNote(&note) { // Only for sustained notes
+			sustainHeight := int32(note.Duration * speed)
+			sustainX := int32(noteX + noteWidth/4)
+			sustainWidth := int32(noteWidth/2)
+			
+			// The tail wobbles while the sustain is whammied
+			wobble := float32(0)
+			if r.game.isWhammying(&note) {
+				wobble = l.Scaled(r.game.Whammy() * WHAMMY_WOBBLE)
+			}
 			
 			// Draw the full sustain tail with transparency
-			rl.DrawRectangle(
+			r.drawSustainTail(
 				sustainX,
-				int32(noteY + note.Height),
+				int32(noteY + noteHeight),
 				sustainWidth,
 				sustainHeight,
-				rl.ColorAlpha(color, 0.3),
+				rl.ColorAlpha(color, 0.3*visibility),
+				wobble,
 			)
 			
 			// If note is being held, show progress
 			if note.IsPressed && note.SustainProgress > 0 {
 				progressHeight := int32(float64(sustainHeight) * note.SustainProgress)
-				rl.DrawRectangle(
+				r.drawSustainTail(
 					sustainX,
-					int32(noteY + note.Height),
+					int32(noteY + noteHeight),
 					sustainWidth,
 					progressHeight,
-					rl.ColorAlpha(rl.Green, 0.7),
+					rl.ColorAlpha(rl.Green, 0.7*visibility),
+					wobble,
 				)
 			}
 		}
 	}
 }
 
+// drawFlatGem draws a note on the flat playfield, styled by how it has to be played
+func (r *Renderer) drawFlatGem(noteType NoteType, rect rl.Rectangle, color rl.Color, visibility float32) {
+	l := r.layout
+	x, y, width, height := int32(rect.X), int32(rect.Y), int32(rect.Width), int32(rect.Height)
+	border := fadeColor(rl.White, visibility)
+	
+	switch noteType {
+	case NoteTap:
+		// Hollow note with a thick outline
+		rl.DrawRectangle(x, y, width, height, fadeColor(rl.Black, visibility))
+		rl.DrawRectangleLinesEx(rect, l.Scaled(4), color)
+	case NoteHOPO:
+		// Solid note with a bright band across the middle
+		rl.DrawRectangle(x, y, width, height, color)
+		bandHeight := rect.Height / 3
+		rl.DrawRectangle(x, int32(rect.Y+bandHeight), width, int32(bandHeight), rl.ColorAlpha(rl.White, 0.8*visibility))
+		rl.DrawRectangleLines(x, y, width, height, border)
+	default:
+		rl.DrawRectangle(x, y, width, height, color)
+		rl.DrawRectangleLines(x, y, width, height, border)
+	}
+}
+
+// noteColor returns a note's color from its state, lane and Star Power phrase
+func (r *Renderer) noteColor(note *GameNote) rl.Color {
+	switch {
+	case note.IsHit:
+		return accuracyColor(note.HitAccuracy)
+	case note.IsBeingHeld:
+		// Green for sustained notes being held correctly
+		return rl.Green
+	case note.IsPressed:
+		// Light green for sustained notes just started
+		return rl.Lime
+	case r.isStarNote(*note):
+		// Notes in an unbroken Star Power phrase
+		return rl.White
+	default:
+		return laneColors[note.Lane]
+	}
+}
+
+// fadeColor scales a color's opacity by visibility from 0 to 1
+func fadeColor(color rl.Color, visibility float32) rl.Color {
+	return rl.ColorAlpha(color, float32(color.A)/255*visibility)
+}
+
+// drawSustainTail draws a sustain tail, bent into a moving wave when wobble is above zero
+func (r *Renderer) drawSustainTail(x, y, width, height int32, color rl.Color, wobble float32) {
+	if wobble <= 0 {
+		rl.DrawRectangle(x, y, width, height, color)
+		return
+	}
+	
+	const segmentHeight = 4
+	for offset := int32(0); offset < height; offset += segmentHeight {
+		wave := math.Sin(float64(offset)/12 + r.game.currentTime*20)
+		shift := int32(wave * float64(wobble))
+		rl.DrawRectangle(x+shift, y+offset, width, min(segmentHeight, height-offset), color)
+	}
+}
+
 // drawUI draws the game UI (score, combo, etc.)
 func (r *Renderer) drawUI() {
+	l := r.layout
+	
 	// Score
 	scoreText := fmt.Sprintf("Score: %d", r.game.score)
-	rl.DrawText(scoreText, 10, 10, 20, rl.White)
+	rl.DrawText(scoreText, l.Px(10), l.Px(10), l.Font(20), rl.White)
 	
 	// Combo
 	if r.game.combo > 0 {
 		comboText := fmt.Sprintf("Combo: %d", r.game.combo)
-		rl.DrawText(comboText, 10, 40, 20, rl.Yellow)
+		rl.DrawText(comboText, l.Px(10), l.Px(40), l.Font(20), rl.Yellow)
+	}
+	
+	// Multiplier
+	multiplier := r.game.ScoreMultiplier()
+	multiplierColor := rl.White
+	if r.game.IsStarPowerActive() {
+		multiplierColor = rl.SkyBlue
+	} else if multiplier >= MAX_MULTIPLIER {
+		multiplierColor = rl.Gold
 	}
+	rl.DrawText(fmt.Sprintf("%dx", multiplier), l.Px(10), l.Px(130), l.Font(40), multiplierColor)
+	
+	// Star Power meter
+	r.drawStarPowerMeter(l.Px(10), l.Px(180), l.Px(80), l.Px(12))
+	
+	// Tempo
+	bpmText := fmt.Sprintf("BPM: %.0f", r.game.BPMAt(r.game.currentTime))
+	rl.DrawText(bpmText, l.Px(10), l.Px(200), l.Font(16), rl.LightGray)
+	
+	// Scroll speed, adjustable with - and +
+	speedText := fmt.Sprintf("Speed: %s", r.game.Modifiers().Value(OptionScrollSpeed))
+	rl.DrawText(speedText, l.Px(10), l.Px(220), l.Font(16), rl.LightGray)
+	
+	// Rock meter in the margin right of the lanes
+	r.drawRockMeter(l.PlayfieldRight()+l.Scaled(50), l.HitLine-l.Scaled(60), l.Scaled(40))
 	
 	// Time remaining (show countdown)
 	timeRemaining := r.game.songDuration - r.game.currentTime
@@ -309,31 +1055,115 @@ func (r *Renderer) drawUI() {
 	if timeRemaining < 5.0 {
 		timeColor = rl.Red // Red when less than 5 seconds remain
 	}
-	rl.DrawText(timeText, 10, 70, 20, timeColor)
+	rl.DrawText(timeText, l.Px(10), l.Px(70), l.Font(20), timeColor)
+	
+	// Practice loop status
+	if r.game.IsPracticing() {
+		practice := r.game.Practice()
+		practiceText := fmt.Sprintf("PRACTICE  %s  %.0f%%  Loop %d",
+			r.game.PracticeValue(PracticeSection), practice.Speed*100, r.game.PracticeLoops()+1)
+		if accuracy := r.game.LastLoopAccuracy(); accuracy >= 0 {
+			practiceText += fmt.Sprintf("  Last: %.1f%%", accuracy)
+		}
+		practiceWidth := rl.MeasureText(practiceText, l.Font(16))
+		rl.DrawText(practiceText, l.CenterX()-practiceWidth/2, l.Px(36), l.Font(16), rl.Orange)
+	}
+	
+	// Replay and autoplay indicators
+	if r.game.IsReplay() || r.game.IsAutoplay() {
+		modeText := "REPLAY"
+		if r.game.IsAutoplay() {
+			modeText = "AUTOPLAY"
+		}
+		modeWidth := rl.MeasureText(modeText, l.Font(20))
+		rl.DrawText(modeText, l.CenterX()-modeWidth/2, l.Px(10), l.Font(20), rl.Red)
+	}
 	
 	// Audio status indicator
 	if r.game.audioManager != nil {
 		audioText := "♪ Audio: "
 		if r.game.audioManager.IsPlaying() {
 			audioText += "ON"
-			rl.DrawText(audioText, 10, 100, 16, rl.Green)
+			rl.DrawText(audioText, l.Px(10), l.Px(100), l.Font(16), rl.Green)
 		} else {
 			audioText += "OFF"
-			rl.DrawText(audioText, 10, 100, 16, rl.Red)
+			rl.DrawText(audioText, l.Px(10), l.Px(100), l.Font(16), rl.Red)
 		}
 	}
 }
 
+// drawStarPowerMeter draws the Star Power meter and whether it can be activated
+func (r *Renderer) drawStarPowerMeter(x, y, width, height int32) {
+	meter := r.game.StarPowerMeter()
+	
+	rl.DrawRectangle(x, y, width, height, rl.DarkGray)
+	fillColor := rl.SkyBlue
+	if r.game.IsStarPowerActive() {
+		fillColor = rl.Blue
+	}
+	rl.DrawRectangle(x, y, int32(float64(width)*meter), height, fillColor)
+	
+	// Mark the activation threshold
+	thresholdX := x + int32(float64(width)*STAR_POWER_MIN_METER)
+	rl.DrawLine(thresholdX, y, thresholdX, y+height, rl.White)
+	rl.DrawRectangleLines(x, y, width, height, rl.White)
+	
+	if r.game.CanActivateStarPower() {
+		rl.DrawText("SPACE: Star Power", x, y+height+r.layout.Px(4), r.layout.Font(12), rl.SkyBlue)
+	}
+}
+
+// drawRockMeter draws the rock meter as a half-circle gauge with red, yellow and green zones
+func (r *Renderer) drawRockMeter(centerX, centerY, radius float32) {
+	center := rl.Vector2{X: centerX, Y: centerY}
+	
+	// Angles run clockwise from the right, so the upper half goes from 180 to 360 degrees
+	rl.DrawCircleSector(center, radius, 180, 240, 16, rl.Maroon)
+	rl.DrawCircleSector(center, radius, 240, 300, 16, rl.Orange)
+	rl.DrawCircleSector(center, radius, 300, 360, 16, rl.DarkGreen)
+	
+	// Needle
+	meter := r.game.RockMeter()
+	angle := (180 + meter*180) * math.Pi / 180
+	tip := rl.Vector2{
+		X: centerX + float32(math.Cos(angle))*radius,
+		Y: centerY + float32(math.Sin(angle))*radius,
+	}
+	rl.DrawLineEx(center, tip, r.layout.Scaled(3), rl.White)
+	rl.DrawCircleV(center, r.layout.Scaled(4), rl.White)
+	
+	label := "ROCK"
+	labelColor := rl.LightGray
+	if meter < ROCK_METER_DANGER {
+		labelColor = rl.Red
+	}
+	if r.game.NoFail() {
+		label = "NO FAIL"
+	}
+	labelSize := r.layout.Font(12)
+	labelWidth := rl.MeasureText(label, labelSize)
+	rl.DrawText(label, int32(centerX)-labelWidth/2, int32(centerY)+r.layout.Px(8), labelSize, labelColor)
+}
+
+// isStarNote returns whether a note belongs to a Star Power phrase that is still intact
+func (r *Renderer) isStarNote(note GameNote) bool {
+	if note.StarPhrase < 0 || note.StarPhrase >= len(r.game.starPhrases) {
+		return false
+	}
+	return !r.game.starPhrases[note.StarPhrase].Broken
+}
+
 // drawProgressBar draws the song progress bar
 func (r *Renderer) drawProgressBar() {
 	if r.game.songDuration <= 0 {
 		return
 	}
 	
-	barWidth := int32(300)
-	barHeight := int32(10)
-	barX := r.game.screenWidth - barWidth - 20
-	barY := int32(20)
+	l := r.layout
+	barWidth := l.Px(300)
+	barHeight := l.Px(10)
+	barX := l.Right(320)
+	barY := l.Px(20)
 	
 	// Background
 	rl.DrawRectangle(barX, barY, barWidth, barHeight, rl.DarkGray)
@@ -355,7 +1185,7 @@ func (r *Renderer) drawProgressBar() {
 		timeRemaining = 0
 	}
 	timeText := fmt.Sprintf("%.1fs remaining", timeRemaining)
-	rl.DrawText(timeText, barX, barY+barHeight+5, 16, rl.White)
+	rl.DrawText(timeText, barX, barY+barHeight+l.Px(5), l.Font(16), rl.White)
 }
 
 // drawInstructions draws game instructions
@@ -368,14 +1198,15 @@ func (r *Renderer) drawInstructions() {
 		"Press ESC to quit",
 	}
 	
-	startY := int32(r.game.screenHeight - int32(len(instructions)*20) - 10)
+	l := r.layout
+	startY := l.Bottom(float32(len(instructions)*20 + 10))
 	
 	for i, instruction := range instructions {
 		rl.DrawText(
 			instruction,
-			10,
-			startY+int32(i*20),
-			16,
+			l.Px(10),
+			startY+l.Px(float32(i*20)),
+			l.Font(16),
 			rl.LightGray,
 		)
 	}
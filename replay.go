@@ -0,0 +1,168 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// REPLAY_VERSION is bumped whenever the replay format or scoring rules change
+const REPLAY_VERSION = 7
+
+// Replay is a recorded run that can be played back or verified
+type Replay struct {
+	Version    int            `json:"version"`
+	SongHash   string         `json:"song_hash"`
+	SongPath   string         `json:"song_path"`
+	RecordedAt time.Time      `json:"recorded_at"`
+	Settings   ReplaySettings `json:"settings"`
+	Events     []InputEvent   `json:"events"`
+	Result     GameResult     `json:"result"`
+}
+
+// ReplaySettings holds the game settings a replay was recorded with
+type ReplaySettings struct {
+	GameDuration float64          `json:"game_duration"`
+	Difficulty   Difficulty       `json:"difficulty"`
+	NoFail       bool             `json:"no_fail,omitempty"`
+	StrumMode    bool             `json:"strum_mode,omitempty"`
+	Judgement    JudgementProfile `json:"judgement"`
+	Modifiers    Modifiers        `json:"modifiers"`
+}
+
+// BuildReplay creates a replay from the events recorded during the last run
+func (g *Game) BuildReplay() *Replay {
+	songPath := ""
+	if g.midiProcessor != nil {
+		songPath = g.midiProcessor.FilePath()
+	}
+	
+	events := make([]InputEvent, len(g.recordedEvents))
+	copy(events, g.recordedEvents)
+	
+	return &Replay{
+		Version:    REPLAY_VERSION,
+		SongHash:   g.songHash,
+		SongPath:   songPath,
+		RecordedAt: time.Now(),
+		Settings: ReplaySettings{
+			GameDuration: g.songDuration,
+			Difficulty:   g.difficulty,
+			NoFail:       g.noFail,
+			StrumMode:    g.strumMode,
+			Judgement:    g.judgement,
+			Modifiers:    g.modifiers,
+		},
+		Events: events,
+		Result: g.Result(),
+	}
+}
+
+// SaveReplay writes a gzip-compressed replay file
+func SaveReplay(path string, replay *Replay) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create replay file: %v", err)
+	}
+	defer file.Close()
+	
+	writer := gzip.NewWriter(file)
+	if err := json.NewEncoder(writer).Encode(replay); err != nil {
+		return fmt.Errorf("failed to encode replay: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write replay: %v", err)
+	}
+	
+	return nil
+}
+
+// LoadReplay reads a replay file written by SaveReplay
+func LoadReplay(path string) (*Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %v", err)
+	}
+	defer file.Close()
+	
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay: %v", err)
+	}
+	defer reader.Close()
+	
+	replay := &Replay{}
+	if err := json.NewDecoder(reader).Decode(replay); err != nil {
+		return nil, fmt.Errorf("failed to decode replay: %v", err)
+	}
+	
+	if replay.Version != REPLAY_VERSION {
+		return nil, fmt.Errorf("unsupported replay version %d", replay.Version)
+	}
+	
+	return replay, nil
+}
+
+// SaveReplayToDataDir saves a replay under the user data directory and returns its path
+func SaveReplayToDataDir(replay *Replay) (string, error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	
+	replayDir := filepath.Join(dataDir, "replays")
+	if err := os.MkdirAll(replayDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create replay directory: %v", err)
+	}
+	
+	hashPrefix := replay.SongHash
+	if len(hashPrefix) > 8 {
+		hashPrefix = hashPrefix[:8]
+	}
+	name := fmt.Sprintf("%s-%s.ghr", replay.RecordedAt.Format("20060102-150405"), hashPrefix)
+	path := filepath.Join(replayDir, name)
+	
+	return path, SaveReplay(path, replay)
+}
+
+// VerifyReplay plays a replay back headlessly and checks that it produces the recorded result
+func VerifyReplay(replay *Replay, midiProcessor *MIDIProcessor) (GameResult, error) {
+	if hash := midiProcessor.SongHash(); hash != replay.SongHash {
+		return GameResult{}, fmt.Errorf("replay was recorded on a different song (hash %s, loaded %s)",
+			replay.SongHash, hash)
+	}
+	
+	clock := NewManualClock()
+	game := NewHeadlessGame(nil, clock)
+	if err := game.LoadMIDITrack(midiProcessor); err != nil {
+		return GameResult{}, err
+	}
+	game.SetReplay(replay)
+	
+	Simulate(game, clock, HEADLESS_FPS)
+	
+	result := game.Result()
+	if result != replay.Result {
+		return result, fmt.Errorf("replay result mismatch: recorded %+v, got %+v", replay.Result, result)
+	}
+	
+	return result, nil
+}
+
+// userDataDir returns the directory where replays and other user data are stored
+func userDataDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find user data directory: %v", err)
+	}
+	
+	dataDir := filepath.Join(configDir, "guitar-hero-game")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create user data directory: %v", err)
+	}
+	
+	return dataDir, nil
+}
\ No newline at end of file
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayRoundTrip(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.5, 1.0),
+		laneNote(2, 1.0, 0.1),
+		laneNote(0, 2.0, 0.1),
+	}
+	events := make([]InputEvent, 0)
+	events = append(events, Press(0, firstNoteTime+0.01, 0.05)...)
+	events = append(events, Press(1, firstNoteTime+0.56, 0.9)...)
+	events = append(events, Press(2, firstNoteTime+0.93, 0.05)...)
+	
+	game := runHeadless(t, notes, events)
+	replay := game.BuildReplay()
+	
+	path := filepath.Join(t.TempDir(), "run.ghr")
+	if err := SaveReplay(path, replay); err != nil {
+		t.Fatalf("SaveReplay failed: %v", err)
+	}
+	loaded, err := LoadReplay(path)
+	if err != nil {
+		t.Fatalf("LoadReplay failed: %v", err)
+	}
+	if len(loaded.Events) != len(events) {
+		t.Fatalf("loaded %d events, want %d", len(loaded.Events), len(events))
+	}
+	
+	result, err := VerifyReplay(loaded, NewMIDIProcessorFromNotes(notes))
+	if err != nil {
+		t.Fatalf("VerifyReplay failed: %v", err)
+	}
+	if result != game.Result() {
+		t.Errorf("verified result %+v, want %+v", result, game.Result())
+	}
+}
+
+func TestReplayDetectsMismatch(t *testing.T) {
+	notes := []MIDINote{laneNote(0, 0.0, 0.1)}
+	
+	game := runHeadless(t, notes, Press(0, firstNoteTime, 0.05))
+	replay := game.BuildReplay()
+	
+	replay.Result.Score += 10
+	if _, err := VerifyReplay(replay, NewMIDIProcessorFromNotes(notes)); err == nil {
+		t.Errorf("expected a tampered score to fail verification")
+	}
+	
+	otherSong := []MIDINote{laneNote(1, 0.0, 0.1)}
+	if _, err := VerifyReplay(game.BuildReplay(), NewMIDIProcessorFromNotes(otherSong)); err == nil {
+		t.Errorf("expected a different song to fail verification")
+	}
+}
\ No newline at end of file
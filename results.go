@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+)
+
+// Results constants
+const (
+	HISTOGRAM_WINDOW    = 0.15 // Offsets shown on each side of the note, in seconds
+	HISTOGRAM_BIN_WIDTH = 0.02 // Width of one histogram bar, in seconds
+)
+
+// Grade thresholds on weighted accuracy, best grade first
+var gradeThresholds = []struct {
+	Grade   string
+	Minimum float64
+}{
+	{"S", 95},
+	{"A", 90},
+	{"B", 80},
+	{"C", 70},
+	{"D", 0},
+}
+
+// Star thresholds on the fraction of the maximum score, five stars first
+var starThresholds = []float64{0.95, 0.8, 0.6, 0.4}
+
+// WeightedAccuracy returns accuracy where Good and OK hits count for less than Perfect hits
+func (r GameResult) WeightedAccuracy() float64 {
+	if r.TotalNotes == 0 {
+		return 0
+	}
+	weighted := float64(r.PerfectHits) + 0.75*float64(r.GoodHits) + 0.5*float64(r.OKHits)
+	return weighted / float64(r.TotalNotes) * 100
+}
+
+// Grade returns the letter grade for the run
+func (r GameResult) Grade() string {
+	accuracy := r.WeightedAccuracy()
+	for _, threshold := range gradeThresholds {
+		if accuracy >= threshold.Minimum {
+			return threshold.Grade
+		}
+	}
+	return "D"
+}
+
+// Stars returns a 1 to 5 star rating from the score relative to the maximum possible score
+func (r GameResult) Stars() int {
+	if r.MaxScore <= 0 {
+		return 1
+	}
+	ratio := float64(r.Score) / float64(r.MaxScore)
+	for i, threshold := range starThresholds {
+		if ratio >= threshold {
+			return 5 - i
+		}
+	}
+	return 1
+}
+
+// IsFullCombo returns whether every note was hit without a miss or a combo-breaking press
+func (r GameResult) IsFullCombo() bool {
+	return r.TotalNotes > 0 && r.MissedHits == 0 && r.PerfectHits+r.GoodHits+r.OKHits == r.TotalNotes &&
+		r.GhostPresses+r.WrongLanePresses == 0
+}
+
+// IsAllPerfect returns whether every note was hit perfectly
+func (r GameResult) IsAllPerfect() bool {
+	return r.IsFullCombo() && r.PerfectHits == r.TotalNotes
+}
+
+// HitOffsetStats returns the mean and standard deviation of hit offsets in seconds,
+// where a positive mean means the player hits late on average
+func HitOffsetStats(offsets []float64) (mean, stdDev float64) {
+	if len(offsets) == 0 {
+		return 0, 0
+	}
+	
+	for _, offset := range offsets {
+		mean += offset
+	}
+	mean /= float64(len(offsets))
+	
+	for _, offset := range offsets {
+		stdDev += (offset - mean) * (offset - mean)
+	}
+	stdDev = math.Sqrt(stdDev / float64(len(offsets)))
+	
+	return mean, stdDev
+}
+
+// HitHistogram counts hit offsets into bins of HISTOGRAM_BIN_WIDTH covering
+// -HISTOGRAM_WINDOW to +HISTOGRAM_WINDOW, early hits first
+func HitHistogram(offsets []float64) []int {
+	binCount := int(math.Round(2 * HISTOGRAM_WINDOW / HISTOGRAM_BIN_WIDTH))
+	bins := make([]int, binCount)
+	
+	for _, offset := range offsets {
+		bin := int(math.Floor((offset + HISTOGRAM_WINDOW) / HISTOGRAM_BIN_WIDTH))
+		if bin < 0 {
+			bin = 0
+		} else if bin >= binCount {
+			bin = binCount - 1
+		}
+		bins[bin]++
+	}
+	
+	return bins
+}
\ No newline at end of file
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGradesAndStars(t *testing.T) {
+	tests := []struct {
+		name   string
+		result GameResult
+		grade  string
+		stars  int
+		fc     bool
+		ap     bool
+	}{
+		{
+			name:   "all perfect",
+			result: GameResult{Score: 1000, MaxScore: 1000, PerfectHits: 10, TotalNotes: 10},
+			grade:  "S", stars: 5, fc: true, ap: true,
+		},
+		{
+			name:   "full combo with goods",
+			result: GameResult{Score: 850, MaxScore: 1000, PerfectHits: 6, GoodHits: 4, TotalNotes: 10},
+			grade:  "A", stars: 4, fc: true,
+		},
+		{
+			name:   "a few misses",
+			result: GameResult{Score: 650, MaxScore: 1000, PerfectHits: 7, OKHits: 1, MissedHits: 2, TotalNotes: 10},
+			grade:  "C", stars: 3,
+		},
+		{
+			name:   "mostly missed",
+			result: GameResult{Score: 100, MaxScore: 1000, PerfectHits: 1, MissedHits: 9, TotalNotes: 10},
+			grade:  "D", stars: 1,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if grade := tt.result.Grade(); grade != tt.grade {
+				t.Errorf("Grade() = %s, want %s", grade, tt.grade)
+			}
+			if stars := tt.result.Stars(); stars != tt.stars {
+				t.Errorf("Stars() = %d, want %d", stars, tt.stars)
+			}
+			if fc := tt.result.IsFullCombo(); fc != tt.fc {
+				t.Errorf("IsFullCombo() = %v, want %v", fc, tt.fc)
+			}
+			if ap := tt.result.IsAllPerfect(); ap != tt.ap {
+				t.Errorf("IsAllPerfect() = %v, want %v", ap, tt.ap)
+			}
+		})
+	}
+}
+
+func TestMaxScoreMatchesPerfectRun(t *testing.T) {
+	notes := make([]MIDINote, 0)
+	for i := 0; i < 14; i++ {
+		duration := 0.1
+		if i%4 == 0 {
+			duration = 0.6
+		}
+		notes = append(notes, laneNote(i%3, float64(i)*0.8, duration))
+	}
+	
+	_, result, err := CheckChartWithBot(NewMIDIProcessorFromNotes(notes))
+	if err != nil {
+		t.Fatalf("CheckChartWithBot failed: %v", err)
+	}
+	if result.Score != result.MaxScore {
+		t.Errorf("perfect run scored %d, max score is %d", result.Score, result.MaxScore)
+	}
+}
+
+func TestHitHistogram(t *testing.T) {
+	bins := HitHistogram([]float64{-0.5, -0.149, 0.0, 0.001, 0.149, 0.3})
+	
+	if len(bins) != 15 {
+		t.Fatalf("got %d bins, want 15", len(bins))
+	}
+	// Out-of-window offsets are clamped into the outermost bins
+	if bins[0] != 2 || bins[14] != 2 || bins[7] != 2 {
+		t.Errorf("unexpected bins: %v", bins)
+	}
+}
+
+func TestHitOffsetStats(t *testing.T) {
+	mean, stdDev := HitOffsetStats([]float64{-0.01, 0.01, 0.03, 0.05})
+	
+	if math.Abs(mean-0.02) > 1e-9 {
+		t.Errorf("mean = %.4f, want 0.0200", mean)
+	}
+	if math.Abs(stdDev-math.Sqrt(0.0005)) > 1e-9 {
+		t.Errorf("stdDev = %.4f, want %.4f", stdDev, math.Sqrt(0.0005))
+	}
+	
+	if mean, stdDev := HitOffsetStats(nil); mean != 0 || stdDev != 0 {
+		t.Errorf("no hits should give zero stats, got %.4f and %.4f", mean, stdDev)
+	}
+}
\ No newline at end of file
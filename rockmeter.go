@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Rock meter constants
+const (
+	ROCK_METER_START  = 0.5  // Rock meter at the start of a run
+	ROCK_METER_DANGER = 0.25 // Below this the meter is in the red
+)
+
+// RockMeter returns the rock meter between 0 and 1
+func (g *Game) RockMeter() float64 {
+	return g.rockMeter
+}
+
+// SetNoFail turns the no-fail modifier on or off for the next runs
+func (g *Game) SetNoFail(noFail bool) {
+	g.noFail = noFail
+}
+
+// NoFail returns whether the no-fail modifier is on
+func (g *Game) NoFail() bool {
+	return g.noFail
+}
+
+// IsFailed returns whether the last run was failed
+func (g *Game) IsFailed() bool {
+	return g.state == StateFailed
+}
+
+// FailTime returns the song time at which the last run was failed
+func (g *Game) FailTime() float64 {
+	return g.failTime
+}
+
+// updateRockMeter moves the rock meter for a judged note and fails the song when it runs out
+func (g *Game) updateRockMeter(accuracy HitAccuracy) {
+	settings := g.difficulty.Settings()
+	switch accuracy {
+	case Perfect:
+		g.rockMeter += settings.RockMeterGain
+	case Good:
+		g.rockMeter += 0.75 * settings.RockMeterGain
+	case OK:
+		g.rockMeter += 0.5 * settings.RockMeterGain
+	case Miss:
+		g.rockMeter -= settings.RockMeterDrain
+	}
+	g.clampRockMeter()
+}
+	
+// drainRockMeter lowers the rock meter outside of note judgements
+func (g *Game) drainRockMeter(amount float64) {
+	g.rockMeter -= amount
+	g.clampRockMeter()
+}
+
+// clampRockMeter keeps the rock meter between 0 and 1 and fails the song when it runs out
+func (g *Game) clampRockMeter() {
+	if g.rockMeter > 1.0 {
+		g.rockMeter = 1.0
+	}
+	if g.rockMeter <= 0 {
+		g.rockMeter = 0
+		if !g.noFail && !g.practicing {
+			g.FailSong()
+		}
+	}
+}
+
+// FailSong ends the run early because the rock meter ran out
+func (g *Game) FailSong() {
+	g.state = StateFailed
+	g.failTime = g.currentTime
+	
+	if g.audioManager != nil {
+		g.audioManager.StopPlayback()
+	}
+	
+	fmt.Printf("Song failed at %.1fs! Score: %d\n", g.failTime, g.score)
+	
+	// Failed runs keep their replay but never make the leaderboard
+	g.saveReplay()
+}
\ No newline at end of file
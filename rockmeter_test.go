@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// unplayedNotes are ten notes a beat apart from 0 to 4.5s, for tests that press nothing
+func unplayedNotes() []MIDINote {
+	notes := make([]MIDINote, 0)
+	for i := 0; i < 10; i++ {
+		notes = append(notes, laneNote(i%3, float64(i)*0.5, 0.1))
+	}
+	return notes
+}
+	
+// onExpert plays on Expert, where misses drain the rock meter fastest
+func onExpert(midiProcessor *MIDIProcessor, game *Game) {
+	game.difficulty = DifficultyExpert
+}
+
+func TestRockMeterFailsSong(t *testing.T) {
+	game := runHeadless(t, unplayedNotes(), nil, onExpert)
+	
+	if !game.IsFailed() {
+		t.Fatalf("state = %d, want StateFailed", game.state)
+	}
+	// Expert drains 0.13 per miss from 0.5, so the fourth miss fails
+	if game.missedHits != 4 {
+		t.Errorf("missedHits = %d, want 4", game.missedHits)
+	}
+	if !game.Result().Failed {
+		t.Errorf("result should be marked as failed")
+	}
+	
+	result, err := VerifyReplay(game.BuildReplay(), game.midiProcessor)
+	if err != nil {
+		t.Fatalf("VerifyReplay failed: %v", err)
+	}
+	if !result.Failed {
+		t.Errorf("replay of a failed run should fail too")
+	}
+}
+
+func TestNoFailPlaysThrough(t *testing.T) {
+	game := runHeadless(t, unplayedNotes(), nil, onExpert, func(midiProcessor *MIDIProcessor, game *Game) {
+		game.SetNoFail(true)
+	})
+	
+	if !game.IsGameOver() {
+		t.Fatalf("state = %d, want StateGameOver", game.state)
+	}
+	if game.missedHits != 10 {
+		t.Errorf("missedHits = %d, want 10", game.missedHits)
+	}
+	if game.RockMeter() != 0 {
+		t.Errorf("rock meter = %f, want 0", game.RockMeter())
+	}
+}
+
+func TestRockMeterRisesOnHits(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.5, 0.1),
+		laneNote(2, 1.0, 0.1),
+	}
+	events := make([]InputEvent, 0)
+	events = append(events, Press(0, firstNoteTime, 0.05)...)
+	events = append(events, Press(1, firstNoteTime+0.5, 0.05)...)
+	events = append(events, Press(2, firstNoteTime+1.0, 0.05)...)
+	
+	game := runHeadless(t, notes, events)
+	
+	want := ROCK_METER_START + 3*DifficultyMedium.Settings().RockMeterGain
+	if math.Abs(game.RockMeter()-want) > 1e-9 {
+		t.Errorf("rock meter = %f, want %f", game.RockMeter(), want)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Score database constants
+const (
+	SCORES_FILE         = "scores.json"
+	SCORE_HISTORY_LIMIT = 100 // Entries kept per song and difficulty
+	LEADERBOARD_SIZE    = 10
+)
+
+// ScoreEntry is a single finished run stored in the score database
+type ScoreEntry struct {
+	Player     string           `json:"player"`
+	Result     GameResult       `json:"result"`
+	Date       time.Time        `json:"date"`
+	ReplayPath string           `json:"replay_path,omitempty"`
+	Judgement  JudgementProfile `json:"judgement"` // Timing windows the run was judged with
+	Modifiers  Modifiers        `json:"modifiers"`
+}
+
+// ScoreDatabase stores score history per song and difficulty in a JSON file
+type ScoreDatabase struct {
+	path   string
+	Scores map[string][]ScoreEntry `json:"scores"` // scoreKey -> entries sorted by score
+}
+
+// scoreKey builds the database key for a song and difficulty
+func scoreKey(songHash string, difficulty Difficulty) string {
+	return fmt.Sprintf("%s/%s", songHash, difficulty)
+}
+
+// LoadScoreDatabase loads the score database, starting empty if the file does not exist
+func LoadScoreDatabase(path string) (*ScoreDatabase, error) {
+	db := &ScoreDatabase{
+		path:   path,
+		Scores: make(map[string][]ScoreEntry),
+	}
+	
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read score database: %v", err)
+	}
+	
+	if err := json.Unmarshal(data, db); err != nil {
+		return nil, fmt.Errorf("failed to decode score database: %v", err)
+	}
+	if db.Scores == nil {
+		db.Scores = make(map[string][]ScoreEntry)
+	}
+	
+	// Scores saved before judgement profiles existed used the Standard windows
+	for _, entries := range db.Scores {
+		for i := range entries {
+			if entries[i].Judgement.Name == "" {
+				entries[i].Judgement = StandardJudgement()
+			}
+		}
+	}
+	
+	return db, nil
+}
+
+// LoadUserScoreDatabase loads the score database from the user data directory
+func LoadUserScoreDatabase() (*ScoreDatabase, error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return LoadScoreDatabase(filepath.Join(dataDir, SCORES_FILE))
+}
+
+// Save writes the score database to disk
+func (db *ScoreDatabase) Save() error {
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode score database: %v", err)
+	}
+	
+	// Write to a temporary file first so a crash never leaves a truncated database
+	tmpPath := db.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write score database: %v", err)
+	}
+	if err := os.Rename(tmpPath, db.path); err != nil {
+		return fmt.Errorf("failed to replace score database: %v", err)
+	}
+	
+	return nil
+}
+
+// AddScore records a run and returns its 1-based rank for the song and difficulty
+func (db *ScoreDatabase) AddScore(songHash string, difficulty Difficulty, entry ScoreEntry) int {
+	key := scoreKey(songHash, difficulty)
+	entries := append(db.Scores[key], entry)
+	
+	// Highest score first, earlier runs win ties
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Result.Score > entries[j].Result.Score
+	})
+	
+	rank := 0
+	for i := range entries {
+		if entries[i].Date.Equal(entry.Date) && entries[i].Result == entry.Result {
+			rank = i + 1
+			break
+		}
+	}
+	
+	if len(entries) > SCORE_HISTORY_LIMIT {
+		entries = entries[:SCORE_HISTORY_LIMIT]
+	}
+	if rank > SCORE_HISTORY_LIMIT {
+		rank = 0 // Dropped from the history straight away
+	}
+	db.Scores[key] = entries
+	
+	return rank
+}
+
+// TopScores returns up to n of the best scores for a song and difficulty
+func (db *ScoreDatabase) TopScores(songHash string, difficulty Difficulty, n int) []ScoreEntry {
+	entries := db.Scores[scoreKey(songHash, difficulty)]
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// PersonalBest returns the player's best score for a song and difficulty
+func (db *ScoreDatabase) PersonalBest(songHash string, difficulty Difficulty, player string) (ScoreEntry, bool) {
+	for _, entry := range db.Scores[scoreKey(songHash, difficulty)] {
+		if entry.Player == player {
+			return entry, true
+		}
+	}
+	return ScoreEntry{}, false
+}
+
+// currentPlayerName returns the name scores are recorded under
+func currentPlayerName() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return "Player"
+}
\ No newline at end of file
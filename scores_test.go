@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScoreDatabaseRanksAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SCORES_FILE)
+	db, err := LoadScoreDatabase(path)
+	if err != nil {
+		t.Fatalf("LoadScoreDatabase failed: %v", err)
+	}
+	
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	scores := []int32{500, 900, 700}
+	wantRanks := []int{1, 1, 2}
+	for i, score := range scores {
+		entry := ScoreEntry{
+			Player: "alice",
+			Result: GameResult{Score: score, TotalNotes: 10},
+			Date:   start.Add(time.Duration(i) * time.Minute),
+		}
+		if rank := db.AddScore("song", DifficultyHard, entry); rank != wantRanks[i] {
+			t.Errorf("score %d ranked %d, want %d", score, rank, wantRanks[i])
+		}
+	}
+	db.AddScore("song", DifficultyEasy, ScoreEntry{Player: "bob", Result: GameResult{Score: 9999}, Date: start})
+	
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadScoreDatabase(path)
+	if err != nil {
+		t.Fatalf("reloading failed: %v", err)
+	}
+	
+	top := loaded.TopScores("song", DifficultyHard, LEADERBOARD_SIZE)
+	if len(top) != 3 || top[0].Result.Score != 900 || top[2].Result.Score != 500 {
+		t.Errorf("unexpected leaderboard: %+v", top)
+	}
+	
+	best, ok := loaded.PersonalBest("song", DifficultyHard, "alice")
+	if !ok || best.Result.Score != 900 {
+		t.Errorf("personal best = %+v, %v, want 900", best, ok)
+	}
+	if _, ok := loaded.PersonalBest("song", DifficultyHard, "bob"); ok {
+		t.Errorf("bob has no scores on hard")
+	}
+}
\ No newline at end of file
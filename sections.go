@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Section constants
+const (
+	SECTION_SILENCE_GAP   = 2.0  // Seconds without notes that split a song without section markers
+	WEAK_SECTION_ACCURACY = 90.0 // Sections hit below this percentage are shown as weak
+	MAX_WEAK_SECTIONS     = 3    // Most weak sections shown on the results screen
+)
+
+// SectionStats counts the judgements in one section of the song
+type SectionStats struct {
+	Hits   int
+	Misses int
+}
+
+// SectionResult is how a section of the song was played
+type SectionResult struct {
+	Section
+	SectionStats
+}
+
+// Accuracy returns the percentage of judged notes in the section that were hit
+func (r SectionResult) Accuracy() float64 {
+	judged := r.Hits + r.Misses
+	if judged == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(judged) * 100
+}
+
+// sectionName reads a section name from a text or marker event. Both accept the
+// "[section verse_1]" and "[prc_verse_1]" forms, markers may also be plain names.
+func sectionName(text string, isMarker bool) (string, bool) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		inner := strings.TrimSpace(text[1 : len(text)-1])
+		switch {
+		case strings.HasPrefix(inner, "section "):
+			text = strings.TrimPrefix(inner, "section ")
+		case strings.HasPrefix(inner, "prc_"):
+			text = strings.TrimPrefix(inner, "prc_")
+		default:
+			// Other bracketed events are cues like [play] or [end]
+			return "", false
+		}
+		
+		// Chart names like verse_1 are shown as "Verse 1"
+		text = strings.TrimSpace(strings.ReplaceAll(text, "_", " "))
+		if text != "" {
+			text = strings.ToUpper(text[:1]) + text[1:]
+		}
+	} else if !isMarker {
+		// Plain text events are lyrics and comments
+		return "", false
+	}
+	
+	return text, text != ""
+}
+
+// SilenceSections splits a song without section markers into parts wherever no
+// note is playing for SECTION_SILENCE_GAP seconds
+func SilenceSections(notes []MIDINote) []Section {
+	if len(notes) == 0 {
+		return nil
+	}
+	
+	sorted := make([]MIDINote, len(notes))
+	copy(sorted, notes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartTime < sorted[j].StartTime
+	})
+	
+	sections := []Section{{StartTime: sorted[0].StartTime}}
+	end := sorted[0].StartTime + sorted[0].Duration
+	for _, note := range sorted[1:] {
+		if note.StartTime-end >= SECTION_SILENCE_GAP {
+			sections[len(sections)-1].EndTime = note.StartTime
+			sections = append(sections, Section{StartTime: note.StartTime})
+		}
+		end = max(end, note.StartTime+note.Duration)
+	}
+	sections[len(sections)-1].EndTime = end
+	
+	for i := range sections {
+		sections[i].Name = fmt.Sprintf("Part %d", i+1)
+	}
+	return sections
+}
+
+// assignSections records which section each note is in
+func (g *Game) assignSections() {
+	for i := range g.gameNotes {
+		note := &g.gameNotes[i]
+		note.Section = -1
+		for index, section := range g.sections {
+			if note.StartTime >= section.StartTime && note.StartTime < section.EndTime {
+				note.Section = index
+				break
+			}
+		}
+	}
+}
+
+// recordSectionJudgement counts a judged note towards its section
+func (g *Game) recordSectionJudgement(note *GameNote, accuracy HitAccuracy) {
+	if note.Section < 0 || note.Section >= len(g.sectionStats) {
+		return
+	}
+	if accuracy == Miss {
+		g.sectionStats[note.Section].Misses++
+	} else {
+		g.sectionStats[note.Section].Hits++
+	}
+}
+
+// SectionResults returns how each section with judged notes was played in the last run
+func (g *Game) SectionResults() []SectionResult {
+	results := make([]SectionResult, 0, len(g.sectionStats))
+	for i, stats := range g.sectionStats {
+		if i >= len(g.sections) || stats.Hits+stats.Misses == 0 {
+			continue
+		}
+		results = append(results, SectionResult{Section: g.sections[i], SectionStats: stats})
+	}
+	return results
+}
+
+// WeakSections returns up to limit sections played below WEAK_SECTION_ACCURACY, worst first
+func WeakSections(results []SectionResult, limit int) []SectionResult {
+	weak := make([]SectionResult, 0)
+	for _, result := range results {
+		if result.Accuracy() < WEAK_SECTION_ACCURACY {
+			weak = append(weak, result)
+		}
+	}
+	sort.SliceStable(weak, func(i, j int) bool {
+		return weak[i].Accuracy() < weak[j].Accuracy()
+	})
+	
+	if len(weak) > limit {
+		weak = weak[:limit]
+	}
+	return weak
+}
\ No newline at end of file
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSectionName(t *testing.T) {
+	tests := []struct {
+		text     string
+		isMarker bool
+		name     string
+		ok       bool
+	}{
+		{"[section verse_1]", false, "Verse 1", true},
+		{"[section guitar_solo]", true, "Guitar solo", true},
+		{"[prc_chorus_2]", false, "Chorus 2", true},
+		{" Bridge ", true, "Bridge", true},
+		{"Bridge", false, "", false},
+		{"[play]", true, "", false},
+		{"[section ]", false, "", false},
+		{"", true, "", false},
+	}
+	
+	for _, tt := range tests {
+		name, ok := sectionName(tt.text, tt.isMarker)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("sectionName(%q, %v) = %q, %v, want %q, %v", tt.text, tt.isMarker, name, ok, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestTextEventSections(t *testing.T) {
+	text := func(delta int, metaType byte, text string) []byte {
+		return midiEvent(delta, append([]byte{0xFF, metaType, byte(len(text))}, text...)...)
+	}
+	eventsTrack := midiTrackChunk(
+		text(0, 0x01, "[section intro]"),
+		text(480, 0x01, "some lyric"),
+		text(480, 0x01, "[section verse_1]"),
+		text(960, 0x06, "[end]"),
+	)
+	noteTrack := midiTrackChunk(
+		midiEvent(0, 0x90, 64, 100),
+		midiEvent(3840, 0x80, 64, 0),
+	)
+	
+	mp := NewMIDIProcessor()
+	if err := mp.LoadMIDI(writeMIDIFile(t, 480, eventsTrack, noteTrack)); err != nil {
+		t.Fatalf("LoadMIDI failed: %v", err)
+	}
+	track, err := mp.FindGuitarTrack()
+	if err != nil {
+		t.Fatalf("FindGuitarTrack failed: %v", err)
+	}
+	
+	want := []Section{{"Intro", 0, 1}, {"Verse 1", 1, 4}}
+	if len(track.Sections) != len(want) {
+		t.Fatalf("Sections = %+v, want %+v", track.Sections, want)
+	}
+	for i, section := range track.Sections {
+		if section != want[i] {
+			t.Errorf("section %d = %+v, want %+v", i, section, want[i])
+		}
+	}
+}
+
+func TestSilenceSections(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0, 0.5),
+		laneNote(1, 1, 3), // Rings over the gap before the next note
+		laneNote(2, 5, 0.1),
+		laneNote(0, 7.5, 0.5),
+		laneNote(1, 8, 0.1),
+	}
+	
+	want := []Section{{"Part 1", 0, 7.5}, {"Part 2", 7.5, 8.1}}
+	sections := SilenceSections(notes)
+	if len(sections) != len(want) {
+		t.Fatalf("SilenceSections = %+v, want %+v", sections, want)
+	}
+	for i, section := range sections {
+		if section != want[i] {
+			t.Errorf("section %d = %+v, want %+v", i, section, want[i])
+		}
+	}
+	
+	if sections := SilenceSections(nil); len(sections) != 0 {
+		t.Errorf("SilenceSections(nil) = %+v, want none", sections)
+	}
+}
+
+func TestSectionResults(t *testing.T) {
+	// Two parts split by silence, with a note missed in the second
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.5, 0.1),
+		laneNote(2, 3.0, 0.1),
+		laneNote(0, 3.5, 0.1),
+	}
+	events := make([]InputEvent, 0)
+	events = append(events, Press(0, firstNoteTime, 0.05)...)
+	events = append(events, Press(1, firstNoteTime+0.5, 0.05)...)
+	events = append(events, Press(2, firstNoteTime+3.0, 0.05)...)
+	
+	game := runHeadless(t, notes, events)
+	
+	results := game.SectionResults()
+	if len(results) != 2 {
+		t.Fatalf("SectionResults = %+v, want 2 sections", results)
+	}
+	if results[0].Hits != 2 || results[0].Misses != 0 {
+		t.Errorf("first section = %+v, want 2 hits", results[0])
+	}
+	if results[1].Hits != 1 || results[1].Misses != 1 {
+		t.Errorf("second section = %+v, want 1 hit and 1 miss", results[1])
+	}
+	
+	weak := WeakSections(results, MAX_WEAK_SECTIONS)
+	if len(weak) != 1 || weak[0].Name != "Part 2" || weak[0].Accuracy() != 50 {
+		t.Errorf("WeakSections = %+v, want Part 2 at 50%%", weak)
+	}
+}
+
+func TestWeakSectionsWorstFirst(t *testing.T) {
+	results := []SectionResult{
+		{Section{Name: "Intro"}, SectionStats{Hits: 9, Misses: 1}},
+		{Section{Name: "Verse"}, SectionStats{Hits: 1, Misses: 3}},
+		{Section{Name: "Chorus"}, SectionStats{Hits: 3, Misses: 3}},
+		{Section{Name: "Solo"}, SectionStats{Hits: 0, Misses: 5}},
+	}
+	
+	weak := WeakSections(results, 2)
+	if len(weak) != 2 || weak[0].Name != "Solo" || weak[1].Name != "Verse" {
+		t.Errorf("WeakSections = %+v, want Solo then Verse", weak)
+	}
+}
\ No newline at end of file
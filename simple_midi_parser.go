@@ -3,21 +3,69 @@ package main
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 )
 
 // SimpleMIDIParser provides basic MIDI parsing functionality
 type SimpleMIDIParser struct {
-	data         []byte
-	position     int
-	ticksPerBeat int
-	tempo        int // microseconds per beat
+	data     []byte
+	position int
+	output   io.Writer // Where parsing progress is printed
+	
+	// Tempo map shared by every track, changes sorted by tick
+	tempoMap TempoMap
+	
+	// Text and marker events naming song sections, in file order
+	sectionEvents []textEvent
+	
+	// Name and instrument of each track, in file order
+	tracks []trackInfo
+	
+	// Note ons and offs without their pair, in file order
+	dangling []danglingNote
+}
+
+// tempoChange is a set tempo meta event
+type tempoChange struct {
+	Tick          int
+	MicrosPerBeat int
+}
+
+// timeSignature is a time signature meta event
+type timeSignature struct {
+	Tick        int
+	Numerator   int
+	Denominator int // Note value of one beat, 4 for quarter notes
+}
+
+// trackInfo is the name and instrument of a track
+type trackInfo struct {
+	Name    string
+	Channel int // Channel of the track's first note
+	Program int // Program of the track's first program change, -1 without one
+	Notes   int
+}
+
+// danglingNote is a note on or off event without its pair
+type danglingNote struct {
+	Time  float64
+	Pitch int
+	Off   bool // A note off with no note on before it, otherwise a note on never turned off
+}
+
+// textEvent is a text-like meta event
+type textEvent struct {
+	Tick int
+	Text string
 }
 
 // NewSimpleMIDIParser creates a new simple MIDI parser
 func NewSimpleMIDIParser() *SimpleMIDIParser {
 	return &SimpleMIDIParser{
-		tempo: 500000, // Default 120 BPM (500000 microseconds per beat)
+		output:   os.Stdout,
+		tempoMap: DefaultTempoMap(), // Until the file's own tempo events are read
 	}
 }
 
@@ -41,7 +89,7 @@ func (p *SimpleMIDIParser) ParseFile(filepath string) ([]MIDINote, error) {
 	for p.position < len(p.data) {
 		trackNotes, err := p.parseTrack()
 		if err != nil {
-			fmt.Printf("Warning: failed to parse track: %v\n", err)
+			fmt.Fprintf(p.output, "Warning: failed to parse track: %v\n", err)
 			break
 		}
 		notes = append(notes, trackNotes...)
@@ -70,10 +118,10 @@ func (p *SimpleMIDIParser) parseHeader() error {
 	division := binary.BigEndian.Uint16(p.data[p.position+4:])
 	
 	p.position += 6
-	p.ticksPerBeat = int(division)
+	p.tempoMap.TicksPerBeat = int(division)
 	
-	fmt.Printf("MIDI Header: Format %d, %d tracks, %d ticks per beat\n", 
-		format, numTracks, p.ticksPerBeat)
+	fmt.Fprintf(p.output, "MIDI Header: Format %d, %d tracks, %d ticks per beat\n",
+		format, numTracks, p.tempoMap.TicksPerBeat)
 	
 	return nil
 }
@@ -94,7 +142,7 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 	trackStart := p.position + 8
 	trackEnd := trackStart + int(trackLength)
 	
-	fmt.Printf("Parsing track: %d bytes\n", trackLength)
+	fmt.Fprintf(p.output, "Parsing track: %d bytes\n", trackLength)
 	
 	p.position = trackStart
 	
@@ -103,6 +151,7 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 	
 	currentTick := 0
 	runningStatus := byte(0)
+	info := trackInfo{Program: -1}
 	
 	for p.position < trackEnd {
 		// Read delta time
@@ -111,6 +160,9 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 			break
 		}
 		currentTick += deltaTime
+		if currentTick > p.tempoMap.EndTick {
+			p.tempoMap.EndTick = currentTick
+		}
 		
 		if p.position >= trackEnd {
 			break
@@ -122,9 +174,11 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 		
 		var status byte
 		if eventByte >= 0x80 {
-			// Status byte
+			// Status byte, only channel messages set the running status
 			status = eventByte
-			runningStatus = status
+			if status < 0xF0 {
+				runningStatus = status
+			}
 		} else {
 			// Data byte, use running status
 			status = runningStatus
@@ -142,11 +196,21 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 			p.position += 2
 			
 			if velocity > 0 {
+				if info.Notes == 0 {
+					info.Channel = int(status & 0x0F)
+				}
+				info.Notes++
+				
+				// A note on replacing one still playing leaves the first without an end
+				if activeNote, exists := activeNotes[pitch]; exists {
+					p.dangling = append(p.dangling, danglingNote{Time: activeNote.StartTime, Pitch: pitch})
+				}
+				
 				// Start new note
 				note := &MIDINote{
 					Pitch:     pitch,
 					Velocity:  velocity,
-					StartTime: p.ticksToSeconds(currentTick),
+					StartTime: p.tempoMap.TicksToSeconds(currentTick),
 					Duration:  0,
 					Lane:      0, // Will be assigned later
 				}
@@ -154,9 +218,11 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 			} else {
 				// Note on with velocity 0 = note off
 				if activeNote, exists := activeNotes[pitch]; exists {
-					activeNote.Duration = p.ticksToSeconds(currentTick) - activeNote.StartTime
+					activeNote.Duration = p.tempoMap.TicksToSeconds(currentTick) - activeNote.StartTime
 					notes = append(notes, *activeNote)
 					delete(activeNotes, pitch)
+				} else {
+					p.dangling = append(p.dangling, danglingNote{Time: p.tempoMap.TicksToSeconds(currentTick), Pitch: pitch, Off: true})
 				}
 			}
 			
@@ -168,12 +234,22 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 			p.position += 2 // Skip velocity
 			
 			if activeNote, exists := activeNotes[pitch]; exists {
-				activeNote.Duration = p.ticksToSeconds(currentTick) - activeNote.StartTime
+				activeNote.Duration = p.tempoMap.TicksToSeconds(currentTick) - activeNote.StartTime
 				notes = append(notes, *activeNote)
 				delete(activeNotes, pitch)
+			} else {
+				p.dangling = append(p.dangling, danglingNote{Time: p.tempoMap.TicksToSeconds(currentTick), Pitch: pitch, Off: true})
 			}
 			
-		case 0xFF: // Meta event
+		case 0xF0: // Meta and system exclusive events
+			if status != 0xFF {
+				// System exclusive data is skipped by its length
+				length, err := p.readVariableLength()
+				if err == nil {
+					p.position += length
+				}
+				break
+			}
 			if p.position >= trackEnd {
 				break
 			}
@@ -190,14 +266,38 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 				tempo := int(p.data[p.position])<<16 | 
 						int(p.data[p.position+1])<<8 | 
 						int(p.data[p.position+2])
-				p.tempo = tempo
-				fmt.Printf("Tempo change: %d microseconds per beat\n", tempo)
+				p.addTempo(currentTick, tempo)
+				fmt.Fprintf(p.output, "Tempo change: %d microseconds per beat\n", tempo)
+			}
+			
+			// Handle time signatures, the denominator is stored as a power of two
+			if metaType == 0x58 && length >= 2 {
+				numerator := int(p.data[p.position])
+				denominator := 1 << p.data[p.position+1]
+				p.addTimeSignature(currentTick, numerator, denominator)
+				fmt.Fprintf(p.output, "Time signature: %d/%d\n", numerator, denominator)
+			}
+			
+			// The first track name meta event names the track
+			if metaType == 0x03 && info.Name == "" {
+				info.Name = string(p.data[p.position : p.position+length])
+			}
+			
+			// Text and marker events name the sections of the song
+			if metaType == 0x01 || metaType == 0x06 {
+				text := string(p.data[p.position : p.position+length])
+				if name, ok := sectionName(text, metaType == 0x06); ok {
+					p.sectionEvents = append(p.sectionEvents, textEvent{Tick: currentTick, Text: name})
+				}
 			}
 			
 			p.position += length
 			
 		default:
 			// Skip other events
+			if status&0xF0 == 0xC0 && info.Program < 0 && p.position < trackEnd {
+				info.Program = int(p.data[p.position])
+			}
 			if status < 0xF0 {
 				// Channel message, skip appropriate number of data bytes
 				dataBytes := []int{2, 2, 2, 2, 1, 1, 2}
@@ -217,11 +317,13 @@ func (p *SimpleMIDIParser) parseTrack() ([]MIDINote, error) {
 	for _, activeNote := range activeNotes {
 		activeNote.Duration = 0.5 // Default duration
 		notes = append(notes, *activeNote)
+		p.dangling = append(p.dangling, danglingNote{Time: activeNote.StartTime, Pitch: activeNote.Pitch})
 	}
 	
 	p.position = trackEnd
+	p.tracks = append(p.tracks, info)
 	
-	fmt.Printf("Extracted %d notes from track\n", len(notes))
+	fmt.Fprintf(p.output, "Extracted %d notes from track\n", len(notes))
 	return notes, nil
 }
 
@@ -245,9 +347,119 @@ func (p *SimpleMIDIParser) readVariableLength() (int, error) {
 	return value, nil
 }
 
-// ticksToSeconds converts MIDI ticks to seconds
-func (p *SimpleMIDIParser) ticksToSeconds(ticks int) float64 {
-	// Convert ticks to seconds using current tempo
-	secondsPerTick := float64(p.tempo) / (float64(p.ticksPerBeat) * 1000000.0)
-	return float64(ticks) * secondsPerTick
+// addTempo records a tempo change, replacing any earlier change at the same tick
+func (p *SimpleMIDIParser) addTempo(tick, microsPerBeat int) {
+	i := sort.Search(len(p.tempoMap.Tempos), func(i int) bool { return p.tempoMap.Tempos[i].Tick >= tick })
+	if i < len(p.tempoMap.Tempos) && p.tempoMap.Tempos[i].Tick == tick {
+		p.tempoMap.Tempos[i].MicrosPerBeat = microsPerBeat
+		return
+	}
+	p.tempoMap.Tempos = append(p.tempoMap.Tempos, tempoChange{})
+	copy(p.tempoMap.Tempos[i+1:], p.tempoMap.Tempos[i:])
+	p.tempoMap.Tempos[i] = tempoChange{Tick: tick, MicrosPerBeat: microsPerBeat}
+}
+
+// addTimeSignature records a time signature, replacing any earlier one at the same tick
+func (p *SimpleMIDIParser) addTimeSignature(tick, numerator, denominator int) {
+	if numerator <= 0 || denominator <= 0 {
+		return
+	}
+	
+	signature := timeSignature{Tick: tick, Numerator: numerator, Denominator: denominator}
+	i := sort.Search(len(p.tempoMap.TimeSignatures), func(i int) bool { return p.tempoMap.TimeSignatures[i].Tick >= tick })
+	if i < len(p.tempoMap.TimeSignatures) && p.tempoMap.TimeSignatures[i].Tick == tick {
+		p.tempoMap.TimeSignatures[i] = signature
+		return
+	}
+	p.tempoMap.TimeSignatures = append(p.tempoMap.TimeSignatures, timeSignature{})
+	copy(p.tempoMap.TimeSignatures[i+1:], p.tempoMap.TimeSignatures[i:])
+	p.tempoMap.TimeSignatures[i] = signature
+}
+
+// TempoMap returns the file's tick resolution, tempo changes and time signatures
+func (p *SimpleMIDIParser) TempoMap() TempoMap {
+	tempoMap := p.tempoMap
+	tempoMap.Tempos = make([]tempoChange, len(p.tempoMap.Tempos))
+	tempoMap.TimeSignatures = make([]timeSignature, len(p.tempoMap.TimeSignatures))
+	copy(tempoMap.Tempos, p.tempoMap.Tempos)
+	copy(tempoMap.TimeSignatures, p.tempoMap.TimeSignatures)
+	return tempoMap
+}
+
+// BeatMap returns every beat from the start of the song to its last event. Beats
+// follow the time signature's note value, and a time signature change starts a
+// new measure.
+func (p *SimpleMIDIParser) BeatMap() []Beat {
+	beats := make([]Beat, 0)
+	if p.tempoMap.TicksPerBeat <= 0 {
+		return beats
+	}
+	
+	signature := 0
+	measure, index := 1, 0
+	for tick := 0; tick <= p.tempoMap.EndTick; {
+		for signature+1 < len(p.tempoMap.TimeSignatures) && p.tempoMap.TimeSignatures[signature+1].Tick <= tick {
+			signature++
+			if index != 0 {
+				measure++
+				index = 0
+			}
+		}
+		current := p.tempoMap.TimeSignatures[signature]
+		
+		beats = append(beats, Beat{
+			Time:    p.tempoMap.TicksToSeconds(tick),
+			Measure: measure,
+			Index:   index,
+			BPM:     60000000.0 / float64(p.tempoMap.TempoAt(tick)),
+		})
+		
+		tick += max(p.tempoMap.TicksPerBeat*4/current.Denominator, 1)
+		index++
+		if index >= current.Numerator {
+			measure++
+			index = 0
+		}
+	}
+	
+	return beats
+}
+
+// Dangling returns the note ons and offs that had no pair, in file order
+func (p *SimpleMIDIParser) Dangling() []danglingNote {
+	return p.dangling
+}
+
+// NoteTrack returns the name and instrument of the first track with notes
+func (p *SimpleMIDIParser) NoteTrack() (trackInfo, bool) {
+	for _, info := range p.tracks {
+		if info.Notes > 0 {
+			return info, true
+		}
+	}
+	return trackInfo{}, false
+}
+
+// Sections returns the song sections named by text and marker events, each lasting
+// until the next one and the last until the end of the song
+func (p *SimpleMIDIParser) Sections() []Section {
+	events := make([]textEvent, len(p.sectionEvents))
+	copy(events, p.sectionEvents)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Tick < events[j].Tick
+	})
+	
+	sections := make([]Section, 0, len(events))
+	for _, event := range events {
+		start := p.tempoMap.TicksToSeconds(event.Tick)
+		if len(sections) > 0 {
+			sections[len(sections)-1].EndTime = start
+		}
+		sections = append(sections, Section{Name: event.Text, StartTime: start})
+	}
+	if len(sections) > 0 {
+		sections[len(sections)-1].EndTime = p.tempoMap.TicksToSeconds(p.tempoMap.EndTick)
+	}
+	
+	return sections
 }
\ No newline at end of file
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SongEntry is a song that can be picked on the song select screen
+type SongEntry struct {
+	Name string
+	Path string
+	Hash string
+	
+	Rating float64 // Difficulty rating of the guitar track, 0 if it could not be read
+}
+
+// ScanSongs finds the MIDI files in a directory
+func ScanSongs(dir string) ([]SongEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read song directory: %v", err)
+	}
+	
+	songs := make([]SongEntry, 0)
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if file.IsDir() || (ext != ".mid" && ext != ".midi") {
+			continue
+		}
+		
+		path := filepath.Join(dir, file.Name())
+		hash, err := hashFile(path)
+		if err != nil {
+			fmt.Printf("Warning: Skipping song %s: %v\n", path, err)
+			continue
+		}
+		
+		songs = append(songs, SongEntry{
+			Name:   strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())),
+			Path:   path,
+			Hash:   hash,
+			Rating: RateSong(path),
+		})
+	}
+	
+	sort.Slice(songs, func(i, j int) bool {
+		return songs[i].Name < songs[j].Name
+	})
+	
+	return songs, nil
+}
+
+// hashFile returns the SHA-256 hash of a file's content
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
\ No newline at end of file
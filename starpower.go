@@ -0,0 +1,166 @@
+package main
+
+import (
+	"sort"
+)
+
+// Scoring and Star Power constants
+const (
+	MAX_MULTIPLIER          = 4     // Highest combo multiplier
+	COMBO_PER_MULTIPLIER    = 10    // Combo needed for each multiplier step
+	STAR_POWER_PER_PHRASE   = 0.25  // Meter gained by completing a Star Power phrase
+	STAR_POWER_MIN_METER    = 0.5   // Meter needed to activate Star Power
+	STAR_POWER_DRAIN_RATE   = 0.125 // Meter drained per second while active (8 seconds for a full meter)
+	STAR_POWER_MARKER_PITCH = 116   // MIDI note marking Star Power phrases in charts
+	STAR_PHRASE_LENGTH      = 8     // Notes per auto-generated phrase
+	STAR_PHRASE_INTERVAL    = 3     // Every third group of notes becomes an auto-generated phrase
+)
+
+// StarPhrase tracks the notes of one Star Power phrase during play
+type StarPhrase struct {
+	NoteCount   int
+	JudgedNotes int
+	Broken      bool // A note in the phrase was missed
+}
+
+// comboMultiplier returns the score multiplier for a combo, without Star Power
+func comboMultiplier(combo int32) int32 {
+	multiplier := 1 + combo/COMBO_PER_MULTIPLIER
+	if multiplier > MAX_MULTIPLIER {
+		multiplier = MAX_MULTIPLIER
+	}
+	return multiplier
+}
+
+// ScoreMultiplier returns the current score multiplier, doubled while Star Power is active
+func (g *Game) ScoreMultiplier() int32 {
+	multiplier := comboMultiplier(g.combo)
+	if g.IsStarPowerActive() {
+		multiplier *= 2
+	}
+	return multiplier
+}
+
+// IsStarPowerActive returns whether Star Power is active at the current time
+func (g *Game) IsStarPowerActive() bool {
+	return g.currentTime < g.starPowerEndTime
+}
+
+// StarPowerMeter returns the Star Power meter between 0 and 1
+func (g *Game) StarPowerMeter() float64 {
+	if g.IsStarPowerActive() {
+		return (g.starPowerEndTime - g.currentTime) * STAR_POWER_DRAIN_RATE
+	}
+	return g.starPowerMeter
+}
+
+// CanActivateStarPower returns whether the meter is full enough to activate Star Power
+func (g *Game) CanActivateStarPower() bool {
+	return !g.IsStarPowerActive() && g.starPowerMeter >= STAR_POWER_MIN_METER
+}
+
+// activateStarPower starts draining the meter to double the multiplier
+func (g *Game) activateStarPower() {
+	if !g.CanActivateStarPower() {
+		return
+	}
+	
+	// The end time is fixed up front so the effect never depends on the frame rate
+	g.starPowerEndTime = g.currentTime + g.starPowerMeter/STAR_POWER_DRAIN_RATE
+	g.starPowerMeter = 0
+}
+
+// addStarPower adds to the meter, or extends Star Power if it is active
+func (g *Game) addStarPower(amount float64) {
+	if g.IsStarPowerActive() {
+		remaining := g.StarPowerMeter()
+		if remaining+amount > 1.0 {
+			amount = 1.0 - remaining
+		}
+		g.starPowerEndTime += amount / STAR_POWER_DRAIN_RATE
+		return
+	}
+	
+	g.starPowerMeter += amount
+	if g.starPowerMeter > 1.0 {
+		g.starPowerMeter = 1.0
+	}
+}
+
+// updateStarPhrase records a judged note against its Star Power phrase
+func (g *Game) updateStarPhrase(note *GameNote, accuracy HitAccuracy) {
+	if note.StarPhrase < 0 || note.StarPhrase >= len(g.starPhrases) {
+		return
+	}
+	
+	phrase := &g.starPhrases[note.StarPhrase]
+	phrase.JudgedNotes++
+	if accuracy == Miss {
+		phrase.Broken = true
+	}
+	
+	// Completing a phrase without a miss fills the meter
+	if phrase.JudgedNotes == phrase.NoteCount && !phrase.Broken {
+		g.addStarPower(STAR_POWER_PER_PHRASE)
+	}
+}
+
+// resetStarPower clears the meter and phrase progress for a new run
+func (g *Game) resetStarPower() {
+	g.starPowerMeter = 0
+	g.starPowerEndTime = 0
+	for i := range g.starPhrases {
+		g.starPhrases[i].JudgedNotes = 0
+		g.starPhrases[i].Broken = false
+	}
+}
+
+// assignStarPhrases marks the notes belonging to Star Power phrases, using
+// the chart's phrases when it has any and generating them otherwise
+func (g *Game) assignStarPhrases(chartPhrases []Phrase) {
+	g.starPhrases = make([]StarPhrase, 0)
+	
+	// Notes in time order
+	order := make([]int, len(g.gameNotes))
+	for i := range order {
+		order[i] = i
+		g.gameNotes[i].StarPhrase = -1
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return g.gameNotes[order[a]].StartTime < g.gameNotes[order[b]].StartTime
+	})
+	
+	if len(chartPhrases) > 0 {
+		for _, phrase := range chartPhrases {
+			count := 0
+			for _, i := range order {
+				note := &g.gameNotes[i]
+				if note.StartTime >= phrase.StartTime && note.StartTime < phrase.EndTime {
+					note.StarPhrase = len(g.starPhrases)
+					count++
+				}
+			}
+			if count > 0 {
+				g.starPhrases = append(g.starPhrases, StarPhrase{NoteCount: count})
+			}
+		}
+		return
+	}
+	
+	// No phrases in the chart, mark every few groups of notes
+	for start := 0; start < len(order); start += STAR_PHRASE_LENGTH {
+		group := start / STAR_PHRASE_LENGTH
+		if group%STAR_PHRASE_INTERVAL != 1 {
+			continue
+		}
+		
+		end := start + STAR_PHRASE_LENGTH
+		if end > len(order) {
+			end = len(order)
+		}
+		for _, i := range order[start:end] {
+			g.gameNotes[i].StarPhrase = len(g.starPhrases)
+		}
+		g.starPhrases = append(g.starPhrases, StarPhrase{NoteCount: end - start})
+	}
+}
\ No newline at end of file
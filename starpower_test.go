@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestComboMultiplierSteps(t *testing.T) {
+	tests := []struct {
+		combo int32
+		want  int32
+	}{
+		{0, 1}, {9, 1}, {10, 2}, {19, 2}, {20, 3}, {30, 4}, {100, 4},
+	}
+	for _, tt := range tests {
+		if got := comboMultiplier(tt.combo); got != tt.want {
+			t.Errorf("comboMultiplier(%d) = %d, want %d", tt.combo, got, tt.want)
+		}
+	}
+}
+
+// starPowerChart has two marked phrases of four notes followed by four more notes
+func starPowerChart() ([]MIDINote, []InputEvent) {
+	notes := make([]MIDINote, 0)
+	events := make([]InputEvent, 0)
+	starts := []float64{0, 0.25, 0.5, 0.75, 1.0, 1.25, 1.5, 1.75, 3.0, 3.25, 3.5, 3.75}
+	for i, start := range starts {
+		notes = append(notes, laneNote(i%3, start, 0.1))
+		events = append(events, Press(i%3, firstNoteTime+start, 0.05)...)
+	}
+	return notes, events
+}
+
+// starPowerPhrases marks the first two groups of four notes of starPowerChart as phrases
+func starPowerPhrases(midiProcessor *MIDIProcessor, game *Game) {
+	midiProcessor.tracks[0].StarPowerPhrases = []Phrase{
+		{StartTime: 0, EndTime: 0.9},
+		{StartTime: 1.0, EndTime: 1.9},
+	}
+}
+
+// activateStarPower activates Star Power between the phrases and the last four notes
+var activateStarPower = InputEvent{Time: firstNoteTime + 2.5, Action: ActionStarPower, Pressed: true}
+
+func TestStarPowerDoublesMultiplier(t *testing.T) {
+	notes, events := starPowerChart()
+	without := runHeadless(t, notes, events, starPowerPhrases)
+	with := runHeadless(t, notes, append(events, activateStarPower), starPowerPhrases)
+	
+	if without.starPowerMeter != 0.5 {
+		t.Errorf("meter = %.2f after two phrases, want 0.5", without.starPowerMeter)
+	}
+	
+	// The last four notes land at combo 9 to 12 and score double under Star Power
+	if diff := with.score - without.score; diff != 100+3*200 {
+		t.Errorf("Star Power added %d points, want %d", diff, 100+3*200)
+	}
+}
+
+func TestMissedPhraseNoteGivesNoStarPower(t *testing.T) {
+	notes, events := starPowerChart()
+	events = append(events[:2], events[4:]...) // Skip the second note
+	game := runHeadless(t, notes, append(events, activateStarPower), starPowerPhrases)
+	
+	if game.starPowerMeter != 0.25 {
+		t.Errorf("meter = %.2f with one phrase broken, want 0.25", game.starPowerMeter)
+	}
+	if game.starPowerEndTime != 0 {
+		t.Errorf("Star Power should not activate below half a meter")
+	}
+}
\ No newline at end of file
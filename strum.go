@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// STRUM_LENIENCY is how long after a note hit by fretting alone a strum is ignored
+// instead of counting as an overstrum, in seconds
+const STRUM_LENIENCY = 0.05
+
+// SetStrumMode switches between lane keys that strum on their own and held frets with a strum bar
+func (g *Game) SetStrumMode(strumMode bool) {
+	g.strumMode = strumMode
+}
+
+// StrumMode returns whether the strum bar input mode is on
+func (g *Game) StrumMode() bool {
+	return g.strumMode
+}
+
+// highestFret returns the highest held fret, or -1 when none are held
+func (g *Game) highestFret() int {
+	for lane := len(g.fretsDown) - 1; lane >= 0; lane-- {
+		if g.fretsDown[lane] {
+			return lane
+		}
+	}
+	return -1
+}
+
+// nextChord returns the earliest unhit notes inside the hit window, more than one for a chord
+func (g *Game) nextChord() []*GameNote {
+	var first *GameNote
+	for i := range g.gameNotes {
+		note := &g.gameNotes[i]
+		if !note.IsActive || note.IsHit || note.IsPressed {
+			continue
+		}
+		if g.calculateAccuracy(g.currentTime-note.StartTime) == Miss {
+			continue
+		}
+		if first == nil || note.StartTime < first.StartTime {
+			first = note
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	
+	chord := make([]*GameNote, 0)
+	for i := range g.gameNotes {
+		note := &g.gameNotes[i]
+		if note.IsActive && !note.IsHit && !note.IsPressed && note.StartTime == first.StartTime {
+			chord = append(chord, note)
+		}
+	}
+	return chord
+}
+
+// fretsMatch returns whether the held frets play a chord. Single notes may be anchored
+// with lower frets held, chords need exactly their frets.
+func (g *Game) fretsMatch(chord []*GameNote) bool {
+	if len(chord) == 1 {
+		return g.highestFret() == chord[0].Lane
+	}
+	
+	var want [3]bool
+	for _, note := range chord {
+		want[note.Lane] = true
+	}
+	return want == g.fretsDown
+}
+
+// handleStrum judges the held frets against the next note or chord
+func (g *Game) handleStrum() {
+	chord := g.nextChord()
+	if len(chord) == 0 {
+		// Strumming through a note just hit by fretting alone is not an overstrum
+		if g.lastFretHit >= 0 && g.currentTime-g.lastFretHit <= STRUM_LENIENCY {
+			return
+		}
+		fmt.Printf("Overstrum!\n")
+		g.penalizePress(g.highestFret())
+		return
+	}
+	
+	if !g.fretsMatch(chord) {
+		fmt.Printf("Wrong frets for the note at %.2fs\n", chord[0].StartTime)
+		g.penalizePress(g.highestFret())
+		return
+	}
+	
+	for _, note := range chord {
+		g.judgePress(note.Lane, true)
+	}
+}
+
+// handleFretPress hammers on a HOPO or tap note when it is the highest held fret
+func (g *Game) handleFretPress(laneIndex int) {
+	if g.highestFret() == laneIndex {
+		g.judgeFret(laneIndex)
+	}
+}
+
+// handleFretRelease pulls off to the highest fret still held
+func (g *Game) handleFretRelease(laneIndex int) {
+	if fret := g.highestFret(); fret >= 0 && fret < laneIndex {
+		g.judgeFret(fret)
+	}
+}
+
+// judgeFret judges a fret change without a strum, remembering when it hit a note
+func (g *Game) judgeFret(laneIndex int) {
+	if g.judgePress(laneIndex, false) {
+		g.lastFretHit = g.currentTime
+	}
+}
+
+// StrumEvents adds a strum after lane presses so events played with lane keys
+// can drive a game in strum mode. Presses at the same time share one strum, and
+// frets that would spoil the fingering are let go before it.
+func StrumEvents(events []InputEvent) []InputEvent {
+	sorted := make([]InputEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time < sorted[j].Time
+	})
+	
+	isPress := func(event InputEvent) bool {
+		return event.Action == ActionLane && event.Pressed
+	}
+	
+	strummed := make([]InputEvent, 0, len(sorted)*2)
+	held := make(map[int]bool)
+	skipRelease := make(map[int]bool) // Lanes already let go ahead of their scripted release
+	for i := 0; i < len(sorted); i++ {
+		event := sorted[i]
+		if !isPress(event) {
+			if event.Action == ActionLane {
+				if skipRelease[event.Lane] {
+					skipRelease[event.Lane] = false
+					continue
+				}
+				held[event.Lane] = false
+			}
+			strummed = append(strummed, event)
+			continue
+		}
+		
+		// Every press at this time is one note or chord
+		end := i + 1
+		for end < len(sorted) && isPress(sorted[end]) && sorted[end].Time == event.Time {
+			end++
+		}
+		chord := make(map[int]bool)
+		highest := -1
+		for _, press := range sorted[i:end] {
+			chord[press.Lane] = true
+			if press.Lane > highest {
+				highest = press.Lane
+			}
+		}
+		
+		// Single notes may keep lower frets anchored, chords need exactly their frets
+		lanes := make([]int, 0, len(held))
+		for lane, down := range held {
+			if down {
+				lanes = append(lanes, lane)
+			}
+		}
+		sort.Ints(lanes)
+		for _, lane := range lanes {
+			if !chord[lane] && (len(chord) > 1 || lane > highest) {
+				strummed = append(strummed, InputEvent{Time: event.Time, Lane: lane, Pressed: false})
+				held[lane] = false
+				skipRelease[lane] = true
+			}
+		}
+		
+		for _, press := range sorted[i:end] {
+			strummed = append(strummed, press)
+			held[press.Lane] = true
+		}
+		strummed = append(strummed, InputEvent{Time: event.Time, Action: ActionStrum, Pressed: true})
+		i = end - 1
+	}
+	return strummed
+}
\ No newline at end of file
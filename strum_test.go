@@ -0,0 +1,126 @@
+package main
+
+import (
+	"testing"
+)
+
+// inStrumMode plays with held frets and the strum bar
+func inStrumMode(midiProcessor *MIDIProcessor, game *Game) {
+	game.SetStrumMode(true)
+}
+
+// strum scripts a strum at the given song time
+func strum(at float64) InputEvent {
+	return InputEvent{Time: at, Action: ActionStrum, Pressed: true}
+}
+
+func TestStrumJudgesHeldFrets(t *testing.T) {
+	notes := []MIDINote{laneNote(1, 0.0, 0.1)}
+	
+	// Fretting alone does nothing, the strum hits
+	events := Press(1, firstNoteTime-0.05, 0.2)
+	events = append(events, strum(firstNoteTime))
+	
+	game := runHeadless(t, notes, events, inStrumMode)
+	if game.perfectHits != 1 {
+		t.Errorf("perfectHits = %d, want 1", game.perfectHits)
+	}
+}
+
+func TestStrumAnchoring(t *testing.T) {
+	notes := []MIDINote{laneNote(1, 0.0, 0.1)}
+	
+	tests := []struct {
+		name  string
+		frets []int
+		hit   bool
+	}{
+		{"anchored below", []int{0, 1}, true},
+		{"higher fret held", []int{1, 2}, false},
+		{"wrong fret", []int{2}, false},
+		{"open strum", nil, false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := []InputEvent{strum(firstNoteTime)}
+			for _, fret := range tt.frets {
+				events = append(events, Press(fret, firstNoteTime-0.05, 0.2)...)
+			}
+			
+			game := runHeadless(t, notes, events, inStrumMode)
+			if hit := game.perfectHits == 1; hit != tt.hit {
+				t.Errorf("hit = %v, want %v", hit, tt.hit)
+			}
+			if !tt.hit && game.wrongLanePresses != 1 {
+				t.Errorf("wrongLanePresses = %d, want 1", game.wrongLanePresses)
+			}
+		})
+	}
+}
+
+func TestStrumChordNeedsExactFrets(t *testing.T) {
+	notes := []MIDINote{laneNote(0, 0.0, 0.1), laneNote(2, 0.0, 0.1)}
+	
+	exact := append(Press(0, firstNoteTime-0.05, 0.2), Press(2, firstNoteTime-0.05, 0.2)...)
+	game := runHeadless(t, notes, append(exact, strum(firstNoteTime)), inStrumMode)
+	if game.perfectHits != 2 {
+		t.Errorf("exact frets: perfectHits = %d, want 2", game.perfectHits)
+	}
+	
+	extra := append(exact, Press(1, firstNoteTime-0.05, 0.2)...)
+	game = runHeadless(t, notes, append(extra, strum(firstNoteTime)), inStrumMode)
+	if game.perfectHits != 0 {
+		t.Errorf("extra fret: perfectHits = %d, want 0", game.perfectHits)
+	}
+}
+
+func TestOverstrum(t *testing.T) {
+	notes := []MIDINote{laneNote(0, 0.0, 0.1), laneNote(0, 1.0, 0.1)}
+	events := Press(0, firstNoteTime-0.05, 1.2)
+	events = append(events, strum(firstNoteTime), strum(firstNoteTime+0.5), strum(firstNoteTime+1.0))
+	
+	game := runHeadless(t, notes, events, inStrumMode)
+	if game.perfectHits != 2 {
+		t.Errorf("perfectHits = %d, want 2", game.perfectHits)
+	}
+	if game.ghostPresses != 1 || game.maxCombo != 1 {
+		t.Errorf("ghostPresses = %d, maxCombo = %d, want 1 and 1", game.ghostPresses, game.maxCombo)
+	}
+}
+
+func TestHammerOnAndPullOff(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(2, 0.1, 0.1), // Hammer-on
+		laneNote(0, 0.2, 0.1), // Pull-off
+	}
+	events := []InputEvent{
+		{Time: firstNoteTime - 0.05, Lane: 0, Pressed: true},
+		strum(firstNoteTime),
+		{Time: firstNoteTime + 0.1, Lane: 2, Pressed: true},
+		{Time: firstNoteTime + 0.2, Lane: 2, Pressed: false},
+		{Time: firstNoteTime + 0.3, Lane: 0, Pressed: false},
+	}
+	
+	game := runHeadless(t, notes, events, inStrumMode)
+	if game.perfectHits != 3 {
+		t.Errorf("perfectHits = %d, want 3", game.perfectHits)
+	}
+}
+
+func TestBotPlaysStrumMode(t *testing.T) {
+	notes := []MIDINote{
+		laneNote(0, 0.0, 0.1),
+		laneNote(1, 0.5, 0.1),
+		laneNote(0, 1.0, 0.1),
+		laneNote(2, 1.0, 0.1),
+		laneNote(1, 1.1, 0.1),
+	}
+	game := runHeadless(t, notes, nil, inStrumMode, func(midiProcessor *MIDIProcessor, game *Game) {
+		game.SetAutoplay(NewBot(1.0, 0))
+	})
+	if game.perfectHits != int32(len(notes)) {
+		t.Errorf("perfectHits = %d, want %d", game.perfectHits, len(notes))
+	}
+}
\ No newline at end of file
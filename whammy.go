@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+)
+
+// Whammy constants
+const (
+	WHAMMY_STEP            = 0.1  // Whammy positions are rounded to this so analog axes do not flood the input
+	WHAMMY_BEND_SEMITONES  = 1.0  // Pitch drop of a held sustain at full whammy
+	WHAMMY_STAR_POWER_RATE = 0.05 // Meter per second gained by fully whammying a Star Power sustain
+	WHAMMY_WOBBLE          = 6.0  // Sideways wobble of a sustain tail at full whammy, in pixels
+)
+
+// quantizeWhammy rounds a whammy position to WHAMMY_STEP between 0 and 1
+func quantizeWhammy(value float32) float32 {
+	steps := math.Round(float64(value) / WHAMMY_STEP)
+	return float32(math.Min(math.Max(steps*WHAMMY_STEP, 0), 1))
+}
+
+// Whammy returns the current whammy position from 0 to 1
+func (g *Game) Whammy() float32 {
+	return g.whammy
+}
+
+// isWhammying returns whether a held sustain is being whammied
+func (g *Game) isWhammying(note *GameNote) bool {
+	return g.whammy > 0 && note.IsPressed && !note.IsHit && g.isSustainedNote(note)
+}
+
+// chargeWhammy adds Star Power for whammying held sustains in intact Star Power
+// phrases, from the last charge up to the given song time
+func (g *Game) chargeWhammy(until float64) {
+	from := g.whammyChargedTo
+	if until <= from {
+		return
+	}
+	g.whammyChargedTo = until
+	
+	for i := range g.gameNotes {
+		note := &g.gameNotes[i]
+		if !g.isWhammying(note) || note.StarPhrase < 0 || g.starPhrases[note.StarPhrase].Broken {
+			continue
+		}
+		
+		// Only the part of the interval the sustain was held for counts
+		start := math.Max(from, note.PressStartTime)
+		end := math.Min(until, note.StartTime+note.Duration)
+		if end > start {
+			g.addStarPower(WHAMMY_STAR_POWER_RATE * float64(g.whammy) * (end - start))
+		}
+	}
+}
+
+// updatePitchBend bends the audio of each lane with a whammied sustain
+func (g *Game) updatePitchBend() {
+	if g.audioManager == nil {
+		return
+	}
+	
+	var bends [3]float64
+	for i := range g.gameNotes {
+		note := &g.gameNotes[i]
+		if g.isWhammying(note) {
+			bends[note.Lane] = float64(g.whammy)
+		}
+	}
+	// The audio keeps the chart's lanes when modifiers move the notes
+	for chartLane, lane := range g.laneMap {
+		g.audioManager.SetPitchBend(chartLane, bends[lane])
+	}
+}
\ No newline at end of file
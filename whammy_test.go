@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuantizeWhammy(t *testing.T) {
+	tests := []struct {
+		value float32
+		want  float32
+	}{
+		{-0.3, 0}, {0.04, 0}, {0.06, 0.1}, {0.52, 0.5}, {1.4, 1},
+	}
+	for _, tt := range tests {
+		if got := quantizeWhammy(tt.value); math.Abs(float64(got-tt.want)) > 1e-6 {
+			t.Errorf("quantizeWhammy(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// starPowerSustain marks the chart's one-second sustain as a Star Power phrase
+func starPowerSustain(midiProcessor *MIDIProcessor, game *Game) {
+	midiProcessor.tracks[0].StarPowerPhrases = []Phrase{{StartTime: 0, EndTime: 0.5}}
+}
+
+func TestWhammyChargesStarPower(t *testing.T) {
+	// Hold one Star Power sustain, whammying from 0.2s in the second time
+	notes := []MIDINote{laneNote(0, 0.0, 1.0)}
+	events := Press(0, firstNoteTime, 1.1)
+	plain := runHeadless(t, notes, events, starPowerSustain)
+	if math.Abs(plain.StarPowerMeter()-STAR_POWER_PER_PHRASE) > 1e-9 {
+		t.Errorf("meter without whammy = %f, want %f", plain.StarPowerMeter(), STAR_POWER_PER_PHRASE)
+	}
+	
+	whammy := InputEvent{Time: firstNoteTime + 0.2, Action: ActionWhammy, Value: 1}
+	whammied := runHeadless(t, notes, append(events, whammy), starPowerSustain)
+	want := STAR_POWER_PER_PHRASE + WHAMMY_STAR_POWER_RATE*0.8
+	if math.Abs(whammied.StarPowerMeter()-want) > 1e-9 {
+		t.Errorf("meter with whammy = %f, want %f", whammied.StarPowerMeter(), want)
+	}
+	
+	// Whammy is part of the replay, so the extra Star Power verifies
+	if _, err := VerifyReplay(whammied.BuildReplay(), whammied.midiProcessor); err != nil {
+		t.Errorf("VerifyReplay failed: %v", err)
+	}
+}
\ No newline at end of file